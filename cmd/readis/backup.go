@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sethrylan/readis/internal/data"
+
+	"github.com/dustin/go-humanize"
+)
+
+// runBackup is the backup subcommand, which writes the DUMP payloads of the keys matching a pattern to a
+// compressed file.
+func runBackup(args []string) int {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: readis backup [flags] [redis URI]")
+		fs.PrintDefaults()
+	}
+	clusterFlag := fs.Bool("c", false, "Use cluster mode")
+	patternFlag := fs.String("pattern", "*", "Glob pattern of the keys to back up")
+	typeFlag := fs.String("type", "", "Only back up keys of this data type")
+	outputFlag := fs.String("o", "", "File to write; by default, standard output")
+	rateFlag := fs.Int("max-keys-per-sec", 0, "Maximum number of keys scanned per second (0 for no limit)")
+	pauseFlag := fs.Duration("scan-pause", 0, "Pause between scan batches on each node; e.g., 100ms")
+	latencyFlag := fs.Duration("max-latency", 0, "Abort when server latency is above this; e.g., 50ms")
+	replicaReadsFlag := fs.Bool("replica-reads", false, "Read from replicas")
+	replicaFlag := fs.String("replica", "", "Address (host:port) of the replica to read from in standalone mode; implies -replica-reads")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	uri := fs.Arg(0)
+	if uri == "" {
+		uri = "redis://localhost:6379"
+	}
+	var opts []data.Option
+	if *replicaReadsFlag || *replicaFlag != "" {
+		opts = append(opts, data.WithReplicaReads(*replicaFlag))
+	}
+	d, err := data.NewData(uri, *clusterFlag, opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid redis URI: %s\n", err)
+		return 1
+	}
+	defer func() {
+		_ = d.Close()
+	}()
+
+	var out io.Writer = os.Stdout
+	if *outputFlag != "" {
+		f, err := os.Create(*outputFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "backup failed: %s\n", err)
+			return 1
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+	bw, err := data.NewBackupWriter(w)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backup failed: %s\n", err)
+		return 1
+	}
+
+	scanOpts := []data.ScanOption{data.WithLazyMetadata()}
+	if *typeFlag != "" {
+		scanOpts = append(scanOpts, data.WithType(*typeFlag))
+	}
+	throttle := data.Throttle{KeysPerSecond: *rateFlag, Pause: *pauseFlag, MaxLatency: *latencyFlag}
+	if throttle.Enabled() {
+		scanOpts = append(scanOpts, data.WithThrottle(throttle))
+	}
+	s := data.NewScan(*patternFlag, exportPageSize, scanOpts...)
+
+	err = d.Backup(appCtx, s, bw, func(n int) {
+		fmt.Fprintf(os.Stderr, "\rbacked up %s keys", humanize.Comma(int64(n)))
+	})
+	if closeErr := bw.Close(); err == nil {
+		err = closeErr
+	}
+	if flushErr := w.Flush(); err == nil {
+		err = flushErr
+	}
+	fmt.Fprintf(os.Stderr, "\rbacked up %s keys\n", humanize.Comma(int64(bw.Count())))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backup failed: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runRestore is the restore subcommand, which restores the keys of a backup with RESTORE.
+func runRestore(args []string) int {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: readis restore [flags] <file, or - for standard input> [redis URI]")
+		fs.PrintDefaults()
+	}
+	clusterFlag := fs.Bool("c", false, "Use cluster mode")
+	replaceFlag := fs.Bool("replace", false, "Replace keys that already exist; by default, they are skipped")
+	absTTLFlag := fs.Bool("absttl", false, "Expire keys when they would have expired on the original server, skipping keys that already have")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	var in io.Reader = os.Stdin
+	if name := fs.Arg(0); name != "-" {
+		f, err := os.Open(name) // #nosec G304 -- the file is given by the user
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "restore failed: %s\n", err)
+			return 1
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		in = f
+	}
+	br, err := data.NewBackupReader(bufio.NewReader(in))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "restore failed: %s\n", err)
+		return 1
+	}
+
+	uri := fs.Arg(1)
+	if uri == "" {
+		uri = "redis://localhost:6379"
+	}
+	d, err := data.NewData(uri, *clusterFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid redis URI: %s\n", err)
+		return 1
+	}
+	defer func() {
+		_ = d.Close()
+	}()
+
+	summary, err := d.Restore(appCtx, br, *replaceFlag, *absTTLFlag, func(s *data.RestoreSummary) {
+		fmt.Fprintf(os.Stderr, "\rrestored %s keys", humanize.Comma(int64(s.Restored)))
+	})
+	fmt.Fprintf(os.Stderr, "\r%s keys in the backup of %s: %s restored, %s existing keys skipped, %s expired keys skipped\n",
+		humanize.Comma(int64(summary.Dumps)), br.Created().Format("2006-01-02 15:04:05"), humanize.Comma(int64(summary.Restored)),
+		humanize.Comma(int64(summary.Existing)), humanize.Comma(int64(summary.Expired)))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "restore failed: %s\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sethrylan/readis/internal/data"
+
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/dustin/go-humanize"
+)
+
+// clusterModel is the cluster topology screen. It shows each shard's slot ranges, master and replicas,
+// and the hash slot and owner of the key selected in the key list.
+type clusterModel struct {
+	data     *data.Data
+	viewport viewport.Model
+	shards   []data.Shard
+	err      error
+	selected int   // index of the selected shard
+	lines    []int // line number of each shard in the viewport content
+
+	key  string // the key selected when the screen was opened
+	slot int64  // the hash slot of key, or -1 if unknown
+}
+
+type topologyMsg struct {
+	shards []data.Shard
+	err    error
+}
+
+// scanNodeMsg requests a scan restricted to a single cluster node.
+type scanNodeMsg struct {
+	addr string
+}
+
+type keySlotMsg struct {
+	key  string
+	slot int64
+	err  error
+}
+
+func newClusterModel(d *data.Data) *clusterModel {
+	c := &clusterModel{
+		data:     d,
+		viewport: viewport.New(),
+		slot:     -1,
+	}
+	c.viewport.Style = viewportStyle
+	return c
+}
+
+func (c *clusterModel) resize(width, height int) {
+	c.viewport.SetWidth(width)
+	c.viewport.SetHeight(height)
+	c.viewport.Style = viewportStyle.Width(width)
+	c.render()
+}
+
+// open refreshes the topology and looks up the hash slot of the selected key.
+func (c *clusterModel) open(key string) tea.Cmd {
+	c.key, c.slot = key, -1
+	c.render()
+	if key == "" {
+		return c.refresh()
+	}
+
+	d := c.data
+	return tea.Batch(c.refresh(), func() tea.Msg {
+		slot, err := d.KeySlot(appCtx, key)
+		return keySlotMsg{key: key, slot: slot, err: err}
+	})
+}
+
+func (c *clusterModel) refresh() tea.Cmd {
+	d := c.data
+	return func() tea.Msg {
+		shards, err := d.Topology(appCtx)
+		return topologyMsg{shards: shards, err: err}
+	}
+}
+
+// Update handles messages for the cluster screen.
+func (c *clusterModel) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case topologyMsg:
+		c.shards, c.err = msg.shards, msg.err
+		c.render()
+	case keySlotMsg:
+		if msg.key == c.key && msg.err == nil {
+			c.slot = msg.slot
+			c.render()
+		}
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "up", "down":
+			if msg.String() == "up" {
+				c.selected = max(c.selected-1, 0)
+			} else {
+				c.selected = min(c.selected+1, len(c.shards)-1)
+			}
+			c.render()
+			if c.selected >= 0 && c.selected < len(c.lines) {
+				c.viewport.EnsureVisible(c.lines[c.selected], 0, 0)
+			}
+		case "enter":
+			if c.selected < len(c.shards) && c.shards[c.selected].Master() != nil {
+				addr := c.shards[c.selected].Master().Addr
+				return func() tea.Msg { return scanNodeMsg{addr: addr} }
+			}
+		default:
+			c.viewport, cmd = c.viewport.Update(msg)
+		}
+	}
+	return cmd
+}
+
+func (c *clusterModel) render() {
+	if c.err != nil {
+		if errors.Is(c.err, data.ErrNotCluster) {
+			c.viewport.SetContent("The cluster topology is only available in cluster mode (-c).")
+			return
+		}
+		c.viewport.SetContent(c.err.Error())
+		return
+	}
+
+	c.selected = min(c.selected, max(len(c.shards)-1, 0))
+	c.lines = c.lines[:0]
+	var sb strings.Builder
+	line := 0
+	for i, shard := range c.shards {
+		c.lines = append(c.lines, line)
+		line += len(shard.Nodes) + 2
+		ranges := make([]string, 0, len(shard.Slots))
+		for _, r := range shard.Slots {
+			ranges = append(ranges, r.String())
+		}
+		marker := "  "
+		if i == c.selected {
+			marker = focusedStyle.Render("▶ ")
+		}
+		title := fmt.Sprintf("shard %d · slots %s (%d)", i+1, strings.Join(ranges, ", "), shard.NumSlots())
+		if c.slot >= 0 && shard.Contains(c.slot) {
+			title += focusedStyle.Render(fmt.Sprintf(" ◀ slot %d", c.slot))
+		}
+		sb.WriteString(marker + lipgloss.NewStyle().Bold(true).Render(title) + "\n")
+
+		for _, n := range shard.Nodes {
+			keys := "? keys"
+			if n.Keys >= 0 {
+				keys = humanize.Comma(n.Keys) + " keys"
+			}
+			fmt.Fprintf(&sb, "    %-8s %-24s %s %14s  %s\n",
+				n.Role,
+				n.Addr,
+				healthStyle(n.Health).Render(fmt.Sprintf("%-8s", n.Health)),
+				keys,
+				shortID(n.ID),
+			)
+		}
+		sb.WriteString("\n")
+	}
+	c.viewport.SetContent(sb.String())
+}
+
+func healthStyle(health string) lipgloss.Style {
+	if health == "online" {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("#00ff00"))
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("#ff0000"))
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+func (c *clusterModel) keyView() string {
+	if c.key == "" {
+		return "no key selected"
+	}
+	if c.slot < 0 {
+		return c.key
+	}
+	owner := "unassigned"
+	if shard := data.ShardForSlot(c.shards, c.slot); shard != nil && shard.Master() != nil {
+		owner = shard.Master().Addr
+	}
+	return fmt.Sprintf("%s → slot %d @ %s", c.key, c.slot, owner)
+}
+
+func (c *clusterModel) summaryView() string {
+	var masters, replicas int
+	for _, shard := range c.shards {
+		for _, n := range shard.Nodes {
+			if n.Role == "master" {
+				masters++
+			} else {
+				replicas++
+			}
+		}
+	}
+	return fmt.Sprintf("%d masters · %d replicas", masters, replicas)
+}
+
+// View renders the cluster screen.
+func (c *clusterModel) View(p panes, uri string) string {
+	return lipgloss.JoinVertical(lipgloss.Left,
+		headerView(p,
+			[]string{inputLine(p, focusedStyle.Render("Cluster")+" · ↑↓ select shard · enter scan shard"), inputLine(p, c.keyView())},
+			[]string{uri, c.summaryView()},
+		),
+		c.viewport.View(),
+	)
+}
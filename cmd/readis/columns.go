@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sethrylan/readis/internal/data"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/dustin/go-humanize"
+)
+
+// minNameWidth is the narrowest the key name column is, so that the header fits short key names.
+const minNameWidth = 20
+
+// column is a column of the key list.
+type column struct {
+	name   string      // used in the config file and the columns menu
+	title  string      // describes the column in the columns menu
+	detail data.Detail // the optional metadata shown in the column, if any
+	value  func(k keyItem) string
+}
+
+// columns are all the columns of the key list, in display order.
+var columns = []column{
+	{name: "type", title: "data type", value: func(k keyItem) string { return k.Datatype }},
+	{name: "name", title: "key name", value: func(k keyItem) string {
+		if k.marked {
+			return "● " + k.Name
+		}
+		return k.Name
+	}},
+	{name: "ttl", title: "time to live", value: keyItem.TTLString},
+	{name: "size", title: "memory usage", value: keyItem.SizeString},
+	{name: "length", title: "element count", detail: data.DetailLength, value: func(k keyItem) string {
+		if d := k.Details; d != nil && d.Length >= 0 {
+			return humanize.Comma(d.Length)
+		}
+		return ""
+	}},
+	{name: "encoding", title: "object encoding", detail: data.DetailEncoding, value: func(k keyItem) string {
+		if k.Details != nil {
+			return k.Details.Encoding
+		}
+		return ""
+	}},
+	{name: "idle", title: "idle time (LRU policies)", detail: data.DetailIdle, value: func(k keyItem) string {
+		if d := k.Details; d != nil && d.Idle >= 0 {
+			return shortDuration(d.Idle)
+		}
+		return ""
+	}},
+	{name: "freq", title: "access frequency (LFU policies)", detail: data.DetailFreq, value: func(k keyItem) string {
+		if d := k.Details; d != nil && d.Freq >= 0 {
+			return strconv.FormatInt(d.Freq, 10)
+		}
+		return ""
+	}},
+}
+
+// defaultColumns are shown when no columns are configured.
+var defaultColumns = []string{"type", "name", "ttl", "size"}
+
+// columnLayout is the set of columns shown in the key list, and their widths.
+type columnLayout struct {
+	columns []column
+	widths  []int
+}
+
+// newColumnLayout creates a layout with the named columns. The key name column is always shown.
+func newColumnLayout(names []string) (*columnLayout, error) {
+	if len(names) == 0 {
+		names = defaultColumns
+	}
+	l := &columnLayout{}
+	for _, name := range names {
+		if !slices.ContainsFunc(columns, func(c column) bool { return c.name == name }) {
+			return nil, fmt.Errorf("unknown column %q; the columns are %s", name, strings.Join(columnNames(), ", "))
+		}
+	}
+	for _, c := range columns {
+		if c.name == "name" || slices.Contains(names, c.name) {
+			l.columns = append(l.columns, c)
+		}
+	}
+	l.fit(nil)
+	return l, nil
+}
+
+func columnNames() []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
+	}
+	return names
+}
+
+// has returns true if the named column is shown.
+func (l *columnLayout) has(name string) bool {
+	return slices.ContainsFunc(l.columns, func(c column) bool { return c.name == name })
+}
+
+// toggle shows or hides the named column. The key name column cannot be hidden.
+func (l *columnLayout) toggle(name string) {
+	if name == "name" {
+		return
+	}
+	var names []string
+	for _, c := range l.columns {
+		if c.name != name {
+			names = append(names, c.name)
+		}
+	}
+	if !l.has(name) {
+		names = append(names, name)
+	}
+	toggled, _ := newColumnLayout(names)
+	*l = *toggled
+}
+
+// details returns the optional metadata needed by the columns.
+func (l *columnLayout) details() data.Detail {
+	var details data.Detail
+	for _, c := range l.columns {
+		details |= c.detail
+	}
+	return details
+}
+
+// fit computes the width of each column from the keys being shown.
+func (l *columnLayout) fit(keys []keyItem) {
+	l.widths = make([]int, len(l.columns))
+	for i, c := range l.columns {
+		width := len(c.name)
+		if c.name == "name" {
+			width = minNameWidth
+		}
+		for _, k := range keys {
+			width = max(width, lipgloss.Width(c.value(k)))
+		}
+		l.widths[i] = width + 1
+	}
+}
+
+// width returns the total width of the columns.
+func (l *columnLayout) width() int {
+	var width int
+	for _, w := range l.widths {
+		width += w
+	}
+	return width
+}
+
+// render renders a key as a row of the key list.
+func (l *columnLayout) render(k keyItem) string {
+	var sb strings.Builder
+	for i, c := range l.columns {
+		value := c.value(k)
+		style := lipgloss.NewStyle().Width(l.widths[i]).Inline(true)
+		if c.name == "type" {
+			value = lipgloss.NewStyle().Background(colorForKeyType(k.Datatype)).Render(value)
+		}
+		sb.WriteString(style.Render(value))
+	}
+	return sb.String()
+}
+
+// header renders the column names, with an arrow next to the sorted column.
+func (l *columnLayout) header(sort sortMode) string {
+	var sb strings.Builder
+	sb.WriteString("  ") // the left padding of the key list rows
+	for i, c := range l.columns {
+		title := c.name
+		if c.name == sort.column {
+			title += " " + sort.indicator()
+		}
+		sb.WriteString(lipgloss.NewStyle().Width(l.widths[i]).Inline(true).Render(title))
+	}
+	return focusedStyle.Bold(true).Render(sb.String())
+}
+
+// shortDuration formats a duration in its largest whole unit; e.g., 45s, 12m, 3h or 5d.
+func shortDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// columnsMenu is the screen for choosing the columns of the key list.
+type columnsMenu struct {
+	layout *columnLayout
+	cursor int
+}
+
+// Update moves the cursor, and shows or hides the column under it.
+func (c *columnsMenu) Update(msg tea.KeyPressMsg) {
+	switch msg.String() {
+	case "up":
+		c.cursor = max(c.cursor-1, 0)
+	case "down":
+		c.cursor = min(c.cursor+1, len(columns)-1)
+	case "space", "enter":
+		c.layout.toggle(columns[c.cursor].name)
+	}
+}
+
+// View renders the columns menu.
+func (c *columnsMenu) View(p panes, uri string) string {
+	var sb strings.Builder
+	for i, col := range columns {
+		marker := "  "
+		if i == c.cursor {
+			marker = focusedStyle.Render("▶ ")
+		}
+		check := "[ ]"
+		if c.layout.has(col.name) {
+			check = "[x]"
+		}
+		fmt.Fprintf(&sb, "%s%s %-10s %s\n", marker, check, col.name, col.title)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		headerView(p,
+			[]string{inputLine(p, focusedStyle.Render("Columns")+" · ↑↓ select · space show/hide"), inputLine(p, "esc applies")},
+			[]string{uri, fmt.Sprintf("%d columns", len(c.layout.columns))},
+		),
+		viewportStyle.Render(sb.String()),
+	)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// config is the optional configuration file; e.g.,
+//
+//	{"columns": ["type", "name", "ttl", "size", "length", "encoding"], "delimiter": ":"}
+type config struct {
+	Columns   []string `json:"columns"`   // the key list columns; see columns
+	Delimiter string   `json:"delimiter"` // separates the namespaces of key names in the namespace tree
+}
+
+// defaultConfigPath returns readis/config.json in the user's config directory; e.g., ~/.config on Linux.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "readis", "config.json")
+}
+
+// loadConfig reads the configuration file. A missing file is the default configuration.
+func loadConfig(path string) (config, error) {
+	var cfg config
+	if path == "" {
+		return cfg, nil
+	}
+	b, err := os.ReadFile(path) // #nosec G304 -- the path is chosen by the user
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/sethrylan/readis/internal/data"
+
+	"github.com/dustin/go-humanize"
+)
+
+// estimateSamples is the number of keys sampled on each master to estimate the number of keys matching a pattern.
+const estimateSamples = 1000
+
+// estimateReport is the estimated number of keys matching the pattern, from a sample of each master.
+type estimateReport struct {
+	estimate *data.Estimate
+}
+
+func newEstimateReport(*reportsModel) analysis {
+	return &estimateReport{}
+}
+
+// collectEstimate estimates the number of keys matching the pattern, rather than scanning them.
+func collectEstimate(ctx context.Context, d *data.Data, run *reportRun, _ func(context.Context) (analysis, error)) (analysis, error) {
+	run.sampling.Store(true)
+	e, err := d.EstimateKeys(ctx, run.pattern, estimateSamples)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range e.Nodes {
+		run.scanned.Add(int64(n.Sampled))
+	}
+	return &estimateReport{estimate: e}, nil
+}
+
+func (e *estimateReport) Add(*data.Key) {}
+
+func (e *estimateReport) Export() any {
+	return e.estimate
+}
+
+func (e *estimateReport) View(int) string {
+	r := e.estimate
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "About %s of %s keys match %s (95%% confidence: %s to %s)\n",
+		humanize.Comma(r.Count), humanize.Comma(r.Keys), r.Pattern, humanize.Comma(r.Low), humanize.Comma(r.High))
+	sb.WriteString("The count report counts them exactly, by scanning every key.\n")
+
+	sb.WriteString(sectionView("By node"))
+	for _, n := range r.Nodes {
+		fmt.Fprintf(&sb, "  %s %12s keys  %-9s %6s of %6s sampled match  ~%s (%s to %s)\n",
+			label(n.Node), humanize.Comma(n.Keys), n.Method, humanize.Comma(int64(n.Matched)), humanize.Comma(int64(n.Sampled)),
+			humanize.Comma(n.Count), humanize.Comma(n.Low), humanize.Comma(n.High))
+	}
+	return sb.String()
+}
+
+// countReport is the exact number of keys matching the pattern, on each node.
+type countReport struct {
+	Keys  int64            `json:"keys"`
+	Nodes map[string]int64 `json:"nodes"`
+}
+
+func newCountReport(*reportsModel) analysis {
+	return &countReport{Nodes: make(map[string]int64)}
+}
+
+func (c *countReport) Add(k *data.Key) {
+	c.Keys++
+	c.Nodes[k.Node]++
+}
+
+func (c *countReport) Export() any {
+	return c
+}
+
+func (c *countReport) View(int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s keys\n", humanize.Comma(c.Keys))
+	sb.WriteString(sectionView("By node"))
+	for _, node := range slices.Sorted(maps.Keys(c.Nodes)) {
+		fmt.Fprintf(&sb, "  %s %12s keys  %s\n", label(node), humanize.Comma(c.Nodes[node]), bar(uint64(c.Nodes[node]), uint64(c.Keys)))
+	}
+	return sb.String()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/sethrylan/readis/internal/data"
+
+	"charm.land/lipgloss/v2"
+	"github.com/dustin/go-humanize"
+)
+
+// ttlReport is the TTL audit: the distribution of TTLs, and the prefixes with the most keys without an expiry.
+type ttlReport struct {
+	analysis *data.TTLAnalysis
+	report   *data.TTLReport
+}
+
+func newTTLReport(r *reportsModel) analysis {
+	return &ttlReport{analysis: data.NewTTLAnalysis(reportTop, r.delimiter, reportDepth)}
+}
+
+func (t *ttlReport) Add(k *data.Key) {
+	t.analysis.Add(k)
+}
+
+func (t *ttlReport) Export() any {
+	return t.result()
+}
+
+func (t *ttlReport) result() *data.TTLReport {
+	if t.report == nil {
+		t.report = t.analysis.Report()
+	}
+	return t.report
+}
+
+func (t *ttlReport) View(width int) string {
+	r := t.result()
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s keys, %s without an expiry (%s)\n",
+		humanize.Comma(int64(r.Keys)), humanize.Comma(int64(r.NoExpiry)), percent(r.NoExpiry, r.Keys))
+
+	sb.WriteString(sectionView("Keys by TTL"))
+	var most uint64
+	for _, b := range r.Buckets {
+		most = max(most, uint64(b.Keys))
+	}
+	for i, b := range r.Buckets {
+		fmt.Fprintf(&sb, "  %-8s %10s keys %10s  %s\n",
+			ttlBucketLabel(r.Buckets, i), humanize.Comma(int64(b.Keys)), humanize.Bytes(b.Memory), bar(uint64(b.Keys), most))
+		if len(b.Samples) > 0 {
+			sb.WriteString(samplesView(b.Samples, width))
+		}
+	}
+
+	sb.WriteString(sectionView("Keys without an expiry by prefix"))
+	for _, p := range r.Prefixes {
+		prefix := p.Prefix
+		if prefix == "" {
+			prefix = "(no prefix)"
+		}
+		fmt.Fprintf(&sb, "  %s %10s of %10s keys (%4s) %10s\n",
+			label(prefix), humanize.Comma(int64(p.NoExpiry)), humanize.Comma(int64(p.Keys)), percent(p.NoExpiry, p.Keys), humanize.Bytes(p.NoExpiryMemory))
+		sb.WriteString(samplesView(p.Samples, width))
+	}
+	return sb.String()
+}
+
+// ttlBucketLabel describes the TTLs in the i-th bucket of the histogram.
+func ttlBucketLabel(buckets []data.TTLBucket, i int) string {
+	switch b := buckets[i]; {
+	case b.Max < 0:
+		return "never"
+	case b.Max == math.MaxInt64:
+		return "> " + shortDuration(buckets[i-1].Max)
+	default:
+		return "≤ " + shortDuration(b.Max)
+	}
+}
+
+// samplesView shows example key names below a line of a report.
+func samplesView(samples []string, width int) string {
+	line := "      e.g. " + strings.Join(samples, ", ")
+	return lipgloss.NewStyle().Faint(true).MaxWidth(max(width, 1)).Render(line) + "\n"
+}
+
+func percent(n, total int) string {
+	if total == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.0f%%", float64(n)/float64(total)*100)
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sethrylan/readis/internal/data"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/dustin/go-humanize"
+)
+
+// exportPageSize is the COUNT of the scans of exports.
+const exportPageSize = 1000
+
+// exportRun is an export being written.
+type exportRun struct {
+	file    string
+	written atomic.Int64
+	cancel  context.CancelFunc
+}
+
+type exportDoneMsg struct {
+	run *exportRun
+	err error
+}
+
+// toggleMark marks or unmarks the selected key for export, and moves to the next key.
+func (m *model) toggleMark() tea.Cmd {
+	name := m.selectedKeyName()
+	i := m.indexOfKey(name)
+	if i < 0 {
+		return nil
+	}
+	k, _ := m.keylist.Items()[i].(keyItem)
+	k.marked = !k.marked
+	if k.marked {
+		m.marked[name] = true
+	} else {
+		delete(m.marked, name)
+	}
+	m.keylist.SetItem(i, k)
+	m.refreshKeys()
+	m.keylist.CursorDown()
+	return m.fetchContent()
+}
+
+// startExport exports the marked keys, or else the keys matching the pattern, with their values, to a file in the
+// working directory; or stops the export being written.
+func (m *model) startExport() tea.Cmd {
+	if m.export != nil {
+		m.export.cancel()
+		return nil
+	}
+
+	var s *data.Scan
+	if len(m.marked) == 0 {
+		pattern, opts, err := parseScanInput(m.textinput.Value())
+		if err != nil {
+			m.notice = err.Error()
+			return nil
+		}
+		if pattern == "" {
+			pattern = "*"
+		}
+		if m.exact {
+			opts = append(opts, data.WithExact())
+		}
+		s = data.NewScan(pattern, exportPageSize, append(m.scanOptions(opts), data.WithLazyMetadata())...)
+	}
+	names := slices.Sorted(maps.Keys(m.marked))
+
+	run := &exportRun{file: fmt.Sprintf("readis-export-%s.%s", time.Now().Format("20060102-150405"), m.exportFormat)}
+	f, err := os.OpenFile(run.file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		m.notice = "export failed: " + err.Error()
+		return nil
+	}
+	w := bufio.NewWriter(f)
+	e, err := data.NewExporter(w, m.exportFormat)
+	if err != nil {
+		_ = f.Close()
+		m.notice = "export failed: " + err.Error()
+		return nil
+	}
+	var ctx context.Context
+	ctx, run.cancel = context.WithCancel(appCtx)
+	m.export = run
+
+	d := m.data
+	return func() tea.Msg {
+		var err error
+		if s != nil {
+			err = d.Export(ctx, s, e, func(n int) { run.written.Store(int64(n)) })
+		} else {
+			err = d.ExportKeys(ctx, names, e)
+		}
+		run.written.Store(int64(e.Count()))
+		if closeErr := e.Close(); err == nil {
+			err = closeErr
+		}
+		if flushErr := w.Flush(); err == nil {
+			err = flushErr
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		return exportDoneMsg{run: run, err: err}
+	}
+}
+
+func (m *model) handleExportDone(msg exportDoneMsg) {
+	if msg.run != m.export {
+		return
+	}
+	m.export = nil
+	written := humanize.Comma(msg.run.written.Load())
+	if msg.err != nil {
+		m.notice = fmt.Sprintf("export stopped after %s keys (%s): %s", written, msg.run.file, msg.err)
+		return
+	}
+	m.notice = fmt.Sprintf("exported %s keys to %s", written, msg.run.file)
+}
+
+// exportView shows the progress of the export being written, and the number of marked keys.
+func (m *model) exportView() []string {
+	var status []string
+	if m.export != nil {
+		status = append(status, fmt.Sprintf("exporting %s keys to %s", humanize.Comma(m.export.written.Load()), m.export.file))
+	}
+	if len(m.marked) > 0 {
+		status = append(status, fmt.Sprintf("%d marked", len(m.marked)))
+	}
+	return status
+}
+
+// runExport is the export subcommand, which exports the keys matching a pattern without the TUI.
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: readis export [flags] [redis URI]")
+		fs.PrintDefaults()
+	}
+	clusterFlag := fs.Bool("c", false, "Use cluster mode")
+	patternFlag := fs.String("pattern", "*", "Glob pattern of the keys to export")
+	typeFlag := fs.String("type", "", "Only export keys of this data type")
+	formatFlag := fs.String("format", "", "Export format: json, ndjson or csv; by default, from the extension of -o, or ndjson")
+	outputFlag := fs.String("o", "", "File to write; by default, standard output")
+	rateFlag := fs.Int("max-keys-per-sec", 0, "Maximum number of keys scanned per second (0 for no limit)")
+	pauseFlag := fs.Duration("scan-pause", 0, "Pause between scan batches on each node; e.g., 100ms")
+	latencyFlag := fs.Duration("max-latency", 0, "Abort when server latency is above this; e.g., 50ms")
+	replicaReadsFlag := fs.Bool("replica-reads", false, "Read from replicas")
+	replicaFlag := fs.String("replica", "", "Address (host:port) of the replica to read from in standalone mode; implies -replica-reads")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	format := *formatFlag
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(*outputFlag), ".")
+		if !slices.Contains(data.ExportFormats, format) {
+			format = data.FormatNDJSON
+		}
+	}
+
+	uri := fs.Arg(0)
+	if uri == "" {
+		uri = "redis://localhost:6379"
+	}
+	var opts []data.Option
+	if *replicaReadsFlag || *replicaFlag != "" {
+		opts = append(opts, data.WithReplicaReads(*replicaFlag))
+	}
+	d, err := data.NewData(uri, *clusterFlag, opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid redis URI: %s\n", err)
+		return 1
+	}
+	defer func() {
+		_ = d.Close()
+	}()
+
+	var out io.Writer = os.Stdout
+	if *outputFlag != "" {
+		f, err := os.Create(*outputFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "export failed: %s\n", err)
+			return 1
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+	e, err := data.NewExporter(w, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export failed: %s\n", err)
+		return 1
+	}
+
+	scanOpts := []data.ScanOption{data.WithLazyMetadata()}
+	if *typeFlag != "" {
+		scanOpts = append(scanOpts, data.WithType(*typeFlag))
+	}
+	throttle := data.Throttle{KeysPerSecond: *rateFlag, Pause: *pauseFlag, MaxLatency: *latencyFlag}
+	if throttle.Enabled() {
+		scanOpts = append(scanOpts, data.WithThrottle(throttle))
+	}
+	s := data.NewScan(*patternFlag, exportPageSize, scanOpts...)
+
+	err = d.Export(appCtx, s, e, func(n int) {
+		fmt.Fprintf(os.Stderr, "\rexported %s keys", humanize.Comma(int64(n)))
+	})
+	if closeErr := e.Close(); err == nil {
+		err = closeErr
+	}
+	if flushErr := w.Flush(); err == nil {
+		err = flushErr
+	}
+	fmt.Fprintf(os.Stderr, "\rexported %s keys\n", humanize.Comma(int64(e.Count())))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export failed: %s\n", err)
+		return 1
+	}
+	return 0
+}
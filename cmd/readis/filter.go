@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/sethrylan/readis/internal/data"
+
+	"charm.land/bubbles/v2/list"
+	tea "charm.land/bubbletea/v2"
+)
+
+// toggleFilterInput moves the focus between the pattern input and the filter input.
+func (m *model) toggleFilterInput() {
+	if m.filtering {
+		m.blurFilter()
+		return
+	}
+	m.filtering = true
+	m.textinput.Blur()
+	m.filterInput.Focus()
+	m.resizeViews()
+}
+
+// blurFilter returns the focus to the pattern input, keeping the filter.
+func (m *model) blurFilter() {
+	m.filtering = false
+	m.filterInput.Blur()
+	m.textinput.Focus()
+	m.resizeViews()
+}
+
+// clearFilter removes the filter, showing every loaded key.
+func (m *model) clearFilter() tea.Cmd {
+	m.filterInput.Reset()
+	m.filter = nil
+	m.blurFilter()
+	return m.refreshKeys()
+}
+
+// updateFilterInput handles a key press in the filter input. The key list is filtered as the filter is typed;
+// while the filter is invalid, the last valid filter stays applied.
+func (m *model) updateFilterInput(msg tea.KeyPressMsg) tea.Cmd {
+	if !isTextInput(msg) {
+		return nil
+	}
+	previous := m.filterInput.Value()
+	var cmd tea.Cmd
+	m.filterInput, cmd = m.filterInput.Update(msg)
+	if m.filterInput.Value() == previous {
+		return cmd
+	}
+
+	f, err := data.ParseFilter(m.filterInput.Value())
+	if err != nil {
+		m.notice = err.Error()
+		return cmd
+	}
+	m.notice = ""
+	m.filter = f
+	if f.Empty() {
+		m.filter = nil
+	}
+	return tea.Batch(cmd, m.refreshKeys(), m.fetchPageMetadata())
+}
+
+// filterNeedsMetadata returns true if the filter matches on metadata that lazily scanned keys are missing.
+func (m *model) filterNeedsMetadata() bool {
+	return m.filter != nil && m.filter.NeedsMetadata()
+}
+
+// applyFilter filters the key list with the current filter, and returns true if the visible keys may have changed.
+// The filter runs synchronously over a copy of the keys, rather than in the list's filter command, so that
+// the visible keys are always up to date with the loaded keys.
+func (m *model) applyFilter() bool {
+	if m.filter == nil {
+		if m.keylist.FilterState() == list.Unfiltered {
+			return false
+		}
+		m.keylist.ResetFilter()
+		return true
+	}
+
+	items := m.keylist.Items()
+	keys := make([]data.Key, len(items))
+	for i, item := range items {
+		if k, ok := item.(keyItem); ok {
+			keys[i] = k.Key
+		}
+	}
+	f := m.filter
+	m.keylist.Filter = func(_ string, targets []string) []list.Rank {
+		var ranks []list.Rank
+		for i := range min(len(targets), len(keys)) {
+			if f.Match(&keys[i]) {
+				ranks = append(ranks, list.Rank{Index: i})
+			}
+		}
+		return ranks
+	}
+	m.keylist.SetFilterText(m.filterInput.Value())
+	return true
+}
+
+// refreshKeys sorts and filters the key list, and groups the keys into namespaces in the tree view,
+// after keys are loaded, updated or removed, keeping the selected key selected.
+func (m *model) refreshKeys() tea.Cmd {
+	selected := m.selectedKeyName()
+	sorted := m.sortKeys()
+	filtered := m.applyFilter()
+	if m.treeMode {
+		m.tree.build(m.visibleKeys())
+	}
+	if !filtered && !sorted {
+		return nil
+	}
+	m.selectKey(selected)
+	// resizeViews resets the viewport, so the content is always fetched again
+	m.resizeViews()
+	return m.fetchContent()
+}
+
+// selectKey selects the named key, if it is visible.
+func (m *model) selectKey(name string) {
+	for i, item := range m.keylist.VisibleItems() {
+		if k, ok := item.(keyItem); ok && k.Name == name {
+			m.keylist.Select(i)
+			return
+		}
+	}
+}
+
+// showsFilter returns true if the filter input is shown in the header.
+func (m *model) showsFilter() bool {
+	return m.filtering || m.filter != nil
+}
+
+// filterCountView shows how many of the loaded keys match the filter.
+func (m *model) filterCountView() string {
+	return fmt.Sprintf("%d of %d shown", len(m.keylist.VisibleItems()), len(m.keylist.Items()))
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sethrylan/readis/internal/data"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/dustin/go-humanize"
+)
+
+// hotKeysWindow is how long MONITOR is sampled for, when OBJECT FREQ is unavailable.
+const hotKeysWindow = 10 * time.Second
+
+// hotReport is the hot keys of each node, from OBJECT FREQ under an LFU policy, or from a MONITOR sample.
+// A hot key can be selected, and opened in the key list.
+type hotReport struct {
+	analysis *data.HotKeyAnalysis
+	report   *data.HotKeyReport
+	cursor   int // the index of the selected key, across nodes
+	line     int // the line of the selected key in the last view
+}
+
+// jumpToKeyMsg opens a key in the key list.
+type jumpToKeyMsg struct {
+	name string
+}
+
+func newHotReport(*reportsModel) analysis {
+	return &hotReport{analysis: data.NewHotKeyAnalysis(reportTop)}
+}
+
+// collectHotKeys scans the keys for their frequency if every master has an LFU policy, or samples MONITOR otherwise.
+func collectHotKeys(ctx context.Context, d *data.Data, run *reportRun, scan func(context.Context) (analysis, error)) (analysis, error) {
+	if lfu, err := d.LFU(ctx); err == nil && lfu {
+		return scan(ctx)
+	}
+	run.sampling.Store(true)
+	report, err := d.SampleHotKeys(ctx, hotKeysWindow, reportTop, func() { run.scanned.Add(1) })
+	return &hotReport{report: report}, err
+}
+
+func (h *hotReport) Add(k *data.Key) {
+	h.analysis.Add(k)
+}
+
+func (h *hotReport) Export() any {
+	return h.result()
+}
+
+func (h *hotReport) result() *data.HotKeyReport {
+	if h.report == nil {
+		h.report = h.analysis.Report()
+	}
+	return h.report
+}
+
+func (h *hotReport) View(int) string {
+	r := h.result()
+	var sb strings.Builder
+	if r.Method == data.HotKeysByFreq {
+		fmt.Fprintf(&sb, "By OBJECT FREQ, the logarithmic access counter of the LFU policy, of %s keys\n", humanize.Comma(r.Samples))
+	} else {
+		fmt.Fprintf(&sb, "By %s key accesses seen by MONITOR in %s; without an LFU policy, OBJECT FREQ is unavailable\n",
+			humanize.Comma(r.Samples), shortDuration(r.Window))
+	}
+
+	lines := strings.Count(sb.String(), "\n")
+	i := 0
+	for _, node := range r.Nodes {
+		title := sectionView(node.Node)
+		sb.WriteString(title)
+		lines += strings.Count(title, "\n")
+		for _, k := range node.Keys {
+			row := fmt.Sprintf("  %s %12s", label(k.Name), humanize.Comma(k.Hits))
+			if i == h.cursor {
+				row = focusedStyle.Render("> " + row[2:])
+				h.line = lines
+			}
+			sb.WriteString(row + "\n")
+			lines++
+			i++
+		}
+	}
+	if i == 0 {
+		sb.WriteString("\nNo keys were accessed.\n")
+	}
+	return sb.String()
+}
+
+func (h *hotReport) keys() []data.HotKey {
+	var keys []data.HotKey
+	for _, node := range h.result().Nodes {
+		keys = append(keys, node.Keys...)
+	}
+	return keys
+}
+
+func (h *hotReport) move(delta int) {
+	h.cursor = max(min(h.cursor+delta, len(h.keys())-1), 0)
+}
+
+func (h *hotReport) selected() (string, bool) {
+	keys := h.keys()
+	if h.cursor >= len(keys) {
+		return "", false
+	}
+	return keys[h.cursor].Name, true
+}
+
+// updateHotKeys handles the selection of the hot keys report, and reports whether the key was handled.
+func (r *reportsModel) updateHotKeys(msg tea.KeyPressMsg) (tea.Cmd, bool) {
+	h, ok := r.results[r.kind().name].(*hotReport)
+	if !ok {
+		return nil, false
+	}
+	switch msg.String() {
+	case "up", "down":
+		if msg.String() == "up" {
+			h.move(-1)
+		} else {
+			h.move(1)
+		}
+		r.render()
+		r.viewport.EnsureVisible(h.line, 0, 0)
+		return nil, true
+	case "v":
+		name, ok := h.selected()
+		if !ok {
+			return nil, true
+		}
+		return func() tea.Msg { return jumpToKeyMsg{name: name} }, true
+	default:
+		return nil, false
+	}
+}
+
+// jumpToKey shows the key in the key list, by looking it up by its exact name.
+func (m *model) jumpToKey(name string) tea.Cmd {
+	if !m.exact {
+		m.toggleExact()
+	}
+	m.keyType = ""
+	m.textinput.SetValue(name)
+	m.screen = keysScreen
+	return m.newScan()
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sethrylan/readis/internal/data"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/dustin/go-humanize"
+)
+
+// coldExpiry is the TTL set on cold keys by the expire action, which leaves time to notice a key that is still
+// needed, and make it persistent again.
+const coldExpiry = 24 * time.Hour
+
+// idleReport is the cold key finder: the keys not accessed recently, and their memory usage by prefix.
+type idleReport struct {
+	analysis *data.IdleAnalysis
+	report   *data.IdleReport
+}
+
+type expireDoneMsg struct {
+	expired, total int
+	err            error
+}
+
+func newIdleReport(r *reportsModel) analysis {
+	return &idleReport{analysis: data.NewIdleAnalysis(reportTop, r.delimiter, reportDepth, r.coldness)}
+}
+
+func (i *idleReport) Add(k *data.Key) {
+	i.analysis.Add(k)
+}
+
+func (i *idleReport) Export() any {
+	return i.result()
+}
+
+func (i *idleReport) result() *data.IdleReport {
+	if i.report == nil {
+		i.report = i.analysis.Report()
+	}
+	return i.report
+}
+
+func (i *idleReport) View(int) string {
+	r := i.result()
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s of %s keys are cold (idle for %s or more, or with an LFU frequency of %d or less), using %s\n",
+		humanize.Comma(int64(r.Cold)), humanize.Comma(int64(r.Keys)), shortDuration(r.Coldness.MinIdle), r.Coldness.MaxFreq,
+		humanize.Bytes(r.ColdMemory))
+	if r.Unavailable > 0 {
+		fmt.Fprintf(&sb, "%s keys have neither an idle time nor a frequency\n", humanize.Comma(int64(r.Unavailable)))
+	}
+
+	sb.WriteString(sectionView("Cold keys by prefix"))
+	for _, p := range r.Prefixes {
+		prefix := p.Prefix
+		if prefix == "" {
+			prefix = "(no prefix)"
+		}
+		fmt.Fprintf(&sb, "  %s %10s keys %10s  %s\n", label(prefix), humanize.Comma(int64(p.Keys)), humanize.Bytes(p.Memory), bar(p.Memory, r.ColdMemory))
+	}
+
+	sb.WriteString(sectionView(fmt.Sprintf("Top %d cold keys by memory", reportTop)))
+	largest := slices.Clone(r.ColdKeys)
+	slices.SortFunc(largest, func(a, b data.ColdKey) int { return cmp.Compare(b.Memory, a.Memory) })
+	for n, k := range largest[:min(len(largest), reportTop)] {
+		fmt.Fprintf(&sb, "  %3d. %s %-6s %10s %s\n", n+1, label(k.Name), k.Type, humanize.Bytes(k.Memory), coldnessView(k))
+	}
+	if r.Truncated {
+		fmt.Fprintf(&sb, "\nOnly the first %s cold keys are listed, exported and expired.\n", humanize.Comma(int64(len(r.ColdKeys))))
+	}
+	return sb.String()
+}
+
+// coldnessView shows the idle time of a cold key, or its frequency under an LFU policy.
+func coldnessView(k data.ColdKey) string {
+	if k.Idle >= 0 {
+		return "idle " + shortDuration(k.Idle)
+	}
+	return fmt.Sprintf("freq %d", k.Freq)
+}
+
+// expireCold sets a TTL of coldExpiry on the keys found by the idle report, once confirmed by pressing x again.
+// Keys that were accessed since the report are left alone.
+func (r *reportsModel) expireCold(confirmed bool) tea.Cmd {
+	report, ok := r.results[r.kind().name].(*idleReport)
+	switch {
+	case !ok:
+		r.status = "run the idle report before expiring cold keys"
+		return nil
+	case r.run != nil:
+		r.status = "wait for the report to finish before expiring cold keys"
+		return nil
+	case len(report.result().ColdKeys) == 0:
+		r.status = "no cold keys to expire"
+		return nil
+	}
+
+	keys := report.result().ColdKeys
+	if !confirmed {
+		r.confirming = true
+		r.status = fmt.Sprintf("press x again to expire %s cold keys in %s", humanize.Comma(int64(len(keys))), shortDuration(coldExpiry))
+		return nil
+	}
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k.Name
+	}
+	r.status = "expiring cold keys"
+	d, coldness := r.data, report.result().Coldness
+	return func() tea.Msg {
+		n, err := d.ExpireCold(appCtx, names, coldness, coldExpiry)
+		return expireDoneMsg{expired: n, total: len(names), err: err}
+	}
+}
+
+func (r *reportsModel) handleExpireDone(msg expireDoneMsg) {
+	r.status = fmt.Sprintf("expired %s of %s cold keys in %s; the others were accessed since the report, or expire sooner",
+		humanize.Comma(int64(msg.expired)), humanize.Comma(int64(msg.total)), shortDuration(coldExpiry))
+	if msg.err != nil {
+		r.status = fmt.Sprintf("expired %s of %s cold keys before failing: %s", humanize.Comma(int64(msg.expired)), humanize.Comma(int64(msg.total)), msg.err)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sethrylan/readis/internal/data"
+
+	"github.com/dustin/go-humanize"
+)
+
+// runImport is the import subcommand, which writes the keys exported to a JSON or NDJSON file.
+func runImport(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: readis import [flags] <file, or - for standard input> [redis URI]")
+		fs.PrintDefaults()
+	}
+	clusterFlag := fs.Bool("c", false, "Use cluster mode")
+	modeFlag := fs.String("mode", data.ImportFail, "What to do with keys that already exist: skip, overwrite or fail")
+	dryRunFlag := fs.Bool("dry-run", false, "Count the new and existing keys, without writing anything")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	var in io.Reader = os.Stdin
+	if name := fs.Arg(0); name != "-" {
+		f, err := os.Open(name) // #nosec G304 -- the file is given by the user
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "import failed: %s\n", err)
+			return 1
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		in = f
+	}
+
+	uri := fs.Arg(1)
+	if uri == "" {
+		uri = "redis://localhost:6379"
+	}
+	d, err := data.NewData(uri, *clusterFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid redis URI: %s\n", err)
+		return 1
+	}
+	defer func() {
+		_ = d.Close()
+	}()
+
+	rr := data.NewRecordReader(bufio.NewReader(in))
+	summary, err := d.Import(appCtx, rr, *modeFlag, *dryRunFlag, func(s *data.ImportSummary) {
+		fmt.Fprintf(os.Stderr, "\rread %s records", humanize.Comma(int64(s.Records)))
+	})
+	if summary != nil {
+		fmt.Fprintf(os.Stderr, "\r%s\n", importSummaryView(summary, *dryRunFlag))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import failed: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// importSummaryView describes what an import did, or would do with a dry run.
+func importSummaryView(s *data.ImportSummary, dryRun bool) string {
+	written := "written"
+	if dryRun {
+		written = "would be written"
+	}
+	lines := []string{fmt.Sprintf("%s records: %s new keys, %s existing keys; %s %s, %s skipped",
+		humanize.Comma(int64(s.Records)), humanize.Comma(int64(s.New)), humanize.Comma(int64(s.Existing)),
+		humanize.Comma(int64(s.Written)), written, humanize.Comma(int64(s.Skipped+s.Unsupported)))}
+	if s.Unsupported > 0 {
+		lines = append(lines, fmt.Sprintf("%s records have no value, such as keys of module types", humanize.Comma(int64(s.Unsupported))))
+	}
+	if len(s.Samples) > 0 {
+		lines = append(lines, "existing keys include "+strings.Join(s.Samples, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
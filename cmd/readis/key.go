@@ -6,13 +6,14 @@ import (
 
 	"github.com/sethrylan/readis/internal/data"
 
-	"charm.land/lipgloss/v2"
 	"github.com/dustin/go-humanize"
 )
 
 // keyItem represents a Redis key, and implements [list.Item]
 type keyItem struct {
 	data.Key
+	layout *columnLayout // the columns of the key list, shared by every item
+	marked bool          // true if the key is marked for export
 }
 
 func (k keyItem) String() string {
@@ -20,6 +21,9 @@ func (k keyItem) String() string {
 }
 
 func (k keyItem) TTLString() string {
+	if k.Partial {
+		return ""
+	}
 	if k.TTL == -1 {
 		return "∞"
 	}
@@ -27,15 +31,17 @@ func (k keyItem) TTLString() string {
 }
 
 func (k keyItem) SizeString() string {
+	if k.Partial {
+		return ""
+	}
 	return humanize.Bytes(k.Size)
 }
 
 func (k keyItem) Title() string {
-	typeLabel := lipgloss.NewStyle().Background(colorForKeyType(k.Datatype)).Render(k.Datatype)
-	return lipgloss.NewStyle().Width(typeLabelWidth).Render(typeLabel) +
-		lipgloss.NewStyle().Width(keyNameWidth).Inline(true).Render(k.Name) +
-		lipgloss.NewStyle().Width(ttlWidth).Render(k.TTLString()) +
-		lipgloss.NewStyle().Width(sizeWidth).Render(k.SizeString())
+	if k.layout == nil {
+		return k.Name
+	}
+	return k.layout.render(k)
 }
 
 func (k keyItem) Description() string {
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"math"
+
+	"github.com/sethrylan/readis/internal/data"
+	"github.com/sethrylan/readis/internal/util"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// liveState tracks whether the key list is kept up to date with keyspace notifications.
+type liveState int
+
+const (
+	liveOff    liveState = iota
+	livePrompt           // waiting for the user to confirm enabling notify-keyspace-events
+	liveOn
+)
+
+type keyspaceEventsMsg struct {
+	enabled bool
+	err     error
+}
+
+type liveStartedMsg struct {
+	ch     <-chan *data.KeyEvent
+	cancel context.CancelFunc
+	err    error
+}
+
+// keyInfoMsg carries the metadata of keys changed by keyspace notifications.
+type keyInfoMsg struct {
+	keys []keyInfo
+}
+
+type keyInfo struct {
+	name string
+	key  *data.Key // nil if the key no longer exists, or does not belong to the scan
+	err  error
+}
+
+// toggleLive cycles the live state: off → (prompt →) on → off.
+func (m *model) toggleLive() tea.Cmd {
+	d := m.data
+	switch m.live {
+	case liveOn:
+		m.stopLive()
+		m.notice = "live updates off"
+		return nil
+	case livePrompt:
+		m.notice = "enabling keyspace events"
+		return func() tea.Msg {
+			err := d.EnableKeyspaceEvents(appCtx)
+			return keyspaceEventsMsg{enabled: err == nil, err: err}
+		}
+	default:
+		return func() tea.Msg {
+			enabled, err := d.KeyspaceEventsEnabled(appCtx)
+			return keyspaceEventsMsg{enabled: enabled, err: err}
+		}
+	}
+}
+
+// handleKeyspaceEvents starts watching if notifications are enabled, or prompts to enable them.
+func (m *model) handleKeyspaceEvents(msg keyspaceEventsMsg) tea.Cmd {
+	switch {
+	case msg.err != nil:
+		m.live = liveOff
+		m.notice = msg.err.Error()
+		return nil
+	case !msg.enabled:
+		m.live = livePrompt
+		m.notice = "notify-keyspace-events is off; " + m.appKeys.Live.Help().Key + " to enable"
+		return nil
+	default:
+		m.live = liveOn
+		m.notice = ""
+		return m.watchKeyspace()
+	}
+}
+
+// watchKeyspace replaces any existing keyspace subscription with one for the current scan pattern.
+func (m *model) watchKeyspace() tea.Cmd {
+	if m.cancelLive != nil {
+		m.cancelLive()
+	}
+	m.liveCh = nil
+
+	pattern := "*"
+	if m.scan != nil {
+		pattern = m.scan.Pattern()
+	}
+
+	d := m.data
+	return func() tea.Msg {
+		ctx, cancel := context.WithCancel(appCtx)
+		ch, err := d.WatchKeyspace(ctx, pattern)
+		if err != nil {
+			cancel()
+			return liveStartedMsg{err: err}
+		}
+		return liveStartedMsg{ch: ch, cancel: cancel}
+	}
+}
+
+func (m *model) stopLive() {
+	if m.cancelLive != nil {
+		m.cancelLive()
+	}
+	m.cancelLive = nil
+	m.liveCh = nil
+	m.live = liveOff
+}
+
+// readKeyEvents drains keyspace notifications without blocking. Removed keys are dropped from the list,
+// and other events trigger a single metadata refresh for the changed keys.
+func (m *model) readKeyEvents() []tea.Cmd {
+	var cmds []tea.Cmd
+	var changed []string
+	requested := make(map[string]bool)
+	selected := m.selectedKeyName()
+	removed := false
+
+read:
+	for {
+		select {
+		case event, ok := <-m.liveCh:
+			if !ok {
+				m.stopLive()
+				m.notice = "live updates stopped"
+				break read
+			}
+			util.Debug("key event: ", event.Key, " ", event.Event)
+			if event.Removed() {
+				if i := m.indexOfKey(event.Key); i >= 0 {
+					m.keylist.RemoveItem(i)
+					removed = true
+				}
+				continue
+			}
+			if !requested[event.Key] {
+				requested[event.Key] = true
+				changed = append(changed, event.Key)
+			}
+		default:
+			break read
+		}
+	}
+
+	if len(changed) > 0 {
+		cmds = append(cmds, m.refreshKeyInfo(changed))
+	}
+	if removed {
+		cmds = append(cmds, m.refreshKeys())
+	}
+	if m.selectedKeyName() != selected {
+		cmds = append(cmds, m.fetchContent())
+	}
+	return cmds
+}
+
+// refreshKeyInfo fetches the metadata of the named keys. Keys that do not belong to the current scan,
+// because of its type filter or its node or slots, are treated as removed.
+func (m *model) refreshKeyInfo(names []string) tea.Cmd {
+	d, s := m.data, m.scan
+	return func() tea.Msg {
+		keys := make([]keyInfo, 0, len(names))
+		for _, name := range names {
+			k, err := d.KeyInfo(appCtx, name)
+			if err == nil && k != nil && s != nil {
+				var in bool
+				if in, err = d.InScan(appCtx, s, k); !in {
+					k = nil
+				}
+			}
+			keys = append(keys, keyInfo{name: name, key: k, err: err})
+		}
+		return keyInfoMsg{keys: keys}
+	}
+}
+
+// handleKeyInfo updates, appends or removes keys after keyspace notifications. The content is fetched
+// again only if the selection changed, or the selected key was updated.
+func (m *model) handleKeyInfo(msg keyInfoMsg) tea.Cmd {
+	selected := m.selectedKeyName()
+	updated := false
+	for _, info := range msg.keys {
+		if info.err != nil {
+			util.Debug("key info: ", info.err.Error())
+			continue
+		}
+		i := m.indexOfKey(info.name)
+		switch {
+		case info.key == nil && i >= 0:
+			m.keylist.RemoveItem(i)
+		case info.key != nil && i >= 0:
+			m.keylist.SetItem(i, m.newKeyItem(info.key))
+			updated = updated || info.name == selected
+		case info.key != nil:
+			m.keylist.InsertItem(math.MaxInt, m.newKeyItem(info.key)) // the list's filter command is replaced by refreshKeys
+		}
+	}
+
+	if cmd := m.refreshKeys(); cmd != nil {
+		return cmd
+	}
+	if m.selectedKeyName() != selected || updated {
+		return m.fetchContent()
+	}
+	return nil
+}
+
+// indexOfKey returns the index of the named key in the key list, or -1.
+func (m *model) indexOfKey(name string) int {
+	for i, item := range m.keylist.Items() {
+		if k, ok := item.(keyItem); ok && k.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *model) selectedKeyName() string {
+	if k, ok := m.keylist.SelectedItem().(keyItem); ok {
+		return k.Name
+	}
+	return ""
+}
@@ -4,11 +4,13 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"slices"
 
 	"github.com/sethrylan/readis/internal/data"
 	"github.com/sethrylan/readis/internal/util"
 
 	tea "charm.land/bubbletea/v2"
+	"github.com/dustin/go-humanize"
 )
 
 // ldflags added by goreleaser
@@ -30,9 +32,36 @@ func run() int {
 		}
 	}()
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export":
+			return runExport(os.Args[2:])
+		case "import":
+			return runImport(os.Args[2:])
+		case "backup":
+			return runBackup(os.Args[2:])
+		case "restore":
+			return runRestore(os.Args[2:])
+		}
+	}
+
 	debugFlag := flag.Bool("debug", false, "Enable debug logging to the debug.log file")
 	clusterFlag := flag.Bool("c", false, "Use cluster mode")
 	versionFlag := flag.Bool("version", false, "Print version and exit")
+	rateFlag := flag.Int("max-keys-per-sec", 0, "Maximum number of keys scanned per second (0 for no limit)")
+	pauseFlag := flag.Duration("scan-pause", 0, "Pause between scan batches on each node; e.g., 100ms")
+	latencyFlag := flag.Duration("max-latency", 0, "Abort scans when server latency is above this; e.g., 50ms")
+	replicaReadsFlag := flag.Bool("replica-reads", false, "Send scans and reads to replicas; writes still go to the primary")
+	eagerFlag := flag.Bool("eager-metadata", false, "Fetch the type, TTL and size of every scanned key, rather than only of the keys shown")
+	noMemoryFlag := flag.Bool("no-memory", false, "Do not collect MEMORY USAGE, and hide the size column")
+	configFlag := flag.String("config", defaultConfigPath(), "Path of the configuration file")
+	samplesFlag := flag.Int("memory-samples", 0, "Number of nested values sampled by MEMORY USAGE in reports (0 for the server default)")
+	coldAfterFlag := flag.Duration("cold-after", defaultColdAfter, "Idle time after which the idle report counts keys as cold")
+	coldFreqFlag := flag.Int64("cold-freq", 0, "OBJECT FREQ at or below which the idle report counts keys as cold, under an LFU maxmemory policy")
+	exportFormatFlag := flag.String("export-format", data.FormatNDJSON, "Format of exports: json, ndjson or csv")
+	replicaFlag := flag.String("replica", "", "Address (host:port) of the replica to read from in standalone mode; implies -replica-reads")
+	rdbFlag := flag.String("rdb", "", "Browse this RDB file, such as a dump.rdb, instead of a Redis server")
+	rdbDBFlag := flag.Int("rdb-db", 0, "Database of the RDB file to browse")
 	flag.Parse()
 
 	if *versionFlag {
@@ -48,17 +77,68 @@ func run() int {
 		}()
 	}
 
-	uri := flag.Arg(0)
-	if uri == "" {
-		uri = "redis://localhost:6379"
-	}
+	var d *data.Data
+	var err error
+	if *rdbFlag != "" {
+		d, err = openRDB(*rdbFlag, *rdbDBFlag)
+		if err != nil {
+			fmt.Printf("invalid RDB file: %s\n", err)
+			return 1
+		}
+	} else {
+		uri := flag.Arg(0)
+		if uri == "" {
+			uri = "redis://localhost:6379"
+		}
 
-	d, err := data.NewData(uri, *clusterFlag)
+		var opts []data.Option
+		if *replicaReadsFlag || *replicaFlag != "" {
+			opts = append(opts, data.WithReplicaReads(*replicaFlag))
+		}
+
+		d, err = data.NewData(uri, *clusterFlag, opts...)
+		if err != nil {
+			fmt.Printf("invalid redis URI: %s\n", err)
+			return 1
+		}
+	}
+	cfg, err := loadConfig(*configFlag)
 	if err != nil {
-		fmt.Printf("invalid redis URI: %s\n", err)
+		fmt.Printf("invalid config: %s\n", err)
 		return 1
 	}
-	p := tea.NewProgram(newModel(d))
+	layout, err := newColumnLayout(cfg.Columns)
+	if err != nil {
+		fmt.Printf("invalid config: %s\n", err)
+		return 1
+	}
+	if *noMemoryFlag && layout.has("size") {
+		layout.toggle("size")
+	}
+	d.SetMemoryUsage(layout.has("size"))
+	d.SetDetails(layout.details())
+
+	m := newModel(d)
+	m.layout = layout
+	m.columns.layout = layout
+	if cfg.Delimiter != "" {
+		m.tree = newNamespaceTree(cfg.Delimiter)
+		m.reports.delimiter = cfg.Delimiter
+	}
+	m.reports.samples = *samplesFlag
+	if !slices.Contains(data.ExportFormats, *exportFormatFlag) {
+		fmt.Printf("invalid export format %q\n", *exportFormatFlag)
+		return 1
+	}
+	m.exportFormat = *exportFormatFlag
+	m.reports.coldness = data.Coldness{MinIdle: *coldAfterFlag, MaxFreq: *coldFreqFlag}
+	m.lazy = !*eagerFlag
+	m.throttle = data.Throttle{
+		KeysPerSecond: *rateFlag,
+		Pause:         *pauseFlag,
+		MaxLatency:    *latencyFlag,
+	}
+	p := tea.NewProgram(m)
 
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("could not start program: %s\n", err)
@@ -67,3 +147,12 @@ func run() int {
 
 	return 0
 }
+
+// openRDB indexes the keys of an RDB file, showing the number of keys read, since large files take a while.
+func openRDB(path string, db int) (*data.Data, error) {
+	d, err := data.OpenRDB(path, db, func(n int) {
+		fmt.Fprintf(os.Stderr, "\rreading %s: %s keys", path, humanize.Comma(int64(n)))
+	})
+	fmt.Fprintln(os.Stderr)
+	return d, err
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/sethrylan/readis/internal/data"
+
+	"github.com/dustin/go-humanize"
+)
+
+// memoryReport is the memory report: the biggest keys by memory and by element count, memory usage by key prefix,
+// and a histogram of key sizes.
+type memoryReport struct {
+	analysis *data.MemoryAnalysis
+	report   *data.MemoryReport
+}
+
+func newMemoryReport(r *reportsModel) analysis {
+	return &memoryReport{analysis: data.NewMemoryAnalysis(reportTop, r.delimiter, reportDepth)}
+}
+
+func (m *memoryReport) Add(k *data.Key) {
+	m.analysis.Add(k)
+}
+
+func (m *memoryReport) Export() any {
+	return m.result()
+}
+
+func (m *memoryReport) result() *data.MemoryReport {
+	if m.report == nil {
+		m.report = m.analysis.Report()
+	}
+	return m.report
+}
+
+func (m *memoryReport) View(int) string {
+	r := m.result()
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s keys using %s\n", humanize.Comma(int64(r.Keys)), humanize.Bytes(r.Memory))
+
+	sb.WriteString(sectionView("Memory by type"))
+	types := slices.Sorted(maps.Keys(r.Types))
+	for _, t := range types {
+		u := r.Types[t]
+		fmt.Fprintf(&sb, "  %s %10s keys %10s  %s\n", label(t), humanize.Comma(int64(u.Keys)), humanize.Bytes(u.Memory), bar(u.Memory, r.Memory))
+	}
+
+	sb.WriteString(sectionView(fmt.Sprintf("Top %d keys by memory", reportTop)))
+	for i, k := range r.TopMemory {
+		fmt.Fprintf(&sb, "  %3d. %s %-6s %10s\n", i+1, label(k.Name), k.Type, humanize.Bytes(k.Memory))
+	}
+
+	sb.WriteString(sectionView(fmt.Sprintf("Top %d keys by element count", reportTop)))
+	for _, t := range slices.Sorted(maps.Keys(r.TopLength)) {
+		sb.WriteString("  " + t + "\n")
+		for i, k := range r.TopLength[t] {
+			fmt.Fprintf(&sb, "  %3d. %s %12s elements %10s\n", i+1, label(k.Name), humanize.Comma(k.Length), humanize.Bytes(k.Memory))
+		}
+	}
+
+	sb.WriteString(sectionView("Memory by prefix"))
+	for _, p := range r.Prefixes {
+		prefix := p.Prefix
+		if prefix == "" {
+			prefix = "(no prefix)"
+		}
+		fmt.Fprintf(&sb, "  %s %10s keys %10s  %s\n", label(prefix), humanize.Comma(int64(p.Keys)), humanize.Bytes(p.Memory), bar(p.Memory, r.Memory))
+	}
+
+	sb.WriteString(sectionView("Key sizes"))
+	var most uint64
+	for _, b := range r.Sizes {
+		most = max(most, uint64(b.Keys))
+	}
+	for i, b := range r.Sizes {
+		bucket := "≤ " + humanize.Bytes(b.Max)
+		if i == len(r.Sizes)-1 {
+			bucket = "> " + humanize.Bytes(r.Sizes[i-1].Max)
+		}
+		fmt.Fprintf(&sb, "  %-10s %10s keys %10s  %s\n", bucket, humanize.Comma(int64(b.Keys)), humanize.Bytes(b.Memory), bar(uint64(b.Keys), most))
+	}
+	return sb.String()
+}
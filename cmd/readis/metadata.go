@@ -0,0 +1,127 @@
+package main
+
+import (
+	"slices"
+	"time"
+
+	"github.com/sethrylan/readis/internal/data"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+const (
+	metadataRetryDelay = 5 * time.Second // how long to wait before fetching metadata again after an error
+	maxMetadataBatch   = 1000            // the most keys fetched at once when sorting every loaded key
+)
+
+// pageMetadataMsg carries the metadata fetched for the keys on the current page.
+type pageMetadataMsg struct {
+	names []string    // the keys that were requested
+	keys  []*data.Key // the requested keys that still exist
+	full  bool        // false if only the types were fetched
+	err   error
+}
+
+// pageKeys returns the keys on the current page of the key list.
+func (m *model) pageKeys() []keyItem {
+	items := m.keylist.VisibleItems()
+	start, end := m.keylist.Paginator.GetSliceBounds(len(items))
+	keys := make([]keyItem, 0, end-start)
+	for _, item := range items[start:end] {
+		if k, ok := item.(keyItem); ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// fetchPageMetadata fetches the metadata of the keys on the current page that were scanned without it.
+// Types are fetched first, so that rows can be colored, followed by the TTL and size. When sorting or
+// filtering by metadata, or summing memory in the namespace tree, every loaded key needs it, so the rest
+// are fetched in batches after the current page.
+func (m *model) fetchPageMetadata() tea.Cmd {
+	if m.fetching || time.Now().Before(m.retryAt) {
+		return nil
+	}
+	var names []string
+	full := true
+	for _, k := range m.pageKeys() {
+		if k.Partial {
+			names = append(names, k.Name)
+			full = full && k.Datatype != ""
+		}
+	}
+	if len(names) == 0 && m.needsAllMetadata() {
+		for _, item := range m.keylist.Items() {
+			if k, ok := item.(keyItem); ok && k.Partial {
+				names = append(names, k.Name)
+				full = full && k.Datatype != ""
+				if len(names) == maxMetadataBatch {
+					break
+				}
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	m.fetching = true
+	d := m.data
+	return func() tea.Msg {
+		var keys []*data.Key
+		var err error
+		if full {
+			keys, err = d.KeysInfo(appCtx, names)
+		} else {
+			keys, err = d.KeyTypes(appCtx, names)
+		}
+		return pageMetadataMsg{names: names, keys: keys, full: full, err: err}
+	}
+}
+
+// needsAllMetadata returns true if every loaded key needs its metadata, rather than only the current page.
+func (m *model) needsAllMetadata() bool {
+	return m.sort.needsMetadata() || m.filterNeedsMetadata() || (m.treeMode && m.data.MemoryUsage())
+}
+
+// handlePageMetadata updates the rows with the fetched metadata, removes the keys that no longer exist,
+// and continues with the TTL and size once the types are known.
+func (m *model) handlePageMetadata(msg pageMetadataMsg) tea.Cmd {
+	m.fetching = false
+	if msg.err != nil {
+		m.notice = msg.err.Error()
+		m.retryAt = time.Now().Add(metadataRetryDelay)
+		return nil
+	}
+
+	found := make(map[string]*data.Key, len(msg.keys))
+	for _, k := range msg.keys {
+		found[k.Name] = k
+	}
+	index := make(map[string]int, len(m.keylist.Items()))
+	for i, item := range m.keylist.Items() {
+		if k, ok := item.(keyItem); ok {
+			index[k.Name] = i
+		}
+	}
+
+	var removed []int
+	for _, name := range msg.names {
+		i, ok := index[name]
+		if !ok {
+			continue // no longer listed; e.g., a new scan was started
+		}
+		if k, ok := found[name]; ok {
+			m.keylist.SetItem(i, m.newKeyItem(k))
+		} else {
+			removed = append(removed, i)
+		}
+	}
+	slices.Sort(removed)
+	for _, i := range slices.Backward(removed) {
+		m.keylist.RemoveItem(i)
+	}
+
+	return tea.Batch(m.refreshKeys(), m.fetchPageMetadata())
+}
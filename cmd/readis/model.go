@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"slices"
+	"strings"
 	"time"
 	"unicode"
 
@@ -27,6 +29,17 @@ import (
 // Bubble Tea callbacks that do not receive a context parameter.
 var appCtx, appCancel = context.WithCancel(context.Background()) //nolint:gochecknoglobals
 
+// screen identifies the view shown below the header.
+type screen int
+
+const (
+	keysScreen screen = iota
+	pubsubScreen
+	clusterScreen
+	columnsScreen
+	reportsScreen
+)
+
 type model struct {
 	data       *data.Data
 	scan       *data.Scan
@@ -35,12 +48,43 @@ type model struct {
 	spinner    spinner.Model
 
 	textinput   textinput.Model
+	filterInput textinput.Model
 	keylist     list.Model
 	viewport    viewport.Model
 	initialized bool
 	totalKeys   int64
 
+	screen    screen
+	appKeys   *ui.AppKeyMap
+	pubsub    *pubsubModel
+	cluster   *clusterModel
+	columns   *columnsMenu
+	reports   *reportsModel
+	layout    *columnLayout // the columns of the key list
+	sort      sortMode      // the order of the key list
+	filter    *data.Filter  // if set, only the loaded keys matching it are shown
+	filtering bool          // true while the filter input has the focus
+	tree      *namespaceTree
+	treeMode  bool   // if true, keys are grouped into namespaces instead of listed
+	notice    string // shown below the input when not scanning
+	exact     bool   // if true, the input is an exact key name rather than a glob pattern
+	keyType   string // if set, only keys of this type are scanned
+
+	marked       map[string]bool // the names of the keys marked for export
+	export       *exportRun      // the export being written, if any
+	exportFormat string          // the format of exports; one of data.ExportFormats
+
+	throttle data.Throttle // limits the load of scans on the server
+	lazy     bool          // if true, metadata is only fetched for the keys on the current page
+	fetching bool          // true while metadata is being fetched for the current page
+	retryAt  time.Time     // after an error, metadata is not fetched again until this time
+
+	live       liveState
+	liveCh     <-chan *data.KeyEvent // receive-only channel for keyspace notifications
+	cancelLive context.CancelFunc    // cancels the keyspace subscription
+
 	windowHeight, windowWidth int
+	panes                     panes // the widths of the key list and value panes, computed by resizeViews
 	hasDarkBg                 bool
 }
 
@@ -76,34 +120,36 @@ func tickTotalKeys() tea.Cmd {
 //
 // So we keep track of the longest key name and the window size for resizing.
 func (m *model) resizeViews() {
-	// Find the longest key name, we'll use that to resize the left hand pane
-	for _, k := range m.keylist.VisibleItems() {
-		if k, ok := k.(keyItem); ok {
-			keyNameWidth = max(keyNameWidth, len(k.Name)+1)
-		}
-	}
+	// Fit the columns to the keys on the page, we'll use that to resize the left hand pane
+	m.layout.fit(m.pageKeys())
+	m.panes.left = m.layout.width() + 3
 
 	hMargin, vMargin := docStyle.GetFrameSize()
 	headerHeight := lipgloss.Height(m.headerView())
-	keylistWidth := leftHandWidth()
+	keylistWidth := m.panes.left
 	keylistHeight := m.windowHeight - vMargin - headerHeight
-	m.keylist.SetSize(keylistWidth, keylistHeight)
+	m.keylist.SetSize(keylistWidth, keylistHeight-1) // room for the column header
+	m.tree.width, m.tree.height = keylistWidth, keylistHeight
 
-	util.Debug(fmt.Sprintf("keyNameWidth: %d", keyNameWidth))
+	util.Debug(fmt.Sprintf("column widths: %v", m.layout.widths))
 	util.Debug(fmt.Sprintf("window width: %d, height: %d", m.windowWidth, m.windowHeight))
 	util.Debug(fmt.Sprintf("frame width: %d, height: %d", hMargin, vMargin))
 	util.Debug(fmt.Sprintf("keylist width: %d, height: %d", keylistWidth, keylistHeight))
 
-	// Update rightHandWidth (also used for styling the status block)
-	rightHandWidth = m.windowWidth - hMargin - leftHandWidth()
+	// The right hand pane takes the rest of the window (also used for styling the status block)
+	m.panes.right = m.windowWidth - hMargin - m.panes.left
 
-	viewportWidth := rightHandWidth + viewportStyle.GetHorizontalBorderSize()
+	viewportWidth := m.panes.right + viewportStyle.GetHorizontalBorderSize()
 	viewportHeight := keylistHeight - headerHeight
 	m.viewport = viewport.New()
 	m.viewport.SetWidth(viewportWidth)
 	m.viewport.SetHeight(viewportHeight)
 	m.viewport.Style = viewportStyle.Width(viewportWidth)
 	m.viewport.YPosition = headerHeight
+
+	m.pubsub.resize(keylistWidth, keylistHeight, viewportWidth, viewportHeight)
+	m.cluster.resize(m.windowWidth-hMargin, keylistHeight)
+	m.reports.resize(m.windowWidth-hMargin, keylistHeight)
 }
 
 func newModel(d *data.Data) *model {
@@ -112,7 +158,17 @@ func newModel(d *data.Data) *model {
 	}
 
 	km := ui.NewListKeyMap()
+	m.appKeys = ui.NewAppKeyMap()
 	m.data = d
+	m.panes = newPanes()
+	m.pubsub = newPubSubModel(d)
+	m.cluster = newClusterModel(d)
+	m.layout, _ = newColumnLayout(defaultColumns)
+	m.columns = &columnsMenu{layout: m.layout}
+	m.reports = newReportsModel(d)
+	m.marked = make(map[string]bool)
+	m.exportFormat = data.FormatNDJSON
+	m.tree = newNamespaceTree(defaultDelimiter)
 
 	m.spinner = spinner.New(
 		spinner.WithSpinner(spinner.Spinner{
@@ -122,15 +178,10 @@ func newModel(d *data.Data) *model {
 		spinner.WithStyle(spinnerStyle),
 	)
 
-	m.textinput = textinput.New()
-	m.textinput.CharLimit = 80
-	m.textinput.Placeholder = "Pattern"
-	m.textinput.Focus()
-	s := m.textinput.Styles()
-	s.Cursor.Color = lipgloss.Color("#c9510c")
-	s.Focused.Prompt = focusedStyle
-	s.Focused.Text = focusedStyle
-	m.textinput.SetStyles(s)
+	m.textinput = newTextInput("Pattern")
+	m.filterInput = newTextInput("Filter: user type:hash size>1MB ttl<60s /regex/")
+	m.filterInput.Prompt = "/ "
+	m.filterInput.Blur()
 
 	delegate := list.NewDefaultDelegate()
 	delegate.ShowDescription = false
@@ -156,11 +207,39 @@ func newModel(d *data.Data) *model {
 		return []key.Binding{
 			km.PageNext,
 			km.PagePrev,
+			m.appKeys.PubSub,
+			m.appKeys.Cluster,
+			m.appKeys.Live,
+			m.appKeys.Exact,
+			m.appKeys.Type,
+			m.appKeys.Memory,
+			m.appKeys.Columns,
+			m.appKeys.Filter,
+			m.appKeys.Sort,
+			m.appKeys.Reverse,
+			m.appKeys.Tree,
+			m.appKeys.Reports,
+			m.appKeys.Mark,
+			m.appKeys.Export,
 		}
 	}
 	return m
 }
 
+// newTextInput creates a focused text input with the application styles.
+func newTextInput(placeholder string) textinput.Model {
+	ti := textinput.New()
+	ti.CharLimit = 80
+	ti.Placeholder = placeholder
+	ti.Focus()
+	s := ti.Styles()
+	s.Cursor.Color = lipgloss.Color("#c9510c")
+	s.Focused.Prompt = focusedStyle
+	s.Focused.Text = focusedStyle
+	ti.SetStyles(s)
+	return ti
+}
+
 func (m *model) Init() tea.Cmd {
 	return tea.Batch(m.spinner.Tick, m.refreshTotalKeys, tickTotalKeys(), tea.RequestBackgroundColor)
 }
@@ -175,10 +254,212 @@ func (m *model) startScan() {
 	m.scanCh = m.data.ScanAsync(scanCtx, m.scan)
 }
 
+// newScan starts a new scan for the input, replacing the current results.
+func (m *model) newScan() tea.Cmd {
+	pattern, opts, err := parseScanInput(m.textinput.Value())
+	if err != nil {
+		m.notice = err.Error()
+		return nil
+	}
+	if pattern == "" && len(opts) > 0 {
+		pattern = "*"
+	}
+	if m.exact {
+		opts = append(opts, data.WithExact())
+	}
+
+	m.keylist.SetItems([]list.Item{})                                    // clear items
+	clear(m.marked)                                                      // unmark the cleared keys
+	m.scan = data.NewScan(pattern, m.pageSize(), m.scanOptions(opts)...) // initialize scan
+	m.startScan()                                                        // cancel previous scan and start new one
+	m.stopNamespaceScan()
+	clear(m.tree.scans) // namespaces are scanned again with the new pattern
+	m.notice = m.scan.Scope()
+	if m.live == liveOn {
+		return m.watchKeyspace() // follow the new pattern
+	}
+	return nil
+}
+
+// scanOptions adds the type filter, throttle and lazy metadata options to the options parsed from the input.
+func (m *model) scanOptions(opts []data.ScanOption) []data.ScanOption {
+	if m.keyType != "" {
+		opts = append(opts, data.WithType(m.keyType))
+	}
+	if m.throttle.Enabled() {
+		opts = append(opts, data.WithThrottle(m.throttle))
+	}
+	if m.lazy {
+		opts = append(opts, data.WithLazyMetadata())
+	}
+	return opts
+}
+
+// pageSize estimates the number of keys on a page of the key list.
+func (m *model) pageSize() int {
+	return m.keylist.Paginator.ItemsOnPage(1000)
+}
+
+// toggleExact switches the input between glob patterns and exact key names.
+// The prompt shows the mode: "> " for patterns and "= " for exact names.
+func (m *model) toggleExact() {
+	m.exact = !m.exact
+	if m.exact {
+		m.textinput.Prompt = "= "
+		m.notice = "exact match"
+	} else {
+		m.textinput.Prompt = "> "
+		m.notice = "pattern match"
+	}
+}
+
+// cycleType selects the next (or previous) data type to filter by, where "" is all types.
+// If keys have already been scanned, the scan is restarted with the new type.
+func (m *model) cycleType(reverse bool) tea.Cmd {
+	types := append([]string{""}, data.KeyTypes...)
+	i := slices.Index(types, m.keyType)
+	if reverse {
+		i += len(types) - 2
+	}
+	m.keyType = types[(i+1)%len(types)]
+	if m.scan == nil {
+		return nil
+	}
+	return m.newScan()
+}
+
+// toggleMemoryUsage turns MEMORY USAGE and the size column on or off.
+func (m *model) toggleMemoryUsage() tea.Cmd {
+	m.layout.toggle("size")
+	if m.layout.has("size") {
+		m.notice = "size column on"
+	} else {
+		m.notice = "size column off"
+	}
+	return m.applyColumns()
+}
+
+// applyColumns collects the metadata needed by the columns. Keys found without it are missing values,
+// so the scan is restarted if the metadata changed.
+func (m *model) applyColumns() tea.Cmd {
+	memory, details := m.layout.has("size"), m.layout.details()
+	changed := memory != m.data.MemoryUsage() || details != m.data.Details()
+	m.data.SetMemoryUsage(memory)
+	m.data.SetDetails(details)
+	m.resizeViews()
+	if !changed || m.scan == nil {
+		return nil
+	}
+	return m.newScan()
+}
+
+// newKeyItem creates a list item for a key, with the columns of the key list.
+func (m *model) newKeyItem(k *data.Key) keyItem {
+	return keyItem{Key: *k, layout: m.layout, marked: m.marked[k.Name]}
+}
+
+// typeView shows the type filter next to the input.
+func (m *model) typeView() string {
+	if m.keyType == "" {
+		return "all types"
+	}
+	return lipgloss.NewStyle().Background(colorForKeyType(m.keyType)).Render(m.keyType)
+}
+
+// parseScanInput splits the input into a pattern and scan options. The pattern may be preceded by
+// "@host:port" to scan a single cluster node, or by "#slots" (e.g., "#0-100,5000") to list the keys
+// in those hash slots.
+func parseScanInput(input string) (string, []data.ScanOption, error) {
+	var opts []data.ScanOption
+	for {
+		switch {
+		case strings.HasPrefix(input, "@"):
+			var addr string
+			addr, input, _ = strings.Cut(input[1:], " ")
+			opts = append(opts, data.WithNode(addr))
+		case strings.HasPrefix(input, "#"):
+			var spec string
+			spec, input, _ = strings.Cut(input[1:], " ")
+			slots, err := data.ParseSlotRanges(spec)
+			if err != nil {
+				return "", nil, err
+			}
+			opts = append(opts, data.WithSlots(slots...))
+		default:
+			return input, opts, nil
+		}
+		input = strings.TrimLeft(input, " ")
+	}
+}
+
 func (m *model) refreshTotalKeys() tea.Msg {
 	return totalKeysMsg(m.data.TotalKeys(appCtx))
 }
 
+// quit cancels all background work and closes the connection.
+func (m *model) quit() tea.Cmd {
+	if m.cancelScan != nil {
+		m.cancelScan()
+	}
+	m.stopNamespaceScan()
+	m.reports.stop()
+	if m.export != nil {
+		m.export.cancel()
+	}
+	m.pubsub.unsubscribe()
+	m.stopLive()
+	appCancel()
+	err := m.data.Close()
+	if err != nil {
+		fmt.Println("error closing connection: ", err)
+	}
+	return tea.Quit
+}
+
+// updateScreen handles key presses while a screen other than the key list is active.
+func (m *model) updateScreen(msg tea.KeyPressMsg) tea.Cmd {
+	switch {
+	case msg.String() == "ctrl+c":
+		return m.quit()
+	case key.Matches(msg, m.appKeys.Back):
+		if m.screen == columnsScreen {
+			m.screen = keysScreen
+			return m.applyColumns()
+		}
+		m.screen = keysScreen
+		return nil
+	}
+
+	switch m.screen {
+	case pubsubScreen:
+		return m.pubsub.Update(msg)
+	case clusterScreen:
+		return m.cluster.Update(msg)
+	case columnsScreen:
+		m.columns.Update(msg)
+		return nil
+	case reportsScreen:
+		if msg.String() == "enter" {
+			return m.startReport()
+		}
+		return m.reports.Update(msg)
+	default:
+		return nil
+	}
+}
+
+// refreshScreen periodically refreshes the active screen.
+func (m *model) refreshScreen() tea.Cmd {
+	switch m.screen {
+	case pubsubScreen:
+		return m.pubsub.refreshChannels()
+	case clusterScreen:
+		return m.cluster.refresh()
+	default:
+		return nil
+	}
+}
+
 func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	cmds := make([]tea.Cmd, 0, 4)
 	var cmd tea.Cmd
@@ -188,7 +469,44 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.totalKeys = int64(msg)
 		return m, nil
 	case refreshTotalKeysMsg:
-		return m, tea.Batch(m.refreshTotalKeys, tickTotalKeys())
+		return m, tea.Batch(m.refreshTotalKeys, tickTotalKeys(), m.refreshScreen())
+	case channelsMsg, subscribedMsg, pubsubStatusMsg:
+		return m, m.pubsub.Update(msg)
+	case topologyMsg, keySlotMsg:
+		return m, m.cluster.Update(msg)
+	case reportDoneMsg, expireDoneMsg:
+		return m, m.reports.Update(msg)
+	case exportDoneMsg:
+		m.handleExportDone(msg)
+		return m, nil
+	case jumpToKeyMsg:
+		return m, m.jumpToKey(msg.name)
+	case scanNodeMsg:
+		// scan the node chosen on the cluster screen, keeping the current pattern
+		pattern, _, err := parseScanInput(m.textinput.Value())
+		if err != nil || pattern == "" {
+			pattern = "*"
+		}
+		m.textinput.SetValue("@" + msg.addr + " " + pattern)
+		m.screen = keysScreen
+		return m, m.newScan()
+	case keyspaceEventsMsg:
+		return m, m.handleKeyspaceEvents(msg)
+	case liveStartedMsg:
+		switch {
+		case msg.err != nil:
+			m.stopLive()
+			m.notice = msg.err.Error()
+		case m.live != liveOn:
+			msg.cancel() // live updates were turned off while subscribing
+		default:
+			m.liveCh, m.cancelLive = msg.ch, msg.cancel
+		}
+		return m, nil
+	case keyInfoMsg:
+		return m, m.handleKeyInfo(msg)
+	case pageMetadataMsg:
+		return m, m.handlePageMetadata(msg)
 	case fetchContentMsg:
 		if m.keylist.SelectedItem() != nil {
 			if sel, ok := m.keylist.SelectedItem().(keyItem); ok && sel.Name == msg.keyName {
@@ -201,28 +519,90 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case tea.KeyPressMsg:
 		util.Debug("key pressed: ", msg.String())
-		switch msg.String() {
-		case "ctrl+c", "esc", "q":
-			if m.cancelScan != nil {
-				m.cancelScan()
+		if m.screen != keysScreen {
+			return m, m.updateScreen(msg)
+		}
+		if m.data.Offline() && m.needsServer(msg) {
+			m.notice = data.ErrOffline.Error()
+			return m, nil
+		}
+		if key.Matches(msg, m.appKeys.PubSub) {
+			m.screen = pubsubScreen
+			return m, m.pubsub.refreshChannels()
+		}
+		if key.Matches(msg, m.appKeys.Cluster) {
+			m.screen = clusterScreen
+			return m, m.cluster.open(m.selectedKeyName())
+		}
+		if key.Matches(msg, m.appKeys.Live) {
+			return m, m.toggleLive()
+		}
+		if key.Matches(msg, m.appKeys.Exact) {
+			m.toggleExact()
+			return m, nil
+		}
+		if key.Matches(msg, m.appKeys.Type) {
+			return m, m.cycleType(msg.String() == "shift+tab")
+		}
+		if key.Matches(msg, m.appKeys.Memory) {
+			return m, m.toggleMemoryUsage()
+		}
+		if key.Matches(msg, m.appKeys.Columns) {
+			m.screen = columnsScreen
+			return m, nil
+		}
+		if key.Matches(msg, m.appKeys.Reports) {
+			m.screen = reportsScreen
+			return m, nil
+		}
+		if key.Matches(msg, m.appKeys.Export) {
+			return m, m.startExport()
+		}
+		if key.Matches(msg, m.appKeys.Mark) && !m.treeMode {
+			return m, m.toggleMark()
+		}
+		if key.Matches(msg, m.appKeys.Filter) {
+			m.toggleFilterInput()
+			return m, nil
+		}
+		if key.Matches(msg, m.appKeys.Tree) {
+			return m, m.toggleTree()
+		}
+		if m.filtering {
+			switch msg.String() {
+			case "enter":
+				m.blurFilter()
+				return m, nil
+			case "esc":
+				return m, m.clearFilter()
+			case "ctrl+c", "up", "down", "left", "right", "home", "end", "pgup", "pgdown":
+				// the key list is navigated as usual while filtering
+			default:
+				return m, m.updateFilterInput(msg)
 			}
-			appCancel()
-			err := m.data.Close()
-			if err != nil {
-				fmt.Println("error closing connection: ", err)
+		}
+		if m.treeMode {
+			if cmd, ok := m.updateTree(msg); ok {
+				return m, cmd
 			}
-			return m, tea.Quit
+		}
+		if key.Matches(msg, m.appKeys.Sort) {
+			return m, m.cycleSort()
+		}
+		if key.Matches(msg, m.appKeys.Reverse) {
+			return m, m.reverseSort()
+		}
+		switch msg.String() {
+		case "ctrl+c", "esc", "q":
+			return m, m.quit()
 		case "enter":
-			m.keylist.SetItems([]list.Item{})                    // clear items
-			pageSize := m.keylist.Paginator.ItemsOnPage(1000)    // estimate the page size
-			m.scan = data.NewScan(m.textinput.Value(), pageSize) // initialize scan
-			m.startScan()                                        // cancel previous scan and start new one
+			cmds = append(cmds, m.newScan())
 			m.keylist, cmd = m.keylist.Update(msg)
 			return m, tea.Batch(append(cmds, cmd)...)
 		case "up", "down", "left", "?", "home", "end", "pgdown", "pgup":
 			m.keylist, cmd = m.keylist.Update(msg)
 			m.resizeViews()
-			return m, tea.Batch(cmd, m.fetchContent())
+			return m, tea.Batch(cmd, m.fetchContent(), m.fetchPageMetadata())
 		case "ctrl+t", "right":
 			// If on the last page and the current scan is complete,
 			// then we can scan for the next page of results.
@@ -232,7 +612,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			m.keylist, cmd = m.keylist.Update(msg)
 			m.resizeViews()
-			cmds = append(cmds, cmd, m.fetchContent())
+			cmds = append(cmds, cmd, m.fetchContent(), m.fetchPageMetadata())
 			return m, tea.Batch(cmds...)
 		}
 	case tea.WindowSizeMsg:
@@ -247,6 +627,8 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	cmds = append(cmds, m.readAndInsert()...)
+	cmds = append(cmds, m.readKeyEvents()...)
+	cmds = append(cmds, m.fetchPageMetadata())
 
 	if m.viewport.VisibleLineCount() == 0 {
 		// On new searches, update the viewport with the first list item.
@@ -264,6 +646,13 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	} else {
 		m.textinput, cmd = m.textinput.Update(msg)
 		cmds = append(cmds, cmd)
+		m.filterInput, cmd = m.filterInput.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+
+	// Keep draining subscriptions, even when the Pub/Sub screen is not active
+	if _, ok := msg.(tea.KeyPressMsg); !ok {
+		cmds = append(cmds, m.pubsub.Update(msg))
 	}
 
 	// Tick the spinner
@@ -273,18 +662,27 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// needsServer reports whether the key opens a feature that needs a Redis server, rather than an RDB file.
+func (m *model) needsServer(msg tea.KeyPressMsg) bool {
+	return key.Matches(msg, m.appKeys.PubSub, m.appKeys.Cluster, m.appKeys.Live, m.appKeys.Export, m.appKeys.Mark)
+}
+
 func (m *model) readAndInsert() []tea.Cmd {
 	var cmds []tea.Cmd
+	inserted := m.readNamespaceScan()
 	for {
 		select {
 		case k, ok := <-m.scanCh:
 			if !ok {
-				return cmds
+				return append(cmds, m.refreshKeys())
 			}
 			util.Debug("found key: ", k.Name)
-			cmd := m.keylist.InsertItem(math.MaxInt, keyItem{*k})
-			cmds = append(cmds, cmd)
+			m.keylist.InsertItem(math.MaxInt, m.newKeyItem(k)) // the list's filter command is replaced by refreshKeys
+			inserted = true
 		default:
+			if inserted {
+				cmds = append(cmds, m.refreshKeys())
+			}
 			return cmds
 		}
 	}
@@ -292,9 +690,48 @@ func (m *model) readAndInsert() []tea.Cmd {
 
 func (m *model) spinnerView() string {
 	if m.scan == nil || !m.scan.Scanning() {
-		return " "
+		status := make([]string, 0, 2)
+		if m.notice != "" {
+			status = append(status, m.notice)
+		}
+		if m.scan != nil && m.scan.Exhausted() {
+			status = append(status, fmt.Sprintf("all %d matches loaded", len(m.keylist.Items())))
+		}
+		status = append(status, m.exportView()...)
+		if len(status) == 0 {
+			return " "
+		}
+		return inputLine(m.panes, strings.Join(status, " · "))
 	}
-	return m.spinner.View()
+	return inputLine(m.panes, m.spinner.View()+m.scanProgressView()+m.throttleView())
+}
+
+// throttleView shows whether a throttled scan is waiting or running, and its rate.
+func (m *model) throttleView() string {
+	if !m.throttle.Enabled() {
+		return ""
+	}
+	status := "running"
+	if m.scan.Throttled() {
+		status = focusedStyle.Render("throttled")
+	}
+	return fmt.Sprintf(" %s · %.0f keys/s", status, m.scan.Rate())
+}
+
+// scanProgressView summarizes the per-node progress of a cluster scan.
+func (m *model) scanProgressView() string {
+	progress := m.scan.Progress()
+	if len(progress) < 2 {
+		return ""
+	}
+	var done, found int
+	for _, p := range progress {
+		if !p.Scanning {
+			done++
+		}
+		found += p.Found
+	}
+	return fmt.Sprintf(" %d/%d shards · %d keys", done, len(progress), found)
 }
 
 // isTextInput returns true if the key message is legitimate user input rather
@@ -315,34 +752,47 @@ func isTextInput(msg tea.KeyPressMsg) bool {
 }
 
 func (m *model) headerView() string {
-	hBorder := headerStyle.GetHorizontalBorderSize()
-	inputBlock := headerStyle.
-		Width(leftHandWidth() - 6 + hBorder).
-		Align(lipgloss.Left).
-		Render(lipgloss.JoinVertical(lipgloss.Left,
-			m.textinput.View(),
-			m.spinnerView(),
-		))
-	statusBlock := headerStyle.
-		Width(rightHandWidth + hBorder).
-		Align(lipgloss.Right).
-		Render(lipgloss.JoinVertical(lipgloss.Right,
-			m.data.URI(),
-			fmt.Sprintf("%d keys", m.totalKeys),
-		))
-
-	return lipgloss.NewStyle().Render(
-		lipgloss.JoinHorizontal(lipgloss.Top, inputBlock, statusBlock),
-	)
+	left := []string{m.inputView(), m.spinnerView()}
+	right := []string{m.uriView(), m.keyCountView()}
+	if m.showsFilter() {
+		left = append(left, inputLine(m.panes, m.filterInput.View()))
+		right = append(right, m.filterCountView())
+	}
+	return headerView(m.panes, left, right)
+}
+
+// inputView renders the pattern input, followed by the type filter.
+func (m *model) inputView() string {
+	return inputLine(m.panes, m.textinput.View()+" "+m.typeView())
+}
+
+// uriView shows the server address, and the node serving reads when reads go to replicas.
+func (m *model) uriView() string {
+	if !m.data.ReplicaReads() {
+		return m.data.URI()
+	}
+	return m.data.URI() + " · reads " + focusedStyle.Render(m.data.ReadNode())
+}
+
+func (m *model) keyCountView() string {
+	count := fmt.Sprintf("%d keys", m.totalKeys)
+	if m.live == liveOn {
+		count = focusedStyle.Render("live") + " · " + count
+	}
+	return count
 }
 
 func (m *model) resultsView() string {
+	keys := lipgloss.JoinVertical(lipgloss.Left, m.layout.header(m.sort), m.keylist.View())
+	if m.treeMode {
+		keys = m.tree.View()
+	}
 	if m.keylist.SelectedItem() == nil {
-		return m.keylist.View()
+		return keys
 	}
 
 	return lipgloss.JoinHorizontal(lipgloss.Top,
-		m.keylist.View(),
+		keys,
 		m.viewport.View(),
 	)
 }
@@ -394,12 +844,24 @@ func (m *model) View() tea.View {
 		return v
 	}
 
-	v := tea.NewView(docStyle.Render(
-		lipgloss.JoinVertical(lipgloss.Left,
+	var content string
+	switch m.screen {
+	case pubsubScreen:
+		content = m.pubsub.View(m.panes, m.data.URI())
+	case clusterScreen:
+		content = m.cluster.View(m.panes, m.data.URI())
+	case columnsScreen:
+		content = m.columns.View(m.panes, m.data.URI())
+	case reportsScreen:
+		content = m.reports.View(m.panes, m.data.URI())
+	default:
+		content = lipgloss.JoinVertical(lipgloss.Left,
 			m.headerView(),
 			m.resultsView(),
-		),
-	))
+		)
+	}
+
+	v := tea.NewView(docStyle.Render(content))
 	v.AltScreen = true
 	return v
 }
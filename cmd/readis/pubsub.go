@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sethrylan/readis/internal/data"
+	"github.com/sethrylan/readis/internal/util"
+
+	"charm.land/bubbles/v2/textinput"
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+const (
+	maxPubSubMessages = 1000
+	subscribersWidth  = 7 // room for the subscriber count after each channel name
+	pubsubUsage       = "sub|psub|ssub <channel>... · pub|spub <channel> <message> · unsub"
+)
+
+// pubsubModel is the Pub/Sub screen. Active channels are listed on the left, received messages
+// are streamed on the right, and the input accepts subscribe and publish commands.
+type pubsubModel struct {
+	data     *data.Data
+	input    textinput.Model
+	viewport viewport.Model
+	channels []data.Channel
+	messages []string
+	subs     []*subscription
+	status   string
+
+	listWidth, listHeight int
+}
+
+// subscription is an active SUBSCRIBE, PSUBSCRIBE or SSUBSCRIBE connection.
+type subscription struct {
+	label  string
+	ch     <-chan *data.Message
+	cancel context.CancelFunc
+}
+
+type channelsMsg struct {
+	channels []data.Channel
+	err      error
+}
+
+type subscribedMsg struct {
+	sub *subscription
+	err error
+}
+
+type pubsubStatusMsg string
+
+func newPubSubModel(d *data.Data) *pubsubModel {
+	p := &pubsubModel{
+		data:     d,
+		input:    newTextInput("Command"),
+		viewport: viewport.New(),
+		status:   pubsubUsage,
+	}
+	p.viewport.Style = viewportStyle
+	return p
+}
+
+// resize sets the size of the channel list and the message viewport.
+func (p *pubsubModel) resize(listWidth, listHeight, viewportWidth, viewportHeight int) {
+	p.listWidth, p.listHeight = listWidth, listHeight
+	p.viewport.SetWidth(viewportWidth)
+	p.viewport.SetHeight(viewportHeight)
+	p.viewport.Style = viewportStyle.Width(viewportWidth)
+}
+
+// Update handles messages for the Pub/Sub screen. Key presses are only sent here while the screen is active,
+// but other messages are always sent so that subscriptions keep draining in the background.
+func (p *pubsubModel) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case channelsMsg:
+		if msg.err != nil {
+			p.status = msg.err.Error()
+			return nil
+		}
+		p.channels = msg.channels
+		return nil
+	case subscribedMsg:
+		if msg.err != nil {
+			p.status = msg.err.Error()
+			return nil
+		}
+		p.subs = append(p.subs, msg.sub)
+		p.status = msg.sub.label
+		return p.refreshChannels()
+	case pubsubStatusMsg:
+		p.status = string(msg)
+		return nil
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "enter":
+			cmd = p.execute(p.input.Value())
+			p.input.Reset()
+			return cmd
+		case "up", "down", "pgup", "pgdown", "home", "end":
+			p.viewport, cmd = p.viewport.Update(msg)
+			return cmd
+		}
+		if isTextInput(msg) {
+			p.input, cmd = p.input.Update(msg)
+		}
+		return cmd
+	}
+
+	p.readMessages()
+	p.input, cmd = p.input.Update(msg)
+	return cmd
+}
+
+// execute runs a command typed into the input.
+func (p *pubsubModel) execute(line string) tea.Cmd {
+	name, rest, _ := strings.Cut(strings.TrimSpace(line), " ")
+	switch strings.ToLower(name) {
+	case "":
+		return nil
+	case "sub", "subscribe":
+		return p.subscribe(data.SubscribeChannels, strings.Fields(rest))
+	case "psub", "psubscribe":
+		return p.subscribe(data.SubscribePatterns, strings.Fields(rest))
+	case "ssub", "ssubscribe":
+		return p.subscribe(data.SubscribeShards, strings.Fields(rest))
+	case "pub", "publish":
+		return p.publish(rest, false)
+	case "spub", "spublish":
+		return p.publish(rest, true)
+	case "unsub", "unsubscribe":
+		p.unsubscribe()
+		p.status = "unsubscribed"
+		return p.refreshChannels()
+	default:
+		p.status = "unknown command: " + name + " (" + pubsubUsage + ")"
+		return nil
+	}
+}
+
+func (p *pubsubModel) subscribe(mode data.SubscribeMode, channels []string) tea.Cmd {
+	if len(channels) == 0 {
+		p.status = mode.String() + " requires at least one channel"
+		return nil
+	}
+
+	d := p.data
+	return func() tea.Msg {
+		ctx, cancel := context.WithCancel(appCtx)
+		ch, err := d.SubscribeAsync(ctx, mode, channels...)
+		if err != nil {
+			cancel()
+			return subscribedMsg{err: err}
+		}
+		return subscribedMsg{sub: &subscription{
+			label:  mode.String() + " " + strings.Join(channels, " "),
+			ch:     ch,
+			cancel: cancel,
+		}}
+	}
+}
+
+func (p *pubsubModel) publish(args string, sharded bool) tea.Cmd {
+	channel, message, ok := strings.Cut(strings.TrimSpace(args), " ")
+	if !ok || channel == "" {
+		p.status = "publish requires a channel and a message"
+		return nil
+	}
+
+	d := p.data
+	return func() tea.Msg {
+		n, err := d.Publish(appCtx, channel, message, sharded)
+		if err != nil {
+			return pubsubStatusMsg(err.Error())
+		}
+		return pubsubStatusMsg(fmt.Sprintf("published to %s, received by %d clients", channel, n))
+	}
+}
+
+// unsubscribe closes all active subscriptions.
+func (p *pubsubModel) unsubscribe() {
+	for _, s := range p.subs {
+		s.cancel()
+	}
+	p.subs = nil
+}
+
+func (p *pubsubModel) refreshChannels() tea.Cmd {
+	d := p.data
+	return func() tea.Msg {
+		channels, err := d.Channels(appCtx, "*")
+		return channelsMsg{channels: channels, err: err}
+	}
+}
+
+// readMessages drains any received messages into the viewport without blocking.
+func (p *pubsubModel) readMessages() {
+	received := false
+	open := p.subs[:0]
+	for _, s := range p.subs {
+		closed := false
+	drain:
+		for {
+			select {
+			case msg, ok := <-s.ch:
+				if !ok {
+					closed = true
+					break drain
+				}
+				util.Debug("message on ", msg.Channel)
+				p.messages = append(p.messages, formatMessage(msg))
+				received = true
+			default:
+				break drain
+			}
+		}
+		if !closed {
+			open = append(open, s)
+		}
+	}
+	p.subs = open
+
+	if !received {
+		return
+	}
+	if len(p.messages) > maxPubSubMessages {
+		p.messages = p.messages[len(p.messages)-maxPubSubMessages:]
+	}
+	atBottom := p.viewport.AtBottom()
+	p.viewport.SetContent(strings.Join(p.messages, "\n"))
+	if atBottom {
+		p.viewport.GotoBottom()
+	}
+}
+
+func formatMessage(msg *data.Message) string {
+	source := msg.Channel
+	if msg.Pattern != "" {
+		source = msg.Pattern + " → " + msg.Channel
+	}
+	return fmt.Sprintf("%s [%s] %s",
+		msg.Received.Format("15:04:05.000"),
+		focusedStyle.Render(source),
+		msg.Payload,
+	)
+}
+
+func (p *pubsubModel) headerView(widths panes, uri string) string {
+	return headerView(widths,
+		[]string{p.input.View(), inputLine(widths, p.status)},
+		[]string{uri, fmt.Sprintf("%d subscriptions", len(p.subs))},
+	)
+}
+
+func (p *pubsubModel) channelsView() string {
+	var sb strings.Builder
+	sb.WriteString(focusedStyle.Render("Channels") + "\n\n")
+	if len(p.channels) == 0 {
+		sb.WriteString("no active channels\n")
+	}
+	for i, c := range p.channels {
+		if i >= p.listHeight-3 {
+			fmt.Fprintf(&sb, "… %d more\n", len(p.channels)-i)
+			break
+		}
+		name := c.Name
+		if c.Sharded {
+			name += " (sharded)"
+		}
+		fmt.Fprintf(&sb, "%s %d\n",
+			lipgloss.NewStyle().Width(p.listWidth-subscribersWidth).Inline(true).Render(name),
+			c.Subscribers,
+		)
+	}
+	return lipgloss.NewStyle().Width(p.listWidth).Height(p.listHeight).Render(sb.String())
+}
+
+// View renders the Pub/Sub screen.
+func (p *pubsubModel) View(widths panes, uri string) string {
+	return lipgloss.JoinVertical(lipgloss.Left,
+		p.headerView(widths, uri),
+		lipgloss.JoinHorizontal(lipgloss.Top,
+			p.channelsView(),
+			p.viewport.View(),
+		),
+	)
+}
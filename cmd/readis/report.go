@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sethrylan/readis/internal/data"
+
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	reportTop        = 20 // the number of keys and prefixes listed in reports
+	reportDepth      = 2  // the number of delimited segments in the prefixes of reports
+	reportPageSize   = 1000
+	reportBarWidth   = 30
+	reportLabelWidth = 32
+
+	defaultColdAfter = 30 * 24 * time.Hour // the idle time of cold keys, unless set by -cold-after
+)
+
+// analysis collects a report from every key matching a scan.
+type analysis interface {
+	Add(k *data.Key)       // called for each key, from the scan goroutine
+	View(width int) string // renders the report, once the scan is done
+	Export() any           // the report, as written to the export file
+}
+
+// reportKind is a kind of report on the reports screen.
+type reportKind struct {
+	name    string      // shown in the tabs, and in the name of the export file
+	details data.Detail // the optional metadata needed by the report
+	actions string      // the help of the report's own keys, if any
+	lazy    bool        // if true, only key names are scanned, without their metadata
+	create  func(r *reportsModel) analysis
+	// collect, if set, collects the report instead of scanning every key with scan; e.g., by sampling commands.
+	collect func(ctx context.Context, d *data.Data, run *reportRun, scan func(context.Context) (analysis, error)) (analysis, error)
+}
+
+// reportsModel is the reports screen. Each report scans every key matching the key list's pattern,
+// throttled like the key list, and on every master in cluster mode.
+type reportsModel struct {
+	data      *data.Data
+	viewport  viewport.Model
+	kinds     []reportKind
+	selected  int
+	delimiter string // separates the segments of key prefixes
+	samples   int    // the SAMPLES of MEMORY USAGE, or 0 for the server default
+	coldness  data.Coldness
+
+	confirming bool // true while an action waits for its key to be pressed again
+
+	run     *reportRun
+	results map[string]analysis // the finished reports, by kind
+	status  string
+}
+
+// reportRun is a report being collected.
+type reportRun struct {
+	kind     reportKind
+	pattern  string
+	scanned  atomic.Int64 // the number of keys scanned, or of commands sampled
+	sampling atomic.Bool  // true if keys or commands are sampled rather than every key scanned
+	started  time.Time
+	cancel   context.CancelFunc
+}
+
+type reportDoneMsg struct {
+	run      *reportRun
+	analysis analysis
+	err      error
+}
+
+func newReportsModel(d *data.Data) *reportsModel {
+	r := &reportsModel{
+		data:      d,
+		viewport:  viewport.New(),
+		delimiter: defaultDelimiter,
+		coldness:  data.Coldness{MinIdle: defaultColdAfter},
+		results:   make(map[string]analysis),
+		status:    "enter runs the report",
+	}
+	r.kinds = []reportKind{
+		{name: "memory", details: data.DetailLength, create: newMemoryReport},
+		{name: "ttl", create: newTTLReport},
+		{name: "idle", details: data.DetailIdle | data.DetailFreq, actions: "x expire", create: newIdleReport},
+		{name: "estimate", create: newEstimateReport, collect: collectEstimate},
+		{name: "count", lazy: true, create: newCountReport},
+		{name: "hot", details: data.DetailFreq, actions: "↑↓ select · v open", create: newHotReport, collect: collectHotKeys},
+	}
+	r.viewport.Style = viewportStyle
+	return r
+}
+
+func (r *reportsModel) resize(width, height int) {
+	r.viewport.SetWidth(width)
+	r.viewport.SetHeight(height)
+	r.viewport.Style = viewportStyle.Width(width)
+	r.render()
+}
+
+func (r *reportsModel) kind() reportKind {
+	return r.kinds[r.selected]
+}
+
+// start runs the selected report over the keys matching the pattern, or stops the report being collected.
+func (r *reportsModel) start(pattern string, opts []data.ScanOption) tea.Cmd {
+	if r.run != nil {
+		r.run.cancel()
+		return nil
+	}
+
+	kind := r.kind()
+	run := &reportRun{kind: kind, pattern: pattern, started: time.Now()}
+	var ctx context.Context
+	ctx, run.cancel = context.WithCancel(appCtx)
+	r.run = run
+	r.status = "scanning " + pattern
+	r.render()
+
+	a := kind.create(r)
+	if kind.lazy {
+		opts = append(opts, data.WithLazyMetadata())
+	} else {
+		opts = append(opts, data.WithMetadata(r.samples, kind.details))
+	}
+	s := data.NewScan(pattern, reportPageSize, opts...)
+	d := r.data
+	scan := func(ctx context.Context) (analysis, error) {
+		err := d.ScanAll(ctx, s, func(k *data.Key) {
+			run.scanned.Add(1)
+			a.Add(k)
+		})
+		return a, err
+	}
+	collect := scan
+	if kind.collect != nil {
+		collect = func(ctx context.Context) (analysis, error) {
+			return kind.collect(ctx, d, run, scan)
+		}
+	}
+	return func() tea.Msg {
+		a, err := collect(ctx)
+		return reportDoneMsg{run: run, analysis: a, err: err}
+	}
+}
+
+// startReport runs the selected report over the keys matching the key list's pattern, or stops it.
+// Reports ignore the type filter, and always fetch the metadata of every key.
+func (m *model) startReport() tea.Cmd {
+	pattern, opts, err := parseScanInput(m.textinput.Value())
+	if err != nil {
+		m.reports.status = err.Error()
+		return nil
+	}
+	if pattern == "" {
+		pattern = "*"
+	}
+	if m.exact {
+		pattern = data.EscapeGlob(pattern)
+	}
+	if m.throttle.Enabled() {
+		opts = append(opts, data.WithThrottle(m.throttle))
+	}
+	return m.reports.start(pattern, opts)
+}
+
+// stop cancels the report being collected, if any.
+func (r *reportsModel) stop() {
+	if r.run != nil {
+		r.run.cancel()
+	}
+}
+
+// Update handles messages for the reports screen.
+func (r *reportsModel) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case reportDoneMsg:
+		if msg.run != r.run {
+			return nil
+		}
+		r.run = nil
+		elapsed := time.Since(msg.run.started).Round(time.Second)
+		scanned := humanize.Comma(msg.run.scanned.Load())
+		switch {
+		case msg.err != nil && msg.run.scanned.Load() == 0:
+			r.status = msg.err.Error()
+			return nil
+		case errors.Is(msg.err, context.Canceled):
+			r.status = fmt.Sprintf("stopped after %s keys; the report is partial", scanned)
+		case msg.err != nil:
+			r.status = fmt.Sprintf("stopped after %s keys; the report is partial: %s", scanned, msg.err)
+		default:
+			r.status = fmt.Sprintf("%s keys in %s", scanned, elapsed)
+		}
+		r.results[msg.run.kind.name] = msg.analysis
+		r.render()
+	case expireDoneMsg:
+		r.handleExpireDone(msg)
+	case tea.KeyPressMsg:
+		confirmed := r.confirming
+		r.confirming = false
+		if cmd, ok := r.updateHotKeys(msg); ok {
+			return cmd
+		}
+		switch msg.String() {
+		case "left":
+			r.selected = max(r.selected-1, 0)
+			r.render()
+		case "right":
+			r.selected = min(r.selected+1, len(r.kinds)-1)
+			r.render()
+		case "e":
+			r.export()
+		case "x":
+			return r.expireCold(confirmed)
+		default:
+			r.viewport, cmd = r.viewport.Update(msg)
+		}
+	}
+	return cmd
+}
+
+// export writes the selected report to a JSON file in the working directory.
+func (r *reportsModel) export() {
+	a, ok := r.results[r.kind().name]
+	if !ok {
+		r.status = "run the report before exporting it"
+		return
+	}
+	name := fmt.Sprintf("readis-%s-%s.json", r.kind().name, time.Now().Format("20060102-150405"))
+	b, err := json.MarshalIndent(a.Export(), "", "  ")
+	if err == nil {
+		err = os.WriteFile(name, b, 0o600)
+	}
+	if err != nil {
+		r.status = "export failed: " + err.Error()
+		return
+	}
+	r.status = "exported to " + name
+}
+
+func (r *reportsModel) render() {
+	a, ok := r.results[r.kind().name]
+	if !ok {
+		r.viewport.SetContent("No report yet. Press enter to scan the keys matching the key list's pattern.")
+		return
+	}
+	r.viewport.SetContent(a.View(r.viewport.Width() - viewportStyle.GetHorizontalFrameSize()))
+}
+
+// tabsView shows the kinds of report, with the selected kind highlighted.
+func (r *reportsModel) tabsView() string {
+	tabs := make([]string, len(r.kinds))
+	for i, k := range r.kinds {
+		if i == r.selected {
+			tabs[i] = focusedStyle.Render("[" + k.name + "]")
+		} else {
+			tabs[i] = " " + k.name + " "
+		}
+	}
+	return strings.Join(tabs, " ")
+}
+
+func (r *reportsModel) statusView() string {
+	if r.run == nil {
+		return r.status
+	}
+	scanned := r.run.scanned.Load()
+	elapsed := time.Since(r.run.started)
+	if r.run.sampling.Load() {
+		return fmt.Sprintf("%s report · sampling · %s samples · enter stops", r.run.kind.name, humanize.Comma(scanned))
+	}
+	return fmt.Sprintf("%s report · %s keys · %.0f keys/s · enter stops",
+		r.run.kind.name, humanize.Comma(scanned), float64(scanned)/max(elapsed.Seconds(), 1))
+}
+
+// View renders the reports screen.
+func (r *reportsModel) View(p panes, uri string) string {
+	help := "enter run · e export"
+	if actions := r.kind().actions; actions != "" {
+		help += " · " + actions
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		headerView(p,
+			[]string{inputLine(p, focusedStyle.Render("Reports")+" "+r.tabsView()+" · "+help), inputLine(p, r.statusView())},
+			[]string{uri, "←→ choose report"},
+		),
+		r.viewport.View(),
+	)
+}
+
+// bar renders a horizontal bar proportional to value, relative to largest.
+func bar(value, largest uint64) string {
+	if largest == 0 {
+		return ""
+	}
+	return strings.Repeat("█", int(float64(value)/float64(largest)*reportBarWidth))
+}
+
+// sectionView renders a report section title.
+func sectionView(title string) string {
+	return "\n" + lipgloss.NewStyle().Bold(true).Render(title) + "\n"
+}
+
+// label truncates or pads s to the width of the labels in reports.
+func label(s string) string {
+	return lipgloss.NewStyle().Width(reportLabelWidth).MaxWidth(reportLabelWidth).Inline(true).Render(s)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/sethrylan/readis/internal/data"
+
+	"charm.land/bubbles/v2/list"
+	tea "charm.land/bubbletea/v2"
+)
+
+// sortColumns are the columns the key list can be sorted by, in the order they are cycled through.
+var sortColumns = []string{"name", "size", "ttl", "type"}
+
+// sortMode is the order of the key list. The zero value keeps keys in the order they were found.
+type sortMode struct {
+	column string // one of sortColumns, or "" if not sorted
+	desc   bool
+}
+
+// next returns the sort mode for the next column; after the last column, keys are no longer sorted.
+func (s sortMode) next() sortMode {
+	i := slices.Index(sortColumns, s.column)
+	if i == len(sortColumns)-1 {
+		return sortMode{}
+	}
+	return sortMode{column: sortColumns[i+1], desc: s.desc}
+}
+
+// needsMetadata returns true if keys are sorted by metadata that lazily scanned keys are missing.
+func (s sortMode) needsMetadata() bool {
+	return s.column != "" && s.column != "name"
+}
+
+// indicator returns the arrow shown next to the sorted column.
+func (s sortMode) indicator() string {
+	if s.desc {
+		return "▼"
+	}
+	return "▲"
+}
+
+func (s sortMode) String() string {
+	if s.column == "" {
+		return "unsorted"
+	}
+	return "sorted by " + s.column + " " + s.indicator()
+}
+
+// compare orders two keys. Keys with missing metadata are last, whatever the direction.
+func (s sortMode) compare(a, b keyItem) int {
+	if s.needsMetadata() && a.Partial != b.Partial {
+		if a.Partial {
+			return 1
+		}
+		return -1
+	}
+
+	var c int
+	switch s.column {
+	case "size":
+		c = cmp.Compare(a.Size, b.Size)
+	case "ttl":
+		c = cmp.Compare(data.TTLOrder(a.TTL), data.TTLOrder(b.TTL))
+	case "type":
+		c = strings.Compare(a.Datatype, b.Datatype)
+	}
+	if c == 0 {
+		c = strings.Compare(a.Name, b.Name)
+	}
+	if s.desc {
+		return -c
+	}
+	return c
+}
+
+// cycleSort sorts the key list by the next column.
+func (m *model) cycleSort() tea.Cmd {
+	m.sort = m.sort.next()
+	m.notice = m.sort.String()
+	return tea.Batch(m.refreshKeys(), m.fetchPageMetadata())
+}
+
+// reverseSort reverses the order of the key list.
+func (m *model) reverseSort() tea.Cmd {
+	if m.sort.column == "" {
+		return nil
+	}
+	m.sort.desc = !m.sort.desc
+	m.notice = m.sort.String()
+	return m.refreshKeys()
+}
+
+// sortKeys sorts every loaded key, and returns true if their order changed.
+func (m *model) sortKeys() bool {
+	if m.sort.column == "" {
+		return false
+	}
+	compare := func(a, b list.Item) int {
+		return m.sort.compare(a.(keyItem), b.(keyItem)) //nolint:forcetypeassert // the key list only has keys
+	}
+	items := m.keylist.Items()
+	if slices.IsSortedFunc(items, compare) {
+		return false
+	}
+	items = slices.Clone(items)
+	slices.SortStableFunc(items, compare)
+	m.keylist.SetItems(items) // the filter is applied again by refreshKeys
+	return true
+}
@@ -6,14 +6,6 @@ import (
 	"charm.land/lipgloss/v2"
 )
 
-var (
-	typeLabelWidth = 10 // max is "string"
-	keyNameWidth   = 20 // assume the max to start, and adjust as keys are found
-	ttlWidth       = 12 // max is "101 minutes"
-	sizeWidth      = 7
-	rightHandWidth = 30
-)
-
 var (
 	focusedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#c9510c"))
 	docStyle     = lipgloss.NewStyle().Margin(1, 2)
@@ -32,8 +24,40 @@ var (
 			Foreground(lipgloss.Color("#00ff00"))
 )
 
-func leftHandWidth() int {
-	return typeLabelWidth + keyNameWidth + ttlWidth + sizeWidth + 3
+// panes are the widths of the left hand pane, with the key list, and of the right hand pane, with the value of the
+// selected key. They are computed by resizeViews from the column layout and the window size.
+type panes struct {
+	left  int
+	right int
+}
+
+// initialRightHandWidth is the width of the right hand pane until the size of the window is known.
+const initialRightHandWidth = 30
+
+// newPanes returns the widths of the panes until the size of the window is known.
+func newPanes() panes {
+	return panes{left: minNameWidth, right: initialRightHandWidth}
+}
+
+// headerView renders the header: a left block of inputs sized to the left hand pane,
+// and a right aligned status block sized to the right hand pane.
+func headerView(p panes, left, right []string) string {
+	hBorder := headerStyle.GetHorizontalBorderSize()
+	inputBlock := headerStyle.
+		Width(p.left - 6 + hBorder).
+		Align(lipgloss.Left).
+		Render(lipgloss.JoinVertical(lipgloss.Left, left...))
+	statusBlock := headerStyle.
+		Width(p.right + hBorder).
+		Align(lipgloss.Right).
+		Render(lipgloss.JoinVertical(lipgloss.Right, right...))
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, inputBlock, statusBlock)
+}
+
+// inputLine renders s on a single line that fits in the header input block.
+func inputLine(p panes, s string) string {
+	return lipgloss.NewStyle().Inline(true).MaxWidth(p.left - 6).Render(s)
 }
 
 func colorForKeyType(keyType string) color.Color {
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sethrylan/readis/internal/data"
+	"github.com/sethrylan/readis/internal/util"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	defaultDelimiter = ":"
+	treeCountWidth   = 8  // room for the key count of a namespace
+	treeSizeWidth    = 10 // room for the memory usage of a namespace or key
+)
+
+// treeRow is a row of the namespace tree: a namespace, or a key in an expanded namespace.
+type treeRow struct {
+	depth     int
+	namespace *data.Namespace // nil for keys
+	key       *data.Key
+	prefix    string // the prefix of the namespace the key is in, which is not repeated in the row
+}
+
+// id identifies the row across rebuilds of the tree.
+func (r treeRow) id() string {
+	if r.namespace != nil {
+		return "namespace " + r.namespace.Prefix
+	}
+	return "key " + r.key.Name
+}
+
+// namespaceTree shows the loaded keys grouped into collapsible namespaces by a delimiter.
+// Expanding a namespace scans for more keys with its prefix.
+type namespaceTree struct {
+	delimiter string
+	root      *data.Namespace
+	expanded  map[string]bool       // the prefixes of expanded namespaces
+	rows      []treeRow             // the visible rows
+	cursor    int                   // the selected row
+	offset    int                   // the first row shown
+	scans     map[string]*data.Scan // the scans started by expanding namespaces, by prefix
+
+	scanCh     <-chan *data.Key   // receive-only channel for the keys found by the current namespace scan
+	cancelScan context.CancelFunc // cancels the current namespace scan
+
+	width, height int
+}
+
+func newNamespaceTree(delimiter string) *namespaceTree {
+	return &namespaceTree{
+		delimiter: delimiter,
+		expanded:  make(map[string]bool),
+		scans:     make(map[string]*data.Scan),
+	}
+}
+
+// build groups the keys into namespaces, keeping the selected row selected.
+func (t *namespaceTree) build(keys []keyItem) {
+	var selected string
+	if row, ok := t.selected(); ok {
+		selected = row.id()
+	}
+
+	ks := make([]*data.Key, len(keys))
+	for i := range keys {
+		ks[i] = &keys[i].Key
+	}
+	t.root = data.NewNamespaceTree(ks, t.delimiter)
+	t.rows = t.rows[:0]
+	t.addRows(t.root, 0)
+
+	t.cursor = min(t.cursor, max(len(t.rows)-1, 0))
+	for i, row := range t.rows {
+		if row.id() == selected {
+			t.cursor = i
+			break
+		}
+	}
+	t.scroll()
+}
+
+func (t *namespaceTree) addRows(n *data.Namespace, depth int) {
+	for _, c := range n.Children {
+		t.rows = append(t.rows, treeRow{depth: depth, namespace: c})
+		if t.expanded[c.Prefix] {
+			t.addRows(c, depth+1)
+		}
+	}
+	for _, k := range n.Keys {
+		t.rows = append(t.rows, treeRow{depth: depth, key: k, prefix: n.Prefix})
+	}
+}
+
+func (t *namespaceTree) selected() (treeRow, bool) {
+	if t.cursor >= len(t.rows) {
+		return treeRow{}, false
+	}
+	return t.rows[t.cursor], true
+}
+
+// move moves the cursor by n rows.
+func (t *namespaceTree) move(n int) {
+	t.cursor = max(min(t.cursor+n, len(t.rows)-1), 0)
+	t.scroll()
+}
+
+// scroll keeps the cursor in view.
+func (t *namespaceTree) scroll() {
+	visible := max(t.height-1, 1) // the first line is the header
+	if t.cursor < t.offset {
+		t.offset = t.cursor
+	}
+	if t.cursor >= t.offset+visible {
+		t.offset = t.cursor - visible + 1
+	}
+}
+
+// collapse collapses the selected namespace, or selects the parent of a collapsed namespace or key.
+func (t *namespaceTree) collapse() {
+	row, ok := t.selected()
+	if !ok {
+		return
+	}
+	if row.namespace != nil && t.expanded[row.namespace.Prefix] {
+		delete(t.expanded, row.namespace.Prefix)
+		return
+	}
+	for i := t.cursor - 1; i >= 0; i-- {
+		if t.rows[i].namespace != nil && t.rows[i].depth < row.depth {
+			t.cursor = i
+			t.scroll()
+			return
+		}
+	}
+}
+
+// View renders the tree, with a header naming the columns.
+func (t *namespaceTree) View() string {
+	nameWidth := max(t.width-treeCountWidth-treeSizeWidth-2, 1)
+	cell := func(s string, width int, align lipgloss.Position) string {
+		return lipgloss.NewStyle().Width(width).Align(align).Inline(true).Render(s)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(focusedStyle.Bold(true).Render(
+		"  " + cell("namespace", nameWidth, lipgloss.Left) + cell("keys", treeCountWidth, lipgloss.Right) + cell("memory", treeSizeWidth, lipgloss.Right),
+	))
+	if len(t.rows) == 0 {
+		sb.WriteString("\n  No keys.")
+	}
+	for i := t.offset; i < len(t.rows) && i < t.offset+t.height-1; i++ {
+		row := t.rows[i]
+		indent := strings.Repeat("  ", row.depth)
+		var name, count, size string
+		if n := row.namespace; n != nil {
+			marker := "▸ "
+			if t.expanded[n.Prefix] {
+				marker = "▾ "
+			}
+			name = indent + marker + n.Name + t.delimiter
+			count = humanize.Comma(int64(n.Count))
+			size = namespaceSize(n)
+		} else {
+			name = indent + "  " + strings.TrimPrefix(row.key.Name, row.prefix)
+			size = keyItem{Key: *row.key}.SizeString()
+		}
+		line := cell(name, nameWidth, lipgloss.Left) + cell(count, treeCountWidth, lipgloss.Right) + cell(size, treeSizeWidth, lipgloss.Right)
+		if i == t.cursor {
+			line = focusedStyle.Render("│ " + line)
+		} else {
+			line = "  " + line
+		}
+		sb.WriteString("\n" + line)
+	}
+	return lipgloss.NewStyle().Width(t.width).Height(t.height).Render(sb.String())
+}
+
+// namespaceSize shows the memory usage of a namespace, marked with + while some of its keys are missing metadata.
+func namespaceSize(n *data.Namespace) string {
+	if n.Partial == n.Count {
+		return ""
+	}
+	size := humanize.Bytes(n.Size)
+	if n.Partial > 0 {
+		size += "+"
+	}
+	return size
+}
+
+// namespaceSummary describes the selected namespace in the viewport.
+func namespaceSummary(n *data.Namespace) string {
+	return fmt.Sprintf("%s\n\n%s keys loaded, using %s\n%d nested namespaces\n\npattern %s",
+		focusedStyle.Render(n.Prefix), humanize.Comma(int64(n.Count)), humanize.Bytes(n.Size), len(n.Children), n.Pattern())
+}
+
+// toggleTree switches between the key list and the namespace tree.
+func (m *model) toggleTree() tea.Cmd {
+	m.treeMode = !m.treeMode
+	if !m.treeMode {
+		m.notice = "key list"
+		return m.fetchContent()
+	}
+	m.notice = "namespaces by " + m.tree.delimiter
+	m.tree.build(m.visibleKeys())
+	return tea.Batch(m.selectTreeRow(), m.fetchPageMetadata())
+}
+
+// updateTree handles the keys that navigate the namespace tree, and returns false for other keys.
+func (m *model) updateTree(msg tea.KeyPressMsg) (tea.Cmd, bool) {
+	switch msg.String() {
+	case "up":
+		m.tree.move(-1)
+	case "down":
+		m.tree.move(1)
+	case "pgup":
+		m.tree.move(-m.tree.height)
+	case "pgdown":
+		m.tree.move(m.tree.height)
+	case "home":
+		m.tree.move(-len(m.tree.rows))
+	case "end":
+		m.tree.move(len(m.tree.rows))
+	case "left":
+		m.tree.collapse()
+		m.tree.build(m.visibleKeys())
+	case "right":
+		return m.expandNamespace(), true
+	default:
+		return nil, false
+	}
+	return m.selectTreeRow(), true
+}
+
+// selectTreeRow shows the selected key in the viewport, or a summary of the selected namespace.
+func (m *model) selectTreeRow() tea.Cmd {
+	row, ok := m.tree.selected()
+	if !ok {
+		return nil
+	}
+	if row.namespace != nil {
+		m.viewport.SetContent(namespaceSummary(row.namespace))
+		return nil
+	}
+	m.selectKey(row.key.Name)
+	return m.fetchContent()
+}
+
+// expandNamespace expands the selected namespace, and scans for more of its keys. Expanding it again
+// continues the scan, until every key with the prefix has been found.
+func (m *model) expandNamespace() tea.Cmd {
+	row, ok := m.tree.selected()
+	if !ok || row.namespace == nil {
+		return nil
+	}
+	n := row.namespace
+	m.tree.expanded[n.Prefix] = true
+	m.tree.build(m.visibleKeys())
+
+	s, ok := m.tree.scans[n.Prefix]
+	if ok && (s.Scanning() || s.Exhausted()) {
+		return nil
+	}
+	if !ok {
+		// scan the same node or slots as the key list
+		_, opts, err := parseScanInput(m.textinput.Value())
+		if err != nil {
+			m.notice = err.Error()
+			return nil
+		}
+		s = data.NewScan(n.Pattern(), m.pageSize(), m.scanOptions(opts)...)
+		m.tree.scans[n.Prefix] = s
+	}
+
+	m.stopNamespaceScan()
+	var ctx context.Context
+	ctx, m.tree.cancelScan = context.WithCancel(appCtx)
+	m.tree.scanCh = m.data.ScanAsync(ctx, s)
+	m.notice = "scanning " + n.Pattern()
+	return nil
+}
+
+// stopNamespaceScan cancels the current namespace scan. Its scan is kept, so that expanding the namespace
+// again continues it; namespaces are only scanned again from the start after a new scan of the key list.
+func (m *model) stopNamespaceScan() {
+	if m.tree.cancelScan != nil {
+		m.tree.cancelScan()
+	}
+	m.tree.cancelScan = nil
+	m.tree.scanCh = nil
+}
+
+// readNamespaceScan adds the keys found by the namespace scan to the key list, without blocking.
+// Keys that are already listed, or that do not match the key list's pattern, are skipped.
+func (m *model) readNamespaceScan() bool {
+	var listed map[string]bool
+	inserted := false
+	for {
+		select {
+		case k, ok := <-m.tree.scanCh:
+			if !ok {
+				m.tree.scanCh = nil
+				return inserted
+			}
+			if k.Datatype == "error" {
+				m.notice = k.Name
+				continue
+			}
+			if m.scan != nil && !m.scan.Matches(k.Name) {
+				continue
+			}
+			if listed == nil {
+				listed = make(map[string]bool, len(m.keylist.Items()))
+				for _, item := range m.keylist.Items() {
+					if k, ok := item.(keyItem); ok {
+						listed[k.Name] = true
+					}
+				}
+			}
+			if listed[k.Name] {
+				continue
+			}
+			util.Debug("found key in namespace: ", k.Name)
+			listed[k.Name] = true
+			m.keylist.InsertItem(len(m.keylist.Items()), m.newKeyItem(k))
+			inserted = true
+		default:
+			return inserted
+		}
+	}
+}
+
+// visibleKeys returns the loaded keys that match the filter.
+func (m *model) visibleKeys() []keyItem {
+	items := m.keylist.VisibleItems()
+	keys := make([]keyItem, 0, len(items))
+	for _, item := range items {
+		if k, ok := item.(keyItem); ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sethrylan/readis/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRDB writes an RDB file with a string key for each name.
+func writeRDB(t *testing.T, names ...string) string {
+	t.Helper()
+	var b bytes.Buffer
+	str := func(s string) {
+		b.WriteByte(byte(len(s))) // the strings are shorter than 64 bytes
+		b.WriteString(s)
+	}
+
+	b.WriteString("REDIS0011")
+	b.WriteByte(0xfe) // select db 0
+	b.WriteByte(0)
+	for _, name := range names {
+		b.WriteByte(0) // string
+		str(name)
+		str("v")
+	}
+	b.WriteByte(0xff)
+	b.Write(make([]byte, 8)) // no checksum
+
+	path := filepath.Join(t.TempDir(), "dump.rdb")
+	require.NoError(t, os.WriteFile(path, b.Bytes(), 0o600))
+	return path
+}
+
+func TestExpandNamespaceContinues(t *testing.T) {
+	t.Parallel()
+
+	d, err := data.OpenRDB(writeRDB(t, "user:1", "user:2", "user:3", "order:1"), 0, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = d.Close() })
+
+	m := newModel(d)
+	m.keylist.SetSize(80, 4) // a page of one key
+	require.Equal(t, 1, m.pageSize())
+	m.keylist.InsertItem(math.MaxInt, m.newKeyItem(&data.Key{Name: "user:1", Datatype: "string"}))
+	m.treeMode = true
+	m.tree.build(m.visibleKeys())
+
+	var scan *data.Scan
+	expansions := 0
+	for ; expansions < 10; expansions++ {
+		m.expandNamespace()
+		if m.tree.scanCh == nil {
+			break // every key with the prefix was found
+		}
+		if scan == nil {
+			scan = m.tree.scans["user:"]
+		}
+		require.Same(t, scan, m.tree.scans["user:"], "expanding again continues the scan")
+		require.Eventually(t, func() bool {
+			m.readNamespaceScan()
+			return m.tree.scanCh == nil
+		}, time.Second, time.Millisecond)
+	}
+
+	require.NotNil(t, scan)
+	assert.True(t, scan.Exhausted())
+	assert.Greater(t, expansions, 1)
+	assert.Less(t, expansions, 10, "the scan stops once exhausted")
+
+	var names []string
+	for _, k := range m.visibleKeys() {
+		names = append(names, k.Name)
+	}
+	assert.ElementsMatch(t, []string{"user:1", "user:2", "user:3"}, names)
+}
@@ -0,0 +1,201 @@
+package data
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+)
+
+// maxPrefixes bounds the number of prefixes tracked by a report; keys with other prefixes are counted as otherPrefix.
+const maxPrefixes = 10000
+
+// otherPrefix groups the keys whose prefix was not tracked, because there were already maxPrefixes prefixes.
+const otherPrefix = "(other)"
+
+// ScanAll scans every key matching the scan, on every master in cluster mode, and calls visit for each key.
+// Reports over the whole keyspace use it with a throttled scan, to limit their load on the server.
+func (d *Data) ScanAll(ctx context.Context, s *Scan, visit func(*Key)) error {
+	for {
+		for k := range d.ScanAsync(ctx, s) {
+			if k.Datatype == "error" {
+				return errors.New(k.Name)
+			}
+			visit(k)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !s.HasMore() {
+			return nil
+		}
+	}
+}
+
+// KeyPrefix returns the first depth segments of the key name, up to and including the last delimiter;
+// e.g., "app:user:" for "app:user:1" with a depth of 2. Keys without a delimiter have the prefix "".
+func KeyPrefix(name, delimiter string, depth int) string {
+	if delimiter == "" {
+		return ""
+	}
+	end := 0
+	for range depth {
+		i := strings.Index(name[end:], delimiter)
+		if i < 0 {
+			break
+		}
+		end += i + len(delimiter)
+	}
+	return name[:end]
+}
+
+// KeyUsage is a key in a report.
+type KeyUsage struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Memory uint64 `json:"memory"`
+	Length int64  `json:"length"` // the number of elements, or -1 if unknown
+}
+
+// PrefixUsage is the number of keys and memory usage of the keys with a prefix.
+type PrefixUsage struct {
+	Prefix string `json:"prefix"`
+	Keys   int    `json:"keys"`
+	Memory uint64 `json:"memory"`
+}
+
+// SizeBucket is a bucket of the histogram of key sizes.
+type SizeBucket struct {
+	Max    uint64 `json:"max"` // the largest size in the bucket; the last bucket is unbounded
+	Keys   int    `json:"keys"`
+	Memory uint64 `json:"memory"`
+}
+
+// sizeBuckets are the upper bounds of the histogram of key sizes.
+var sizeBuckets = []uint64{100, 1_000, 10_000, 100_000, 1_000_000, 10_000_000, math.MaxUint64}
+
+// MemoryReport is the memory usage of the keyspace, collected by a MemoryAnalysis.
+type MemoryReport struct {
+	Keys      int                   `json:"keys"`
+	Memory    uint64                `json:"memory"`
+	Types     map[string]*TypeUsage `json:"types"`
+	TopMemory []KeyUsage            `json:"topMemory"` // the keys using the most memory, largest first
+	TopLength map[string][]KeyUsage `json:"topLength"` // the keys with the most elements, per type, largest first
+	Prefixes  []PrefixUsage         `json:"prefixes"`  // memory usage by key prefix, largest first
+	Sizes     []SizeBucket          `json:"sizes"`     // the histogram of key sizes
+}
+
+// TypeUsage is the number of keys and memory usage of a data type.
+type TypeUsage struct {
+	Keys   int    `json:"keys"`
+	Memory uint64 `json:"memory"`
+}
+
+// MemoryAnalysis collects a MemoryReport from scanned keys. The keys need their memory usage and length;
+// see WithMetadata.
+type MemoryAnalysis struct {
+	top       int
+	delimiter string
+	depth     int
+	report    MemoryReport
+	prefixes  map[string]*PrefixUsage
+}
+
+// NewMemoryAnalysis creates an analysis reporting the top keys, and memory usage by the prefixes of key names,
+// up to depth segments separated by the delimiter.
+func NewMemoryAnalysis(top int, delimiter string, depth int) *MemoryAnalysis {
+	a := &MemoryAnalysis{
+		top:       top,
+		delimiter: delimiter,
+		depth:     depth,
+		prefixes:  make(map[string]*PrefixUsage),
+	}
+	a.report.Types = make(map[string]*TypeUsage)
+	a.report.TopLength = make(map[string][]KeyUsage)
+	for _, limit := range sizeBuckets {
+		a.report.Sizes = append(a.report.Sizes, SizeBucket{Max: limit})
+	}
+	return a
+}
+
+// Add adds a scanned key to the report.
+func (a *MemoryAnalysis) Add(k *Key) {
+	r := &a.report
+	r.Keys++
+	r.Memory += k.Size
+
+	t, ok := r.Types[k.Datatype]
+	if !ok {
+		t = &TypeUsage{}
+		r.Types[k.Datatype] = t
+	}
+	t.Keys++
+	t.Memory += k.Size
+
+	u := KeyUsage{Name: k.Name, Type: k.Datatype, Memory: k.Size, Length: -1}
+	if k.Details != nil {
+		u.Length = k.Details.Length
+	}
+	r.TopMemory = insertTop(r.TopMemory, u, a.top, func(a, b KeyUsage) int { return cmp.Compare(b.Memory, a.Memory) })
+	if u.Length >= 0 {
+		r.TopLength[u.Type] = insertTop(r.TopLength[u.Type], u, a.top, func(a, b KeyUsage) int { return cmp.Compare(b.Length, a.Length) })
+	}
+
+	a.prefix(KeyPrefix(k.Name, a.delimiter, a.depth)).add(k)
+
+	i, _ := slices.BinarySearchFunc(r.Sizes, k.Size, func(b SizeBucket, size uint64) int { return cmp.Compare(b.Max, size) })
+	r.Sizes[i].Keys++
+	r.Sizes[i].Memory += k.Size
+}
+
+// prefix returns the usage of the prefix, grouping new prefixes as otherPrefix once maxPrefixes are tracked.
+func (a *MemoryAnalysis) prefix(prefix string) *PrefixUsage {
+	return trackPrefix(a.prefixes, prefix, func(prefix string) *PrefixUsage { return &PrefixUsage{Prefix: prefix} })
+}
+
+func (p *PrefixUsage) add(k *Key) {
+	p.Keys++
+	p.Memory += k.Size
+}
+
+// Report returns the report, with up to top prefixes.
+func (a *MemoryAnalysis) Report() *MemoryReport {
+	r := a.report
+	r.Prefixes = make([]PrefixUsage, 0, len(a.prefixes))
+	for _, p := range a.prefixes {
+		r.Prefixes = append(r.Prefixes, *p)
+	}
+	slices.SortFunc(r.Prefixes, func(a, b PrefixUsage) int {
+		return cmp.Or(cmp.Compare(b.Memory, a.Memory), strings.Compare(a.Prefix, b.Prefix))
+	})
+	r.Prefixes = r.Prefixes[:min(len(r.Prefixes), a.top)]
+	return &r
+}
+
+// insertTop inserts v into the sorted slice, keeping at most n values.
+func insertTop[T any](top []T, v T, n int, compare func(a, b T) int) []T {
+	i, _ := slices.BinarySearchFunc(top, v, compare)
+	if i >= n {
+		return top
+	}
+	top = slices.Insert(top, i, v)
+	return top[:min(len(top), n)]
+}
+
+// trackPrefix returns the entry for the prefix, creating it, or grouping it as otherPrefix once maxPrefixes are tracked.
+func trackPrefix[T any](prefixes map[string]*T, prefix string, create func(string) *T) *T {
+	if p, ok := prefixes[prefix]; ok {
+		return p
+	}
+	if len(prefixes) >= maxPrefixes {
+		prefix = otherPrefix
+		if p, ok := prefixes[prefix]; ok {
+			return p
+		}
+	}
+	p := create(prefix)
+	prefixes[prefix] = p
+	return p
+}
@@ -0,0 +1,101 @@
+package data //nolint:testpackage // white-box testing of internal package
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyPrefix(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "app:", KeyPrefix("app:user:1", ":", 1))
+	assert.Equal(t, "app:user:", KeyPrefix("app:user:1", ":", 2))
+	assert.Equal(t, "app:user:", KeyPrefix("app:user:1", ":", 5))
+	assert.Equal(t, "app::", KeyPrefix("app::1", ":", 2))
+	assert.Empty(t, KeyPrefix("plain", ":", 2))
+	assert.Empty(t, KeyPrefix("app:user:1", "", 2))
+	assert.Equal(t, "a//", KeyPrefix("a//b//c", "//", 1))
+}
+
+func TestInsertTop(t *testing.T) {
+	t.Parallel()
+
+	var top []int
+	for _, v := range []int{3, 9, 1, 7, 5} {
+		top = insertTop(top, v, 3, func(a, b int) int { return cmp.Compare(b, a) })
+	}
+	assert.Equal(t, []int{9, 7, 5}, top)
+}
+
+func TestMemoryAnalysis(t *testing.T) {
+	t.Parallel()
+
+	a := NewMemoryAnalysis(2, ":", 1)
+	a.Add(&Key{Name: "user:1", Datatype: "hash", Size: 500, Details: &KeyDetails{Length: 10}})
+	a.Add(&Key{Name: "user:2", Datatype: "hash", Size: 2_000_000, Details: &KeyDetails{Length: 3}})
+	a.Add(&Key{Name: "user:3", Datatype: "hash", Size: 50, Details: &KeyDetails{Length: 30}})
+	a.Add(&Key{Name: "order:1", Datatype: "list", Size: 5_000, Details: &KeyDetails{Length: 100}})
+	a.Add(&Key{Name: "plain", Datatype: "string", Size: 100})
+	r := a.Report()
+
+	assert.Equal(t, 5, r.Keys)
+	assert.Equal(t, uint64(2_005_650), r.Memory)
+	assert.Equal(t, &TypeUsage{Keys: 3, Memory: 2_000_550}, r.Types["hash"])
+
+	require.Len(t, r.TopMemory, 2)
+	assert.Equal(t, "user:2", r.TopMemory[0].Name)
+	assert.Equal(t, "order:1", r.TopMemory[1].Name)
+
+	require.Len(t, r.TopLength["hash"], 2)
+	assert.Equal(t, "user:3", r.TopLength["hash"][0].Name)
+	assert.Equal(t, "user:1", r.TopLength["hash"][1].Name)
+	assert.Empty(t, r.TopLength["string"], "keys without a length are not ranked")
+
+	assert.Equal(t, []PrefixUsage{
+		{Prefix: "user:", Keys: 3, Memory: 2_000_550},
+		{Prefix: "order:", Keys: 1, Memory: 5_000},
+	}, r.Prefixes)
+
+	sizes := make(map[uint64]int)
+	for _, b := range r.Sizes {
+		sizes[b.Max] = b.Keys
+	}
+	assert.Equal(t, 2, sizes[100])
+	assert.Equal(t, 1, sizes[1_000])
+	assert.Equal(t, 1, sizes[10_000])
+	assert.Equal(t, 1, sizes[10_000_000])
+}
+
+func TestTrackPrefix(t *testing.T) {
+	t.Parallel()
+
+	prefixes := make(map[string]*PrefixUsage)
+	for i := range maxPrefixes + 5 {
+		trackPrefix(prefixes, fmt.Sprintf("p%d:", i), func(p string) *PrefixUsage { return &PrefixUsage{Prefix: p} }).Keys++
+	}
+	assert.Len(t, prefixes, maxPrefixes+1)
+	assert.Equal(t, 5, prefixes[otherPrefix].Keys)
+}
+
+func TestScanAll(t *testing.T) {
+	client, d := setupTest(t)
+	ctx := context.Background()
+
+	for i := range 25 {
+		require.NoError(t, client.HSet(ctx, fmt.Sprintf("report:%d", i), "field", "value").Err())
+	}
+
+	a := NewMemoryAnalysis(5, ":", 1)
+	s := NewScan("report:*", 10, WithMetadata(0, DetailLength))
+	require.NoError(t, d.ScanAll(ctx, s, a.Add))
+	r := a.Report()
+	assert.Equal(t, 25, r.Keys)
+	assert.Positive(t, r.Memory)
+	require.Len(t, r.TopLength["hash"], 5)
+	assert.Equal(t, int64(1), r.TopLength["hash"][0].Length)
+}
@@ -0,0 +1,330 @@
+package data
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// backupMagic starts every backup, after decompression, followed by the format version.
+const backupMagic = "readis-backup\x00"
+
+// backupVersion is the version of the backup format.
+const backupVersion = 1
+
+// maxDumpLength is the longest key name or DUMP payload read from a backup, so that a corrupt length cannot
+// allocate unbounded memory. It is the largest string that Redis accepts.
+const maxDumpLength = 512 << 20
+
+// Dump is a key backed up with DUMP, which restores with the exact encoding of the value, for any type.
+type Dump struct {
+	Key     string
+	TTL     int64     // in milliseconds, when the key was dumped, or -1 for no expiry
+	Dumped  time.Time // when the key was dumped; a backup made with throttling may take hours
+	Payload []byte    // the DUMP serialization of the value
+}
+
+// expireAt returns when the key expires, in Unix milliseconds, if it has a TTL.
+func (dump *Dump) expireAt() int64 {
+	return dump.Dumped.UnixMilli() + dump.TTL
+}
+
+// BackupWriter writes the dumps of keys to a gzip compressed backup. Dumps are written as they are given, so a
+// backup of any number of keys is streamed.
+//
+// A backup is the magic string and format version, the time the backup was made, and then, for each key, the key
+// name, its TTL, the time it was dumped and its DUMP payload, with strings prefixed by their length.
+type BackupWriter struct {
+	gz    *gzip.Writer
+	buf   []byte
+	count int
+}
+
+// NewBackupWriter creates a backup writer, writing the backup header to w.
+func NewBackupWriter(w io.Writer) (*BackupWriter, error) {
+	bw := &BackupWriter{gz: gzip.NewWriter(w)}
+	bw.buf = append(bw.buf, backupMagic...)
+	bw.buf = binary.AppendUvarint(bw.buf, backupVersion)
+	bw.buf = binary.AppendVarint(bw.buf, time.Now().UnixMilli())
+	if _, err := bw.gz.Write(bw.buf); err != nil {
+		return nil, err
+	}
+	return bw, nil
+}
+
+// Count returns the number of dumps written.
+func (bw *BackupWriter) Count() int {
+	return bw.count
+}
+
+// Write writes the dump of a key.
+func (bw *BackupWriter) Write(dump *Dump) error {
+	bw.buf = binary.AppendUvarint(bw.buf[:0], uint64(len(dump.Key)))
+	bw.buf = append(bw.buf, dump.Key...)
+	bw.buf = binary.AppendVarint(bw.buf, dump.TTL)
+	bw.buf = binary.AppendVarint(bw.buf, dump.Dumped.UnixMilli())
+	bw.buf = binary.AppendUvarint(bw.buf, uint64(len(dump.Payload)))
+	bw.buf = append(bw.buf, dump.Payload...)
+	if _, err := bw.gz.Write(bw.buf); err != nil {
+		return err
+	}
+	bw.count++
+	return nil
+}
+
+// Close finishes the backup, without closing the underlying writer.
+func (bw *BackupWriter) Close() error {
+	return bw.gz.Close()
+}
+
+// BackupReader reads the dumps of a backup written by a BackupWriter, one at a time.
+type BackupReader struct {
+	r       *bufio.Reader
+	created time.Time
+}
+
+// NewBackupReader creates a reader of the backup in r, reading its header.
+func NewBackupReader(r io.Reader) (*BackupReader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup: %w", err)
+	}
+	br := &BackupReader{r: bufio.NewReader(gz)}
+	magic := make([]byte, len(backupMagic))
+	if _, err := io.ReadFull(br.r, magic); err != nil || string(magic) != backupMagic {
+		return nil, errors.New("not a backup")
+	}
+	version, err := binary.ReadUvarint(br.r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup: %w", err)
+	}
+	if version != backupVersion {
+		return nil, fmt.Errorf("unsupported backup version %d", version)
+	}
+	created, err := binary.ReadVarint(br.r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup: %w", err)
+	}
+	br.created = time.UnixMilli(created)
+	return br, nil
+}
+
+// Created returns the time the backup was made.
+func (br *BackupReader) Created() time.Time {
+	return br.created
+}
+
+// Next returns the next dump, or io.EOF after the last dump.
+func (br *BackupReader) Next() (*Dump, error) {
+	if _, err := br.r.Peek(1); errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+	key, err := br.readString()
+	if err != nil {
+		return nil, err
+	}
+	ttl, err := binary.ReadVarint(br.r)
+	if err != nil {
+		return nil, truncated(err)
+	}
+	dumped, err := binary.ReadVarint(br.r)
+	if err != nil {
+		return nil, truncated(err)
+	}
+	payload, err := br.readString()
+	if err != nil {
+		return nil, err
+	}
+	return &Dump{Key: string(key), TTL: ttl, Dumped: time.UnixMilli(dumped), Payload: payload}, nil
+}
+
+func (br *BackupReader) readString() ([]byte, error) {
+	n, err := binary.ReadUvarint(br.r)
+	if err != nil {
+		return nil, truncated(err)
+	}
+	if n > maxDumpLength {
+		return nil, fmt.Errorf("corrupt backup: a string of %d bytes", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(br.r, b); err != nil {
+		return nil, truncated(err)
+	}
+	return b, nil
+}
+
+// truncated reports an end of file in the middle of a dump as a truncated backup.
+func truncated(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return errors.New("truncated backup")
+	}
+	return err
+}
+
+// Backup writes the dumps of the keys found by the scan, a batch at a time, so that only a batch of keys is held
+// in memory. progress, if set, is called with the number of keys written after each batch.
+// The scan should be lazy, since only the names of the keys are needed; see WithLazyMetadata.
+func (d *Data) Backup(ctx context.Context, s *Scan, bw *BackupWriter, progress func(int)) error {
+	if d.Offline() {
+		return ErrOffline
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var names []string
+	var writeErr error
+	flush := func() {
+		if writeErr == nil {
+			writeErr = d.backupKeys(ctx, names, bw)
+		}
+		names = names[:0]
+		if writeErr != nil {
+			cancel()
+		} else if progress != nil {
+			progress(bw.Count())
+		}
+	}
+	err := d.ScanAll(ctx, s, func(k *Key) {
+		names = append(names, k.Name)
+		if len(names) >= exportBatch {
+			flush()
+		}
+	})
+	if len(names) > 0 && err == nil {
+		flush()
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+	return err
+}
+
+// backupKeys writes the dumps of the keys, read with a pipeline of DUMP and PTTL. Keys that no longer exist are
+// skipped. The keys are recorded as dumped when the pipeline is sent, so that their expiry is never later than on
+// the server.
+func (d *Data) backupKeys(ctx context.Context, names []string, bw *BackupWriter) error {
+	pipe := d.reader(ctx).Pipeline()
+	dumps := make([]*redis.StringCmd, len(names))
+	ttls := make([]*redis.DurationCmd, len(names))
+	for i, name := range names {
+		dumps[i] = pipe.Dump(ctx, name)
+		ttls[i] = pipe.PTTL(ctx, name)
+	}
+	dumped := time.Now()
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	for i, name := range names {
+		payload, err := dumps[i].Result()
+		if errors.Is(err, redis.Nil) {
+			continue // deleted or expired since it was scanned
+		}
+		if err != nil {
+			return fmt.Errorf("dumping %s: %w", name, err)
+		}
+		dump := &Dump{Key: name, TTL: -1, Dumped: dumped, Payload: []byte(payload)}
+		if ttl := ttls[i].Val(); ttl > 0 {
+			dump.TTL = ttl.Milliseconds()
+		}
+		if err := bw.Write(dump); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreSummary counts the dumps of a restore, and what was done with them.
+type RestoreSummary struct {
+	Dumps    int // the number of dumps read
+	Restored int // keys restored
+	Existing int // keys that already existed, which are skipped without replace
+	Expired  int // keys whose absolute expiry has passed, which are skipped
+}
+
+// Restore restores the keys of the backup read from br with RESTORE, a batch at a time. Each key is sent to the
+// node that owns its slot, so a backup can be restored to a different topology than it was made from; e.g., from
+// a standalone server to a cluster.
+//
+// With replace, existing keys are replaced; otherwise they are skipped. With absTTL, keys expire when they would
+// have expired on the server the backup was made from (RESTORE ABSTTL), and keys that have already expired are
+// skipped; otherwise, keys are restored with the TTL they had when they were dumped. progress, if set, is
+// called with the summary after each batch.
+func (d *Data) Restore(ctx context.Context, br *BackupReader, replace, absTTL bool, progress func(*RestoreSummary)) (*RestoreSummary, error) {
+	if d.Offline() {
+		return nil, ErrOffline
+	}
+	summary := &RestoreSummary{}
+	batch := make([]*Dump, 0, importBatch)
+	for {
+		dump, err := br.Next()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return summary, err
+		}
+		if dump != nil {
+			summary.Dumps++
+			batch = append(batch, dump)
+		}
+		if len(batch) == importBatch || (errors.Is(err, io.EOF) && len(batch) > 0) {
+			if batchErr := d.restoreBatch(ctx, batch, replace, absTTL, summary); batchErr != nil {
+				return summary, batchErr
+			}
+			batch = batch[:0]
+			if progress != nil {
+				progress(summary)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return summary, nil
+		}
+	}
+}
+
+// restoreBatch restores the dumps of the batch with a pipeline of RESTORE commands.
+func (d *Data) restoreBatch(ctx context.Context, batch []*Dump, replace, absTTL bool, summary *RestoreSummary) error {
+	now := time.Now().UnixMilli()
+	pipe := d.client().Pipeline()
+	cmds := make([]*redis.Cmd, 0, len(batch))
+	keys := make([]string, 0, len(batch))
+	for _, dump := range batch {
+		args := []any{"restore", dump.Key, int64(0), dump.Payload}
+		if dump.TTL > 0 {
+			args[2] = dump.TTL
+			if absTTL {
+				expireAt := dump.expireAt()
+				if expireAt <= now {
+					summary.Expired++
+					continue
+				}
+				args[2] = expireAt
+				args = append(args, "absttl")
+			}
+		}
+		if replace {
+			args = append(args, "replace")
+		}
+		cmds = append(cmds, pipe.Do(ctx, args...))
+		keys = append(keys, dump.Key)
+	}
+	_, _ = pipe.Exec(ctx) // the error of each command is checked below
+
+	for i, cmd := range cmds {
+		err := cmd.Err()
+		switch {
+		case err == nil:
+			summary.Restored++
+		case strings.HasPrefix(err.Error(), "BUSYKEY"):
+			summary.Existing++
+		default:
+			return fmt.Errorf("restoring %s: %w", keys[i], err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package data //nolint:testpackage // white-box testing of internal package
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readDumps(t *testing.T, backup []byte) []*Dump {
+	t.Helper()
+	br, err := NewBackupReader(bytes.NewReader(backup))
+	require.NoError(t, err)
+	var dumps []*Dump
+	for {
+		dump, err := br.Next()
+		if errors.Is(err, io.EOF) {
+			return dumps
+		}
+		require.NoError(t, err)
+		dumps = append(dumps, dump)
+	}
+}
+
+func TestBackupFormat(t *testing.T) {
+	t.Parallel()
+
+	dumps := []*Dump{
+		{Key: "greeting", TTL: -1, Dumped: time.UnixMilli(1_700_000_000_000), Payload: []byte("\x00\x05hello\x0b\x00")},
+		{Key: "bin\xff", TTL: 60000, Dumped: time.UnixMilli(1_700_000_001_000), Payload: []byte{}},
+	}
+	var buf bytes.Buffer
+	bw, err := NewBackupWriter(&buf)
+	require.NoError(t, err)
+	for _, dump := range dumps {
+		require.NoError(t, bw.Write(dump))
+	}
+	require.NoError(t, bw.Close())
+	assert.Equal(t, 2, bw.Count())
+	assert.Equal(t, dumps, readDumps(t, buf.Bytes()))
+
+	br, err := NewBackupReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), br.Created(), time.Minute)
+
+	_, err = NewBackupReader(strings.NewReader(`{"key":"not a backup"}`))
+	require.Error(t, err)
+}
+
+func TestBackupTruncated(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	header := binary.AppendUvarint([]byte(backupMagic), backupVersion)
+	header = binary.AppendVarint(header, time.Now().UnixMilli())
+	_, err := gz.Write(binary.AppendUvarint(header, 100)) // a key name of 100 bytes, which is missing
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	br, err := NewBackupReader(&buf)
+	require.NoError(t, err)
+	_, err = br.Next()
+	require.ErrorContains(t, err, "truncated")
+}
+
+func TestBackupRestore(t *testing.T) {
+	client, d := setupTest(t)
+	ctx := context.Background()
+
+	require.NoError(t, client.Set(ctx, "backup:s", "hello", time.Hour).Err())
+	require.NoError(t, client.HSet(ctx, "backup:h", "field", "value").Err())
+	require.NoError(t, client.ZAdd(ctx, "backup:z", redis.Z{Score: 2, Member: "m"}).Err())
+	require.NoError(t, client.Set(ctx, "other", "v", 0).Err())
+
+	var buf bytes.Buffer
+	bw, err := NewBackupWriter(&buf)
+	require.NoError(t, err)
+	require.NoError(t, d.Backup(ctx, NewScan("backup:*", 10, WithLazyMetadata()), bw, nil))
+	require.NoError(t, bw.Close())
+	assert.Equal(t, 3, bw.Count())
+
+	require.NoError(t, client.FlushDB(ctx).Err())
+	require.NoError(t, client.Set(ctx, "backup:s", "changed", 0).Err())
+
+	restore := func(replace, absTTL bool) *RestoreSummary {
+		br, err := NewBackupReader(bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+		summary, err := d.Restore(ctx, br, replace, absTTL, nil)
+		require.NoError(t, err)
+		return summary
+	}
+
+	summary := restore(false, false)
+	assert.Equal(t, &RestoreSummary{Dumps: 3, Restored: 2, Existing: 1}, summary)
+	assert.Equal(t, "changed", client.Get(ctx, "backup:s").Val())
+	assert.Equal(t, "value", client.HGet(ctx, "backup:h", "field").Val())
+	assert.Equal(t, []redis.Z{{Score: 2, Member: "m"}}, client.ZRangeWithScores(ctx, "backup:z", 0, -1).Val())
+	assert.Equal(t, int64(0), client.Exists(ctx, "other").Val())
+
+	summary = restore(true, true)
+	assert.Equal(t, 3, summary.Restored)
+	assert.Equal(t, "hello", client.Get(ctx, "backup:s").Val())
+	assert.InDelta(t, time.Hour.Seconds(), client.TTL(ctx, "backup:s").Val().Seconds(), 60)
+
+	// keys dumped late in a long backup expire relative to when they were dumped, not when the backup started
+	payload, err := client.Dump(ctx, "backup:s").Result()
+	require.NoError(t, err)
+	dumped := time.Now().Add(-2 * time.Hour)
+	buf.Reset()
+	bw, err = NewBackupWriter(&buf)
+	require.NoError(t, err)
+	require.NoError(t, bw.Write(&Dump{Key: "backup:late", TTL: (3 * time.Hour).Milliseconds(), Dumped: dumped, Payload: []byte(payload)}))
+	require.NoError(t, bw.Write(&Dump{Key: "backup:gone", TTL: time.Hour.Milliseconds(), Dumped: dumped, Payload: []byte(payload)}))
+	require.NoError(t, bw.Close())
+
+	summary = restore(false, true)
+	assert.Equal(t, &RestoreSummary{Dumps: 2, Restored: 1, Expired: 1}, summary)
+	assert.InDelta(t, time.Hour.Seconds(), client.TTL(ctx, "backup:late").Val().Seconds(), 60)
+	assert.Equal(t, int64(0), client.Exists(ctx, "backup:gone").Val())
+}
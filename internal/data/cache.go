@@ -0,0 +1,157 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// metadataTTL is how long fetched metadata is reused for.
+const metadataTTL = 10 * time.Second
+
+// metadataCache holds recently fetched key metadata, so that paging back and forth does not fetch it again.
+type metadataCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	key     Key
+	full    bool // false if only the type is known
+	expires time.Time
+}
+
+// get returns the cached key, if it has at least the type, or the full metadata if full is true.
+func (c *metadataCache) get(name string, full bool) (Key, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[name]
+	if !ok || time.Now().After(e.expires) || (full && !e.full) {
+		return Key{}, false
+	}
+	return e.key, true
+}
+
+// put caches the keys. Types do not replace cached full metadata.
+func (c *metadataCache) put(keys []*Key, full bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.entries == nil {
+		c.entries = make(map[string]cacheEntry)
+	}
+	for name, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, name)
+		}
+	}
+	for _, k := range keys {
+		if e, ok := c.entries[k.Name]; ok && e.full && !full {
+			continue
+		}
+		c.entries[k.Name] = cacheEntry{key: *k, full: full, expires: now.Add(metadataTTL)}
+	}
+}
+
+// clear drops every key, when the metadata being collected changes.
+func (c *metadataCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = nil
+}
+
+func (c *metadataCache) remove(names ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, name := range names {
+		delete(c.entries, name)
+	}
+}
+
+// KeyTypes returns the keys with their types, for coloring rows before the rest of their metadata is fetched.
+// Keys that no longer exist are omitted.
+func (d *Data) KeyTypes(ctx context.Context, names []string) ([]*Key, error) {
+	if d.src != nil {
+		return d.src.keys(names), nil
+	}
+	return d.cachedKeys(ctx, names, false, func(missing []string) ([]*Key, error) {
+		cmds := make([]*redis.StatusCmd, len(missing))
+		_, err := d.reader(ctx).Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i, name := range missing {
+				cmds[i] = pipe.Type(ctx, name)
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+		keys := make([]*Key, 0, len(missing))
+		for i, name := range missing {
+			if datatype := cmds[i].Val(); datatype != "none" {
+				keys = append(keys, &Key{Name: name, Datatype: datatype, Partial: true})
+			}
+		}
+		return keys, nil
+	})
+}
+
+// KeysInfo returns the keys with their metadata. Keys that no longer exist are omitted.
+func (d *Data) KeysInfo(ctx context.Context, names []string) ([]*Key, error) {
+	if d.src != nil {
+		return d.src.keys(names), nil
+	}
+	return d.cachedKeys(ctx, names, true, func(missing []string) ([]*Key, error) {
+		if d.cluster {
+			// the keys may be on any node, so the commands are pipelined and routed by key
+			return d.pipelinedMetadata(ctx, d.cc, missing)
+		}
+		return d.metadata(ctx, d.reader(ctx), missing)
+	})
+}
+
+// cachedKeys returns the keys from the cache, and fetches the keys that are not cached, in the order of names.
+func (d *Data) cachedKeys(ctx context.Context, names []string, full bool, fetch func([]string) ([]*Key, error)) ([]*Key, error) {
+	found := make(map[string]*Key, len(names))
+	var missing []string
+	for _, name := range names {
+		if k, ok := d.cache.get(name, full); ok {
+			found[name] = &k
+		} else {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		fetched, err := fetch(missing)
+		if err != nil {
+			return nil, err
+		}
+		d.cache.remove(missing...)
+		d.cache.put(fetched, full)
+		for _, k := range fetched {
+			found[k.Name] = k
+		}
+	}
+
+	keys := make([]*Key, 0, len(names))
+	for _, name := range names {
+		if k, ok := found[name]; ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+// partialKeys creates keys with only a name, and the type if known.
+func partialKeys(names []string, datatype string) []*Key {
+	keys := make([]*Key, len(names))
+	for i, name := range names {
+		keys[i] = &Key{Name: name, Datatype: datatype, Partial: true}
+	}
+	return keys
+}
@@ -0,0 +1,111 @@
+package data //nolint:testpackage // white-box testing of internal package
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataCache(t *testing.T) {
+	t.Parallel()
+
+	var c metadataCache
+	_, ok := c.get("a", false)
+	assert.False(t, ok)
+
+	c.put([]*Key{{Name: "a", Datatype: "hash", Partial: true}}, false)
+	k, ok := c.get("a", false)
+	require.True(t, ok)
+	assert.Equal(t, "hash", k.Datatype)
+	_, ok = c.get("a", true)
+	assert.False(t, ok, "only the type is cached")
+
+	c.put([]*Key{{Name: "a", Datatype: "hash", Size: 64, TTL: -1}}, true)
+	c.put([]*Key{{Name: "a", Datatype: "hash", Partial: true}}, false)
+	k, ok = c.get("a", true)
+	require.True(t, ok, "types do not replace full metadata")
+	assert.Equal(t, uint64(64), k.Size)
+
+	c.remove("a")
+	_, ok = c.get("a", false)
+	assert.False(t, ok)
+
+	c.put([]*Key{{Name: "b", Datatype: "set"}}, true)
+	c.mu.Lock()
+	e := c.entries["b"]
+	e.expires = time.Now().Add(-time.Second)
+	c.entries["b"] = e
+	c.mu.Unlock()
+	_, ok = c.get("b", false)
+	assert.False(t, ok, "expired")
+}
+
+func TestPartialKeys(t *testing.T) {
+	t.Parallel()
+
+	keys := partialKeys([]string{"a", "b"}, "hash")
+	require.Len(t, keys, 2)
+	assert.Equal(t, Key{Name: "b", Datatype: "hash", Partial: true}, *keys[1])
+}
+
+func TestScanAsyncLazy(t *testing.T) {
+	c, d := setupTest(t)
+	ctx := t.Context()
+
+	require.NoError(t, c.Set(ctx, "lazy:string", "v", 0).Err())
+	require.NoError(t, c.HSet(ctx, "lazy:hash", "f", "v").Err())
+
+	var keys []*Key
+	for key := range d.ScanAsync(ctx, NewScan("lazy:*", 100, WithLazyMetadata())) {
+		keys = append(keys, key)
+	}
+	require.Len(t, keys, 2)
+	for _, k := range keys {
+		assert.True(t, k.Partial)
+		assert.Empty(t, k.Datatype)
+	}
+
+	// with a type filter, the server's filtering gives the type
+	keys = keys[:0]
+	for key := range d.ScanAsync(ctx, NewScan("lazy:*", 100, WithLazyMetadata(), WithType("hash"))) {
+		keys = append(keys, key)
+	}
+	require.Len(t, keys, 1)
+	assert.Equal(t, Key{Name: "lazy:hash", Datatype: "hash", Partial: true}, *keys[0])
+}
+
+func TestKeysInfo(t *testing.T) {
+	c, d := setupTest(t)
+	ctx := t.Context()
+
+	require.NoError(t, c.Set(ctx, "lazy:string", "v", time.Hour).Err())
+	require.NoError(t, c.HSet(ctx, "lazy:hash", "f", "v").Err())
+	names := []string{"lazy:hash", "lazy:gone", "lazy:string"}
+
+	types, err := d.KeyTypes(ctx, names)
+	require.NoError(t, err)
+	require.Len(t, types, 2)
+	assert.Equal(t, Key{Name: "lazy:hash", Datatype: "hash", Partial: true}, *types[0])
+	assert.Equal(t, "string", types[1].Datatype)
+
+	keys, err := d.KeysInfo(ctx, names)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.False(t, keys[0].Partial)
+	assert.Equal(t, "lazy:string", keys[1].Name)
+	assert.Positive(t, keys[1].TTL)
+	assert.Positive(t, keys[1].Size)
+
+	// cached metadata is reused
+	require.NoError(t, c.Del(ctx, "lazy:string").Err())
+	keys, err = d.KeysInfo(ctx, names)
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	// fetching the value of a key with an unknown type
+	value, err := d.Fetch(ctx, Key{Name: "lazy:hash"})
+	require.NoError(t, err)
+	assert.Contains(t, value, "| f | v |")
+}
@@ -0,0 +1,346 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sethrylan/readis/internal/util"
+)
+
+// ErrNotCluster is returned for operations that are only available in cluster mode.
+var ErrNotCluster = errors.New("not connected in cluster mode")
+
+// numSlots is the number of hash slots in a cluster.
+const numSlots = 16384
+
+// Shard is a master and its replicas, and the hash slots they serve.
+type Shard struct {
+	Slots []SlotRange
+	Nodes []Node // the master is first, followed by replicas
+}
+
+// SlotRange is an inclusive range of hash slots.
+type SlotRange struct {
+	Start int64
+	End   int64
+}
+
+// Node is a single server in a cluster.
+type Node struct {
+	ID     string
+	Addr   string // host:port
+	Role   string // master or replica
+	Health string // online, failed, loading, etc; https://redis.io/commands/cluster-shards/
+	Keys   int64  // number of keys, or -1 if the node could not be reached
+}
+
+// Master returns the master node of the shard, or nil if the shard has no master.
+func (s Shard) Master() *Node {
+	for i := range s.Nodes {
+		if s.Nodes[i].Role == "master" {
+			return &s.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// Contains returns true if the shard serves the hash slot.
+func (s Shard) Contains(slot int64) bool {
+	for _, r := range s.Slots {
+		if slot >= r.Start && slot <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// NumSlots returns the number of hash slots served by the shard.
+func (s Shard) NumSlots() int64 {
+	var n int64
+	for _, r := range s.Slots {
+		n += r.End - r.Start + 1
+	}
+	return n
+}
+
+func (r SlotRange) String() string {
+	if r.Start == r.End {
+		return strconv.FormatInt(r.Start, 10)
+	}
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+// ShardForSlot returns the shard serving the hash slot, or nil if the slot is not assigned.
+func ShardForSlot(shards []Shard, slot int64) *Shard {
+	for i := range shards {
+		if shards[i].Contains(slot) {
+			return &shards[i]
+		}
+	}
+	return nil
+}
+
+// Topology returns the shards of the cluster, with the number of keys on each node.
+//
+// CLUSTER SHARDS is used when available (Redis 7+), otherwise the topology is parsed from CLUSTER NODES.
+func (d *Data) Topology(ctx context.Context) ([]Shard, error) {
+	if d.Offline() {
+		return nil, ErrOffline
+	}
+	if !d.cluster {
+		return nil, ErrNotCluster
+	}
+
+	shards, err := d.shards(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	counts := make(map[string]int64)
+	_ = d.cc.ForEachShard(ctx, func(ctx context.Context, rc *redis.Client) error {
+		n, err := rc.DBSize(ctx).Result()
+		if err != nil {
+			// unreachable nodes are reported with an unknown key count, rather than failing the topology
+			return nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		counts[rc.Options().Addr] = n
+		return nil
+	})
+
+	for i := range shards {
+		for j := range shards[i].Nodes {
+			node := &shards[i].Nodes[j]
+			node.Keys = -1
+			if n, ok := counts[node.Addr]; ok {
+				node.Keys = n
+			}
+		}
+	}
+
+	sort.Slice(shards, func(i, j int) bool {
+		if len(shards[i].Slots) == 0 || len(shards[j].Slots) == 0 {
+			return len(shards[i].Slots) > len(shards[j].Slots)
+		}
+		return shards[i].Slots[0].Start < shards[j].Slots[0].Start
+	})
+	return shards, nil
+}
+
+// shards returns the cluster shards from CLUSTER SHARDS, or from CLUSTER NODES on servers older than Redis 7.
+func (d *Data) shards(ctx context.Context) ([]Shard, error) {
+	shards, err := d.clusterShards(ctx)
+	if err == nil {
+		return shards, nil
+	}
+	util.Debug("cluster shards: ", err.Error())
+	nodes, err := d.cc.ClusterNodes(ctx).Result()
+	if err != nil {
+		return nil, err
+	}
+	return parseClusterNodes(nodes), nil
+}
+
+// clients returns the cluster's connections by address; masters only, or every node if replicas is true.
+func (d *Data) clients(ctx context.Context, replicas bool) (map[string]*redis.Client, error) {
+	var mu sync.Mutex
+	clients := make(map[string]*redis.Client)
+	fn := func(_ context.Context, rc *redis.Client) error {
+		mu.Lock()
+		defer mu.Unlock()
+		clients[rc.Options().Addr] = rc
+		return nil
+	}
+	if replicas {
+		return clients, d.cc.ForEachShard(ctx, fn)
+	}
+	return clients, d.cc.ForEachMaster(ctx, fn)
+}
+
+// nodeClient returns the connection to the cluster node with the given address. Replicas are only
+// accepted with replica reads on, since only then do the node connections send READONLY.
+func (d *Data) nodeClient(ctx context.Context, addr string) (*redis.Client, error) {
+	if !d.cluster {
+		return nil, ErrNotCluster
+	}
+	clients, err := d.clients(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	rc, ok := clients[addr]
+	if !ok {
+		return nil, fmt.Errorf("unknown cluster node: %s", addr)
+	}
+	if d.replicaReads {
+		return rc, nil
+	}
+	masters, err := d.clients(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := masters[addr]; !ok {
+		return nil, fmt.Errorf("cluster node %s is a replica; enable replica reads to scan it", addr)
+	}
+	return rc, nil
+}
+
+// ParseSlotRanges parses a comma separated list of hash slots and inclusive ranges; e.g., "0-100,5000".
+func ParseSlotRanges(spec string) ([]SlotRange, error) {
+	var ranges []SlotRange
+	for part := range strings.SplitSeq(spec, ",") {
+		start, end, found := strings.Cut(strings.TrimSpace(part), "-")
+		if !found {
+			end = start
+		}
+		startSlot, err := strconv.ParseInt(start, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid slot %q", start)
+		}
+		endSlot, err := strconv.ParseInt(end, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid slot %q", end)
+		}
+		if startSlot < 0 || endSlot >= numSlots || startSlot > endSlot {
+			return nil, fmt.Errorf("invalid slot range %q", part)
+		}
+		ranges = append(ranges, SlotRange{Start: startSlot, End: endSlot})
+	}
+	return ranges, nil
+}
+
+func (d *Data) clusterShards(ctx context.Context) ([]Shard, error) {
+	result, err := d.cc.ClusterShards(ctx).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	shards := make([]Shard, 0, len(result))
+	for _, cs := range result {
+		shard := Shard{}
+		for _, r := range cs.Slots {
+			shard.Slots = append(shard.Slots, SlotRange{Start: r.Start, End: r.End})
+		}
+		for _, n := range cs.Nodes {
+			host := n.Endpoint
+			if host == "" || host == "?" {
+				host = n.IP
+			}
+			shard.Nodes = append(shard.Nodes, Node{
+				ID:     n.ID,
+				Addr:   fmt.Sprintf("%s:%d", host, n.Port),
+				Role:   n.Role,
+				Health: n.Health,
+			})
+		}
+		sortNodes(shard.Nodes)
+		shards = append(shards, shard)
+	}
+	return shards, nil
+}
+
+// parseClusterNodes parses the output of CLUSTER NODES into shards.
+// See https://redis.io/commands/cluster-nodes/ for the format.
+func parseClusterNodes(nodes string) []Shard {
+	shardsByMaster := make(map[string]*Shard)
+	var order []string
+	replicas := make(map[string][]Node)
+
+	for line := range strings.Lines(nodes) {
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			continue
+		}
+		id, addr, flags, masterID, linkState := fields[0], fields[1], fields[2], fields[3], fields[7]
+
+		// ip:port@cport[,hostname]
+		addr, _, _ = strings.Cut(addr, "@")
+
+		node := Node{
+			ID:     id,
+			Addr:   addr,
+			Role:   "replica",
+			Health: nodeHealth(flags, linkState),
+		}
+
+		if !strings.Contains(flags, "master") {
+			replicas[masterID] = append(replicas[masterID], node)
+			continue
+		}
+
+		node.Role = "master"
+		shard := &Shard{Nodes: []Node{node}}
+		for _, s := range fields[8:] {
+			if strings.HasPrefix(s, "[") {
+				continue // slot migration in progress
+			}
+			start, end, found := strings.Cut(s, "-")
+			if !found {
+				end = start
+			}
+			startSlot, startErr := strconv.ParseInt(start, 10, 64)
+			endSlot, endErr := strconv.ParseInt(end, 10, 64)
+			if startErr != nil || endErr != nil {
+				continue
+			}
+			shard.Slots = append(shard.Slots, SlotRange{Start: startSlot, End: endSlot})
+		}
+		shardsByMaster[id] = shard
+		order = append(order, id)
+	}
+
+	shards := make([]Shard, 0, len(order))
+	for _, id := range order {
+		shard := shardsByMaster[id]
+		shard.Nodes = append(shard.Nodes, replicas[id]...)
+		sortNodes(shard.Nodes)
+		shards = append(shards, *shard)
+	}
+	return shards
+}
+
+// nodeHealth maps CLUSTER NODES flags and link state to the health values used by CLUSTER SHARDS.
+func nodeHealth(flags, linkState string) string {
+	for f := range strings.SplitSeq(flags, ",") {
+		switch f {
+		case "fail":
+			return "failed"
+		case "fail?":
+			return "pfail"
+		case "handshake", "noaddr":
+			return f
+		}
+	}
+	if linkState != "connected" {
+		return linkState
+	}
+	return "online"
+}
+
+// sortNodes orders the master first, then replicas by address.
+func sortNodes(nodes []Node) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].Role != nodes[j].Role {
+			return nodes[i].Role == "master"
+		}
+		return nodes[i].Addr < nodes[j].Addr
+	})
+}
+
+// KeySlot returns the hash slot of a key, using CLUSTER KEYSLOT.
+func (d *Data) KeySlot(ctx context.Context, key string) (int64, error) {
+	if d.Offline() {
+		return 0, ErrOffline
+	}
+	if !d.cluster {
+		return 0, ErrNotCluster
+	}
+	return d.cc.ClusterKeySlot(ctx, key).Result()
+}
@@ -0,0 +1,80 @@
+package data //nolint:testpackage // white-box testing of internal package
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const clusterNodes = `07c37dfeb235213a872192d90877d0cd55635b91 127.0.0.1:30004@31004,hostname4 slave e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 0 1426238317239 4 connected
+67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 127.0.0.1:30002@31002,hostname2 master - 0 1426238316232 2 connected 5461-10922
+292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f 127.0.0.1:30003@31003,hostname3 master - 0 1426238318243 3 connected 10923-16383 [16383->-e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca]
+6ec23923021cf3ffec47632106199cb7f496ce01 127.0.0.1:30005@31005,hostname5 slave,fail 67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 0 1426238316232 5 disconnected
+824fe116063bc5fcf9f4ffd895bc17aee7731ac3 127.0.0.1:30006@31006,hostname6 slave 292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f 0 1426238317741 6 connected
+e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 127.0.0.1:30001@31001,hostname1 myself,master - 0 0 1 connected 0-5459 5460
+`
+
+func TestParseClusterNodes(t *testing.T) {
+	t.Parallel()
+
+	shards := parseClusterNodes(clusterNodes)
+	require.Len(t, shards, 3)
+
+	assert.Equal(t, []SlotRange{{Start: 5461, End: 10922}}, shards[0].Slots)
+	assert.Equal(t, []Node{
+		{ID: "67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1", Addr: "127.0.0.1:30002", Role: "master", Health: "online"},
+		{ID: "6ec23923021cf3ffec47632106199cb7f496ce01", Addr: "127.0.0.1:30005", Role: "replica", Health: "failed"},
+	}, shards[0].Nodes)
+
+	assert.Equal(t, []SlotRange{{Start: 10923, End: 16383}}, shards[1].Slots, "migrating slots are ignored")
+
+	assert.Equal(t, []SlotRange{{Start: 0, End: 5459}, {Start: 5460, End: 5460}}, shards[2].Slots)
+	assert.Equal(t, int64(5461), shards[2].NumSlots())
+	assert.Equal(t, "127.0.0.1:30001", shards[2].Master().Addr)
+	assert.Len(t, shards[2].Nodes, 2)
+}
+
+func TestShardForSlot(t *testing.T) {
+	t.Parallel()
+
+	shards := parseClusterNodes(clusterNodes)
+
+	assert.Equal(t, "127.0.0.1:30001", ShardForSlot(shards, 0).Master().Addr)
+	assert.Equal(t, "127.0.0.1:30001", ShardForSlot(shards, 5460).Master().Addr)
+	assert.Equal(t, "127.0.0.1:30002", ShardForSlot(shards, 5461).Master().Addr)
+	assert.Equal(t, "127.0.0.1:30003", ShardForSlot(shards, 16383).Master().Addr)
+	assert.Nil(t, ShardForSlot(shards, 16384))
+}
+
+func TestNodeHealth(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "online", nodeHealth("myself,master", "connected"))
+	assert.Equal(t, "failed", nodeHealth("master,fail", "connected"))
+	assert.Equal(t, "pfail", nodeHealth("slave,fail?", "connected"))
+	assert.Equal(t, "disconnected", nodeHealth("slave", "disconnected"))
+}
+
+func TestTopologyStandalone(t *testing.T) {
+	_, d := setupTest(t)
+
+	_, err := d.Topology(t.Context())
+	require.ErrorIs(t, err, ErrNotCluster)
+
+	_, err = d.KeySlot(t.Context(), "key")
+	require.ErrorIs(t, err, ErrNotCluster)
+}
+
+func TestParseSlotRanges(t *testing.T) {
+	t.Parallel()
+
+	ranges, err := ParseSlotRanges("0-100, 5000,16383")
+	require.NoError(t, err)
+	assert.Equal(t, []SlotRange{{Start: 0, End: 100}, {Start: 5000, End: 5000}, {Start: 16383, End: 16383}}, ranges)
+
+	for _, spec := range []string{"", "a", "1-b", "100-0", "16384", "-1"} {
+		_, err := ParseSlotRanges(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -3,12 +3,11 @@ package data
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"sort"
 	"strconv"
 	"strings"
-	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -21,6 +20,19 @@ type Data struct {
 
 	rc *redis.Client
 	cc *redis.ClusterClient
+
+	replicaReads bool   // if true, reads are sent to replicas
+	replicaAddr  string // the standalone replica to read from, or "" to discover one
+	replicaState replicaState
+
+	noMemory          atomic.Bool   // if true, MEMORY USAGE is not collected
+	details           atomic.Uint32 // the optional metadata that is collected, as a Detail
+	noScripts         atomic.Bool   // if true, metadata is pipelined because scripting is unavailable
+	noReadOnlyScripts atomic.Bool   // if true, the metadata script is run with EVAL, because EVAL_RO is unavailable
+
+	cache metadataCache // recently fetched metadata of lazily scanned keys
+
+	src source // if set, keys are read from it instead of a Redis server; see OpenRDB
 }
 
 // Key represents a Redis key
@@ -29,52 +41,72 @@ type Key struct {
 	Datatype string        // Hash, String, Set, etc; https://redis.io/commands/type/
 	Size     uint64        // in bytes
 	TTL      time.Duration // or -1, if no TTL. Note, in some rare cases, this can be -2.
+	Partial  bool          // true if only the name, and the type if not "", are known
+	Details  *KeyDetails   // optional metadata, or nil if none was fetched; see [Data.SetDetails]
+	Node     string        // the address of the node the key was scanned on, or "" if it was not scanned
 }
 
 // NewData creates a new Data object for interacting with Redis.
-func NewData(uri string, cluster bool) (*Data, error) {
+func NewData(uri string, cluster bool, opts ...Option) (*Data, error) {
 	uri, err := util.NormalizeURI(uri)
 	if err != nil {
 		return nil, fmt.Errorf("invalid URI: %w", err)
 	}
 
+	d := &Data{cluster: cluster}
+	for _, opt := range opts {
+		opt(d)
+	}
+
 	if cluster {
 		clusterOpts, clusterErr := redis.ParseClusterURL(uri)
 		if clusterErr != nil {
 			return nil, fmt.Errorf("invalid cluster URL: %w", clusterErr)
 		}
-		return &Data{
-			cluster: true,
-			cc:      redis.NewClusterClient(clusterOpts),
-		}, nil
+		clusterOpts.ReadOnly = d.replicaReads
+		clusterOpts.RouteByLatency = d.replicaReads
+		d.cc = redis.NewClusterClient(clusterOpts)
+		return d, nil
 	}
 
 	options, err := redis.ParseURL(uri)
 	if err != nil {
 		return nil, fmt.Errorf("invalid URL: %w", err)
 	}
-	return &Data{
-		cluster: false,
-		rc:      redis.NewClient(options),
-	}, nil
+	d.rc = redis.NewClient(options)
+	return d, nil
 }
 
 // URI returns the Redis server address.
 func (d *Data) URI() string {
+	if d.src != nil {
+		return d.src.name()
+	}
 	if d.cluster {
 		return d.cc.Options().Addrs[0]
 	}
 	return d.rc.Options().Addr
 }
 
-// Close closes the Redis connection.
+// Close closes the Redis connections.
 func (d *Data) Close() error {
+	if d.src != nil {
+		return d.src.close()
+	}
+	d.replicaState.mu.Lock()
+	defer d.replicaState.mu.Unlock()
+	if d.replicaState.replica != nil {
+		_ = d.replicaState.replica.Close()
+	}
 	return d.client().Close()
 }
 
 // TotalKeys returns the total number of keys in the Redis database.
 func (d *Data) TotalKeys(ctx context.Context) int64 {
-	return d.client().DBSize(ctx).Val()
+	if d.src != nil {
+		return d.src.count()
+	}
+	return d.reader(ctx).DBSize(ctx).Val()
 }
 
 // client is a helper function to get the redis client depending on mode (standalone, cluster, etc)
@@ -86,49 +118,88 @@ func (d *Data) client() redis.UniversalClient {
 }
 
 // ScanAsync scans Redis keys asynchronously and returns results via a channel.
+//
+// In cluster mode, every master is scanned concurrently, and keys are sent as soon as each master's
+// batch completes, rather than after the slowest master has finished.
+//
+// If the scan has a throttle, every node waits for the throttle before each batch; a node's latency
+// rising above the threshold aborts the scan, and the error is sent as a key.
 func (d *Data) ScanAsync(ctx context.Context, s *Scan) <-chan *Key {
 	util.Debug("scan: ", s.pattern, " ", strconv.Itoa(s.pageSize))
 	s.scanning.Store(true)
 	ch := make(chan *Key)
 
+	// send sends the keys from a completed batch, and returns an error if the scan was canceled.
+	send := func(keys []*Key) error {
+		for _, key := range keys {
+			if !s.matchesType(key) {
+				continue
+			}
+			util.DebugDelay(0.50) // inject delay for testing
+			select {
+			case ch <- key:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	}
+
+	// scanNode scans the next batch of keys on a node, and collects their metadata from the same node.
+	scanNode := func(ctx context.Context, rc *redis.Client) ([]*Key, error) {
+		names, err := s.NextKeys(ctx, rc)
+		if err != nil {
+			return nil, err
+		}
+		addr := rc.Options().Addr
+		var keys []*Key
+		if s.sendsPartialKeys(addr) {
+			keys = partialKeys(names, s.keyType)
+		} else if keys, err = d.scanMetadata(ctx, rc, s, names); err != nil {
+			return nil, err
+		}
+		for _, k := range keys {
+			k.Node = addr
+		}
+		return keys, nil
+	}
+
 	go func() {
 		defer func() {
 			// Close the channel to signal that we're done
 			s.scanning.Store(false)
 			close(ch)
 		}()
-		var cmds []redis.Cmder
+		var keys []*Key
 		var err error
 
-		if strings.Contains(s.pattern, "*") {
+		switch {
+		case d.src != nil:
+			keys, err = d.src.scan(s)
+		case len(s.slots) > 0:
+			keys, err = d.slotKeys(ctx, s)
+		case s.node != "":
+			var rc *redis.Client
+			rc, err = d.nodeClient(ctx, s.node)
+			if err == nil {
+				keys, err = scanNode(ctx, rc)
+			}
+		case s.isGlob():
 			if d.cluster {
-				var mu sync.Mutex
-				err = d.cc.ForEachMaster(ctx, func(ctx context.Context, rc *redis.Client) error {
-					mu.Lock()
-					defer mu.Unlock()
-					shardCmds, shardErr := s.PipelinedCmds(ctx, rc)
+				err = d.forEachScanNode(ctx, func(ctx context.Context, rc *redis.Client) error {
+					shardKeys, shardErr := scanNode(ctx, rc)
 					if shardErr != nil {
 						return shardErr
 					}
-					cmds = append(cmds, shardCmds...)
-					return nil
+					return send(shardKeys)
 				})
 			} else {
-				rc := d.rc
-				cmds, err = s.PipelinedCmds(ctx, rc)
+				keys, err = scanNode(ctx, d.standaloneReader(ctx))
 			}
-		} else {
-			cmds, err = d.client().Pipelined(ctx, func(pipe redis.Pipeliner) error {
-				pipe.TTL(ctx, s.pattern)
-				pipe.Type(ctx, s.pattern)
-				pipe.MemoryUsage(ctx, s.pattern)
-				return nil
-			})
+		default:
+			keys, err = d.scanMetadata(ctx, d.reader(ctx), s, []string{s.keyName()})
 		}
 
-		if errors.Is(err, redis.Nil) {
-			return
-		}
 		if err != nil {
 			select {
 			case ch <- &Key{
@@ -141,114 +212,106 @@ func (d *Data) ScanAsync(ctx context.Context, s *Scan) <-chan *Key {
 			return
 		}
 
-		keys := make(map[string]*Key)
-
-		for _, cmd := range cmds {
-			key, ok := cmd.Args()[1].(string)
-			if !ok {
-				continue
-			}
-			if key == "usage" {
-				key, ok = cmd.Args()[2].(string)
-				if !ok {
-					continue
-				}
-			}
-
-			if _, ok := keys[key]; !ok {
-				keys[key] = &Key{Name: key}
-			}
-
-			switch c := cmd.(type) {
-			case *redis.DurationCmd:
-				keys[key].TTL = c.Val()
-			case *redis.StatusCmd:
-				keys[key].Datatype = c.Val()
-			case *redis.IntCmd:
-				val := c.Val()
-				if val >= 0 {
-					keys[key].Size = uint64(val) // #nosec G115 -- val is checked to be >= 0
-				}
-			default:
-				util.Debug("unknown command type: ", fmt.Sprintf("%T", cmd))
-				continue
-			}
-		}
-
-		for _, key := range keys {
-			util.DebugDelay(0.50) // inject delay for testing
-			select {
-			case ch <- key:
-			case <-ctx.Done():
-				return
-			}
-		}
+		_ = send(keys)
 	}()
 
 	return ch
 }
 
+// KeyInfo returns the metadata for a single key, or nil if the key does not exist.
+func (d *Data) KeyInfo(ctx context.Context, name string) (*Key, error) {
+	if d.src != nil {
+		if keys := d.src.keys([]string{name}); len(keys) > 0 {
+			return keys[0], nil
+		}
+		return nil, nil
+	}
+	keys, err := d.metadata(ctx, d.reader(ctx), []string{name})
+	if err != nil {
+		return nil, err
+	}
+	d.cache.put(keys, true)
+	if len(keys) == 0 {
+		d.cache.remove(name)
+		return nil, nil
+	}
+	return keys[0], nil
+}
+
 // Fetch retrieves the value of a key from Redis and returns it as markdown.
 func (d *Data) Fetch(ctx context.Context, key Key) (string, error) {
-	c := d.client()
-
-	switch key.Datatype {
-	case "string":
-		r, err := c.Get(ctx, key.Name).Result()
-		if err == nil {
-			return fmt.Sprintf("```%s```", r), nil
-		}
-		return "", err
-	case "list":
-		vals, err := c.LRange(ctx, key.Name, 0, -1).Result()
+	if d.src != nil {
+		value, err := d.src.value(key.Name)
 		if err != nil {
 			return "", err
 		}
-		var sb strings.Builder
-		for _, v := range vals {
-			fmt.Fprintf(&sb, "- `%v`\n", v)
-		}
-		return sb.String(), nil
-	case "set":
-		vals, err := c.SMembers(ctx, key.Name).Result()
+		return valueMarkdown(key.Datatype, value), nil
+	}
+
+	c := d.reader(ctx)
+
+	if key.Datatype == "" {
+		datatype, err := c.Type(ctx, key.Name).Result()
 		if err != nil {
 			return "", err
 		}
-		var sb strings.Builder
-		for _, v := range vals {
-			fmt.Fprintf(&sb, "- `%v`\n", v)
-		}
-		return sb.String(), nil
+		key.Datatype = datatype
+	}
+
+	var value any
+	var err error
+	switch key.Datatype {
+	case "string":
+		value, err = c.Get(ctx, key.Name).Result()
+	case "list":
+		value, err = c.LRange(ctx, key.Name, 0, -1).Result()
+	case "set":
+		value, err = c.SMembers(ctx, key.Name).Result()
 	case "zset":
-		vals, err := c.ZRangeWithScores(ctx, key.Name, 0, -1).Result()
-		if err != nil {
-			return "", err
+		var zs []redis.Z
+		zs, err = c.ZRangeWithScores(ctx, key.Name, 0, -1).Result()
+		entries := make([]ZEntry, len(zs))
+		for i, z := range zs {
+			entries[i] = ZEntry{Member: fmt.Sprint(z.Member), Score: z.Score}
+		}
+		value = entries
+	case "hash":
+		value, err = c.HGetAll(ctx, key.Name).Result()
+	}
+	if err != nil {
+		return "", err
+	}
+	return valueMarkdown(key.Datatype, value), nil
+}
+
+// valueMarkdown renders the value of a key of the type as markdown. The value is in the form of a Record value.
+func valueMarkdown(datatype string, value any) string {
+	var sb strings.Builder
+	switch v := value.(type) {
+	case string:
+		return fmt.Sprintf("```%s```", v)
+	case []string:
+		for _, s := range v {
+			fmt.Fprintf(&sb, "- `%v`\n", s)
 		}
-		var sb strings.Builder
+	case []ZEntry:
 		sb.WriteString("| score | value |\n| --- | --- |\n")
-		for _, z := range vals {
+		for _, z := range v {
 			fmt.Fprintf(&sb, "| %f | `%v` |\n", z.Score, z.Member)
 		}
-		return sb.String(), nil
-	case "hash":
-		hash, err := c.HGetAll(ctx, key.Name).Result()
-		if err != nil {
-			return "", err
-		}
-
-		fields := make([]string, 0, len(hash))
-		for f := range hash {
+	case map[string]string:
+		fields := make([]string, 0, len(v))
+		for f := range v {
 			fields = append(fields, f)
 		}
 		sort.Strings(fields)
 
-		var sb strings.Builder
 		sb.WriteString("| field | value |\n| --- | --- |\n")
 		for _, f := range fields {
-			fmt.Fprintf(&sb, "| %s | %s |\n", f, hash[f])
+			fmt.Fprintf(&sb, "| %s | %s |\n", f, v[f])
 		}
-		return sb.String(), nil
 	default:
-		return "Unknown data type: " + key.Datatype, nil
+		return "Unknown data type: " + datatype
 	}
+	return sb.String()
 }
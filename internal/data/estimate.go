@@ -0,0 +1,175 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sethrylan/readis/internal/util"
+)
+
+// Estimate methods: RANDOMKEY, a partial SCAN when RANDOMKEY is unavailable, or an exact count of small nodes.
+const (
+	EstimateByRandomKey = "randomkey"
+	EstimateByScan      = "scan"
+	EstimateExact       = "exact"
+)
+
+// confidenceZ is the z-score of the 95% confidence interval of estimates.
+const confidenceZ = 1.96
+
+// NodeEstimate is the estimated number of keys matching a pattern on a node.
+type NodeEstimate struct {
+	Node    string `json:"node"`
+	Method  string `json:"method"`  // EstimateByRandomKey, EstimateByScan or EstimateExact
+	Keys    int64  `json:"keys"`    // DBSIZE
+	Sampled int    `json:"sampled"` // the number of keys sampled
+	Matched int    `json:"matched"` // the number of sampled keys matching the pattern
+	Count   int64  `json:"count"`   // the estimated number of matching keys
+	Low     int64  `json:"low"`     // the lower bound of the 95% confidence interval
+	High    int64  `json:"high"`    // the upper bound of the 95% confidence interval
+}
+
+// Estimate is the estimated number of keys matching a pattern, on every master.
+type Estimate struct {
+	Pattern string         `json:"pattern"`
+	Keys    int64          `json:"keys"`
+	Count   int64          `json:"count"`
+	Low     int64          `json:"low"`   // the sum of the lower bounds of the nodes, which is conservative
+	High    int64          `json:"high"`  // the sum of the upper bounds of the nodes
+	Nodes   []NodeEstimate `json:"nodes"` // ordered by address
+}
+
+// EstimateKeys estimates the number of keys matching the glob pattern without scanning every key: it samples up to
+// samples keys on each master with RANDOMKEY, or with a partial SCAN if RANDOMKEY is unavailable, and extrapolates
+// from the DBSIZE of the node. Nodes with no more keys than samples are counted exactly.
+func (d *Data) EstimateKeys(ctx context.Context, pattern string, samples int) (*Estimate, error) {
+	if d.Offline() {
+		return nil, ErrOffline
+	}
+	var mu sync.Mutex
+	e := &Estimate{Pattern: pattern}
+	err := d.forEachMaster(ctx, func(ctx context.Context, rc *redis.Client) error {
+		n, err := estimateNode(ctx, rc, pattern, samples)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		e.Nodes = append(e.Nodes, *n)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(e.Nodes, func(a, b NodeEstimate) int { return strings.Compare(a.Node, b.Node) })
+	for _, n := range e.Nodes {
+		e.Keys += n.Keys
+		e.Count += n.Count
+		e.Low += n.Low
+		e.High += n.High
+	}
+	return e, nil
+}
+
+func estimateNode(ctx context.Context, rc *redis.Client, pattern string, samples int) (*NodeEstimate, error) {
+	keys, err := rc.DBSize(ctx).Result()
+	if err != nil {
+		return nil, err
+	}
+	n := &NodeEstimate{Node: rc.Options().Addr, Keys: keys}
+	if keys <= int64(samples) {
+		count, err := countMatching(ctx, rc, pattern, int64(samples))
+		if err != nil {
+			return nil, err
+		}
+		n.Method = EstimateExact
+		n.Sampled, n.Matched = int(keys), int(count)
+		n.Count, n.Low, n.High = count, count, count
+		return n, nil
+	}
+
+	names, err := randomKeys(ctx, rc, samples)
+	n.Method = EstimateByRandomKey
+	if err != nil {
+		util.Debug("randomkey: ", err.Error())
+		if names, err = scanSample(ctx, rc, samples); err != nil {
+			return nil, err
+		}
+		n.Method = EstimateByScan
+	}
+	n.Sampled = len(names)
+	for _, name := range names {
+		if matchGlob(pattern, name) {
+			n.Matched++
+		}
+	}
+	n.Count, n.Low, n.High = extrapolate(n.Matched, n.Sampled, keys)
+	return n, nil
+}
+
+// randomKeys returns n keys chosen by RANDOMKEY, with replacement.
+func randomKeys(ctx context.Context, rc *redis.Client, n int) ([]string, error) {
+	pipe := rc.Pipeline()
+	cmds := make([]*redis.StringCmd, n)
+	for i := range cmds {
+		cmds[i] = pipe.RandomKey(ctx)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	names := make([]string, 0, n)
+	for _, cmd := range cmds {
+		if name, err := cmd.Result(); err == nil {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// scanSample returns the first n keys found by SCAN. SCAN visits keys in the order of the hash table,
+// which is unrelated to their names, so they are a sample of the keyspace.
+func scanSample(ctx context.Context, rc *redis.Client, n int) ([]string, error) {
+	var names []string
+	var cursor uint64
+	for len(names) < n {
+		keys, next, err := rc.Scan(ctx, cursor, "", int64(n-len(names))).Result()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, keys...)
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+	return names[:min(len(names), n)], nil
+}
+
+// countMatching counts the keys matching the pattern on the node, with SCAN.
+func countMatching(ctx context.Context, rc *redis.Client, pattern string, batch int64) (int64, error) {
+	var count int64
+	iter := rc.Scan(ctx, 0, pattern, batch).Iterator()
+	for iter.Next(ctx) {
+		count++
+	}
+	return count, iter.Err()
+}
+
+// extrapolate estimates the number of matching keys among total keys from a sample, with the Wilson score
+// interval, which stays within [0, total] even when few or none of the sampled keys match.
+func extrapolate(matched, sampled int, total int64) (count, low, high int64) {
+	if sampled == 0 {
+		return 0, 0, total
+	}
+	n := float64(sampled)
+	p := float64(matched) / n
+	z2 := confidenceZ * confidenceZ
+	center := (p + z2/(2*n)) / (1 + z2/n)
+	half := confidenceZ / (1 + z2/n) * math.Sqrt(p*(1-p)/n+z2/(4*n*n))
+	t := float64(total)
+	return int64(math.Round(p * t)), int64(math.Floor(max(center-half, 0) * t)), int64(math.Ceil(min(center+half, 1) * t))
+}
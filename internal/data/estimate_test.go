@@ -0,0 +1,61 @@
+package data //nolint:testpackage // white-box testing of internal package
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtrapolate(t *testing.T) {
+	t.Parallel()
+
+	count, low, high := extrapolate(100, 1000, 1_000_000)
+	assert.Equal(t, int64(100_000), count)
+	assert.Less(t, low, count)
+	assert.Greater(t, high, count)
+	assert.InDelta(t, 100_000, low, 25_000)
+	assert.InDelta(t, 100_000, high, 25_000)
+
+	count, low, high = extrapolate(0, 1000, 1_000_000)
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, int64(0), low)
+	assert.Positive(t, high, "no matches in the sample does not rule out some matching keys")
+
+	count, low, high = extrapolate(1000, 1000, 1_000_000)
+	assert.Equal(t, int64(1_000_000), count)
+	assert.Equal(t, int64(1_000_000), high)
+	assert.Less(t, low, count)
+
+	count, low, high = extrapolate(0, 0, 50)
+	assert.Equal(t, []int64{0, 0, 50}, []int64{count, low, high})
+}
+
+func TestEstimateKeys(t *testing.T) {
+	client, d := setupTest(t)
+	ctx := context.Background()
+
+	for i := range 200 {
+		prefix := "estimate:other"
+		if i%4 == 0 {
+			prefix = "estimate:order"
+		}
+		require.NoError(t, client.Set(ctx, fmt.Sprintf("%s:%d", prefix, i), "v", 0).Err())
+	}
+	total := client.DBSize(ctx).Val()
+
+	e, err := d.EstimateKeys(ctx, "estimate:order:*", int(total))
+	require.NoError(t, err)
+	require.Len(t, e.Nodes, 1)
+	assert.Equal(t, EstimateExact, e.Nodes[0].Method)
+	assert.Equal(t, int64(50), e.Count)
+
+	e, err = d.EstimateKeys(ctx, "estimate:order:*", 100)
+	require.NoError(t, err)
+	assert.Equal(t, EstimateByRandomKey, e.Nodes[0].Method)
+	assert.Equal(t, total, e.Keys)
+	assert.LessOrEqual(t, e.Low, e.Count)
+	assert.GreaterOrEqual(t, e.High, e.Count)
+}
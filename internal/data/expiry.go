@@ -0,0 +1,120 @@
+package data
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxSamples is the number of key names kept as examples in each group of a report.
+const maxSamples = 5
+
+// ttlBuckets are the upper bounds of the histogram of TTLs. Keys without an expiry are counted in a separate,
+// last bucket.
+var ttlBuckets = []time.Duration{time.Minute, time.Hour, 24 * time.Hour, math.MaxInt64}
+
+// TTLBucket is a bucket of the histogram of TTLs.
+type TTLBucket struct {
+	Max     time.Duration `json:"max"` // the longest TTL in the bucket, or -1 for the keys without an expiry
+	Keys    int           `json:"keys"`
+	Memory  uint64        `json:"memory"`
+	Samples []string      `json:"samples"` // the names of some keys in the bucket
+}
+
+// PrefixExpiry is the number of keys with a prefix, and how many of them have no expiry.
+type PrefixExpiry struct {
+	Prefix         string   `json:"prefix"`
+	Keys           int      `json:"keys"`
+	NoExpiry       int      `json:"noExpiry"`
+	NoExpiryMemory uint64   `json:"noExpiryMemory"`
+	Samples        []string `json:"samples"` // the names of some keys with the prefix and no expiry
+}
+
+// TTLReport is the distribution of TTLs in the keyspace, collected by a TTLAnalysis.
+type TTLReport struct {
+	Keys     int            `json:"keys"`
+	NoExpiry int            `json:"noExpiry"`
+	Buckets  []TTLBucket    `json:"buckets"`  // the histogram of TTLs, ending with the keys without an expiry
+	Prefixes []PrefixExpiry `json:"prefixes"` // the prefixes with the most keys without an expiry first
+}
+
+// TTLAnalysis collects a TTLReport from scanned keys. The keys need their TTL, so the scan must not be lazy.
+type TTLAnalysis struct {
+	top       int
+	delimiter string
+	depth     int
+	report    TTLReport
+	prefixes  map[string]*PrefixExpiry
+}
+
+// NewTTLAnalysis creates an analysis reporting the keys without an expiry by the prefixes of key names,
+// up to depth segments separated by the delimiter.
+func NewTTLAnalysis(top int, delimiter string, depth int) *TTLAnalysis {
+	a := &TTLAnalysis{
+		top:       top,
+		delimiter: delimiter,
+		depth:     depth,
+		prefixes:  make(map[string]*PrefixExpiry),
+	}
+	for _, limit := range ttlBuckets {
+		a.report.Buckets = append(a.report.Buckets, TTLBucket{Max: limit})
+	}
+	a.report.Buckets = append(a.report.Buckets, TTLBucket{Max: -1})
+	return a
+}
+
+// Add adds a scanned key to the report. Keys that expired before their TTL was read are ignored.
+func (a *TTLAnalysis) Add(k *Key) {
+	if k.TTL == -2 {
+		return
+	}
+	r := &a.report
+	r.Keys++
+
+	noExpiry := k.TTL < 0
+	i := len(r.Buckets) - 1
+	if !noExpiry {
+		i, _ = slices.BinarySearchFunc(r.Buckets[:i], k.TTL, func(b TTLBucket, ttl time.Duration) int { return cmp.Compare(b.Max, ttl) })
+	}
+	b := &r.Buckets[i]
+	b.Keys++
+	b.Memory += k.Size
+	b.Samples = addSample(b.Samples, k.Name)
+
+	p := trackPrefix(a.prefixes, KeyPrefix(k.Name, a.delimiter, a.depth), func(prefix string) *PrefixExpiry {
+		return &PrefixExpiry{Prefix: prefix}
+	})
+	p.Keys++
+	if noExpiry {
+		r.NoExpiry++
+		p.NoExpiry++
+		p.NoExpiryMemory += k.Size
+		p.Samples = addSample(p.Samples, k.Name)
+	}
+}
+
+// Report returns the report, with up to top prefixes that have keys without an expiry.
+func (a *TTLAnalysis) Report() *TTLReport {
+	r := a.report
+	r.Prefixes = make([]PrefixExpiry, 0, len(a.prefixes))
+	for _, p := range a.prefixes {
+		if p.NoExpiry > 0 {
+			r.Prefixes = append(r.Prefixes, *p)
+		}
+	}
+	slices.SortFunc(r.Prefixes, func(a, b PrefixExpiry) int {
+		return cmp.Or(cmp.Compare(b.NoExpiry, a.NoExpiry), strings.Compare(a.Prefix, b.Prefix))
+	})
+	r.Prefixes = r.Prefixes[:min(len(r.Prefixes), a.top)]
+	return &r
+}
+
+// addSample adds the key name to the samples, until there are maxSamples.
+func addSample(samples []string, name string) []string {
+	if len(samples) >= maxSamples {
+		return samples
+	}
+	return append(samples, name)
+}
@@ -0,0 +1,48 @@
+package data //nolint:testpackage // white-box testing of internal package
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTTLAnalysis(t *testing.T) {
+	t.Parallel()
+
+	a := NewTTLAnalysis(3, ":", 1)
+	a.Add(&Key{Name: "session:1", TTL: 30 * time.Second, Size: 100})
+	a.Add(&Key{Name: "session:2", TTL: time.Minute, Size: 100})
+	a.Add(&Key{Name: "session:3", TTL: -1, Size: 100})
+	a.Add(&Key{Name: "cache:1", TTL: 2 * time.Hour, Size: 200})
+	a.Add(&Key{Name: "cache:2", TTL: 48 * time.Hour, Size: 200})
+	for i := range 8 {
+		a.Add(&Key{Name: fmt.Sprintf("user:%d", i), TTL: -1, Size: 1000})
+	}
+	a.Add(&Key{Name: "plain", TTL: -1, Size: 10})
+	a.Add(&Key{Name: "expired", TTL: -2})
+	r := a.Report()
+
+	assert.Equal(t, 14, r.Keys)
+	assert.Equal(t, 10, r.NoExpiry)
+
+	require.Len(t, r.Buckets, 5)
+	keys := make([]int, len(r.Buckets))
+	for i, b := range r.Buckets {
+		keys[i] = b.Keys
+	}
+	assert.Equal(t, []int{2, 0, 1, 1, 10}, keys)
+	assert.Equal(t, time.Duration(-1), r.Buckets[4].Max)
+	assert.Equal(t, []string{"session:1", "session:2"}, r.Buckets[0].Samples)
+	assert.Len(t, r.Buckets[4].Samples, maxSamples)
+
+	require.Len(t, r.Prefixes, 3)
+	assert.Equal(t, "user:", r.Prefixes[0].Prefix)
+	assert.Equal(t, 8, r.Prefixes[0].NoExpiry)
+	assert.Equal(t, uint64(8000), r.Prefixes[0].NoExpiryMemory)
+	assert.Len(t, r.Prefixes[0].Samples, maxSamples)
+	assert.Empty(t, r.Prefixes[1].Prefix, "ties are sorted by prefix")
+	assert.Equal(t, PrefixExpiry{Prefix: "session:", Keys: 3, NoExpiry: 1, NoExpiryMemory: 100, Samples: []string{"session:3"}}, r.Prefixes[2])
+}
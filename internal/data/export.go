@@ -0,0 +1,439 @@
+package data
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// exportBatch is the number of keys read per pipeline by an export, and so the most keys held in memory.
+const exportBatch = 500
+
+// Export formats.
+const (
+	FormatJSON   = "json"   // a JSON array of records
+	FormatNDJSON = "ndjson" // a record per line
+	FormatCSV    = "csv"    // a row per element, with collections flattened
+)
+
+// ExportFormats are the formats that keys can be exported to.
+var ExportFormats = []string{FormatJSON, FormatNDJSON, FormatCSV}
+
+// EncodingBase64 is the encoding of records whose key name or values are not valid UTF-8.
+const EncodingBase64 = "base64"
+
+// Record is an exported key, with its value.
+//
+// The value depends on the type: a string, a list of strings for lists and sets, an object of fields for hashes,
+// a list of ZEntry for sorted sets, and a list of StreamEntry for streams. If the key name or any of the strings
+// of the value is not valid UTF-8, they are all base64 encoded, and Encoding is EncodingBase64.
+type Record struct {
+	Key      string `json:"key"`
+	Type     string `json:"type"`
+	TTL      int64  `json:"ttl"` // in milliseconds, or -1 for no expiry
+	Encoding string `json:"encoding,omitempty"`
+	Value    any    `json:"value"`
+}
+
+// ZEntry is a member of a sorted set.
+type ZEntry struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+// MarshalJSON writes the score as a number, or, since JSON numbers cannot be infinite, as "inf" or "-inf".
+func (z ZEntry) MarshalJSON() ([]byte, error) {
+	type entry ZEntry // without the methods of ZEntry
+	if !math.IsInf(z.Score, 0) {
+		return json.Marshal(entry(z))
+	}
+	return json.Marshal(struct {
+		Member string `json:"member"`
+		Score  string `json:"score"`
+	}{Member: z.Member, Score: formatScore(z.Score)})
+}
+
+// UnmarshalJSON reads a score written by MarshalJSON, as a number or as a string that Redis accepts as a score.
+func (z *ZEntry) UnmarshalJSON(b []byte) error {
+	var entry struct {
+		Member string          `json:"member"`
+		Score  json.RawMessage `json:"score"`
+	}
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return err
+	}
+	z.Member, z.Score = entry.Member, 0
+	if len(entry.Score) == 0 {
+		return nil
+	}
+	var score string
+	if err := json.Unmarshal(entry.Score, &score); err != nil {
+		return json.Unmarshal(entry.Score, &z.Score)
+	}
+	f, err := strconv.ParseFloat(score, 64)
+	if err != nil || math.IsNaN(f) {
+		return fmt.Errorf("invalid score %q of %s", score, entry.Member)
+	}
+	z.Score = f
+	return nil
+}
+
+// formatScore formats a sorted set score as Redis does; e.g., "inf" and "-inf" for infinite scores.
+func formatScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	default:
+		return strconv.FormatFloat(score, 'g', -1, 64)
+	}
+}
+
+// StreamEntry is an entry of a stream.
+type StreamEntry struct {
+	ID     string            `json:"id"`
+	Fields map[string]string `json:"fields"`
+}
+
+// Exporter writes records in an export format. Records are written as they are given, so an export of any
+// number of keys is streamed.
+type Exporter struct {
+	format string
+	w      io.Writer
+	csv    *csv.Writer
+	count  int
+}
+
+// NewExporter creates an exporter writing to w in the format, one of ExportFormats.
+func NewExporter(w io.Writer, format string) (*Exporter, error) {
+	e := &Exporter{format: format, w: w}
+	switch format {
+	case FormatJSON, FormatNDJSON:
+	case FormatCSV:
+		e.csv = csv.NewWriter(w)
+		if err := e.csv.Write([]string{"key", "type", "ttl", "field", "value"}); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown export format %q; the formats are %v", format, ExportFormats)
+	}
+	return e, nil
+}
+
+// Count returns the number of records written.
+func (e *Exporter) Count() int {
+	return e.count
+}
+
+// Write writes a record.
+func (e *Exporter) Write(r *Record) error {
+	var err error
+	switch e.format {
+	case FormatCSV:
+		err = e.writeCSV(r)
+	case FormatJSON:
+		prefix := ",\n"
+		if e.count == 0 {
+			prefix = "[\n"
+		}
+		err = e.writeJSON(prefix, r)
+	default:
+		err = e.writeJSON("", r)
+	}
+	if err == nil {
+		e.count++
+	}
+	return err
+}
+
+func (e *Exporter) writeJSON(prefix string, r *Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if e.format == FormatNDJSON {
+		b = append(b, '\n')
+	}
+	_, err = io.WriteString(e.w, prefix+string(b))
+	return err
+}
+
+// writeCSV writes a row for each element of the record; e.g., for each field of a hash. The field column is the
+// hash field, the list index, the sorted set member (with the score as the value), or the stream entry ID and
+// field, separated by a colon. Strings and sets have no field.
+func (e *Exporter) writeCSV(r *Record) error {
+	ttl := strconv.FormatInt(r.TTL, 10)
+	row := func(field, value string) error {
+		return e.csv.Write([]string{r.Key, r.Type, ttl, field, value})
+	}
+	var err error
+	switch v := r.Value.(type) {
+	case string:
+		err = row("", v)
+	case []string:
+		for i, s := range v {
+			field := ""
+			if r.Type == "list" {
+				field = strconv.Itoa(i)
+			}
+			err = errors.Join(err, row(field, s))
+		}
+	case map[string]string:
+		for _, f := range slices.Sorted(maps.Keys(v)) {
+			err = errors.Join(err, row(f, v[f]))
+		}
+	case []ZEntry:
+		for _, z := range v {
+			err = errors.Join(err, row(z.Member, formatScore(z.Score)))
+		}
+	case []StreamEntry:
+		for _, s := range v {
+			for _, f := range slices.Sorted(maps.Keys(s.Fields)) {
+				err = errors.Join(err, row(s.ID+":"+f, s.Fields[f]))
+			}
+		}
+	default:
+		err = row("", "")
+	}
+	return err
+}
+
+// Close finishes the export, without closing the underlying writer.
+func (e *Exporter) Close() error {
+	switch e.format {
+	case FormatCSV:
+		e.csv.Flush()
+		return e.csv.Error()
+	case FormatJSON:
+		end := "\n]\n"
+		if e.count == 0 {
+			end = "[]\n"
+		}
+		_, err := io.WriteString(e.w, end)
+		return err
+	default:
+		return nil
+	}
+}
+
+// Export writes the keys found by the scan, with their values, a batch at a time, so that only a batch of keys
+// is held in memory. progress, if set, is called with the number of keys written after each batch.
+// The scan should be lazy, since only the names of the keys are needed; see WithLazyMetadata.
+func (d *Data) Export(ctx context.Context, s *Scan, e *Exporter, progress func(int)) error {
+	if d.Offline() {
+		return ErrOffline
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var names []string
+	var writeErr error
+	flush := func() {
+		if writeErr == nil {
+			writeErr = d.ExportKeys(ctx, names, e)
+		}
+		names = names[:0]
+		if writeErr != nil {
+			cancel()
+		} else if progress != nil {
+			progress(e.Count())
+		}
+	}
+	err := d.ScanAll(ctx, s, func(k *Key) {
+		names = append(names, k.Name)
+		if len(names) >= exportBatch {
+			flush()
+		}
+	})
+	if len(names) > 0 && err == nil {
+		flush()
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+	return err
+}
+
+// ExportKeys writes the keys with the names, with their values. Keys that no longer exist are skipped.
+func (d *Data) ExportKeys(ctx context.Context, names []string, e *Exporter) error {
+	if d.Offline() {
+		return ErrOffline
+	}
+	for batch := range slices.Chunk(names, exportBatch) {
+		records, err := d.records(ctx, batch)
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			if err := e.Write(r); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// records reads the type, TTL and value of the keys, with one pipeline for the types and TTLs, and another for
+// the values, which are read by type.
+func (d *Data) records(ctx context.Context, names []string) ([]*Record, error) {
+	c := d.reader(ctx)
+	pipe := c.Pipeline()
+	types := make([]*redis.StatusCmd, len(names))
+	ttls := make([]*redis.DurationCmd, len(names))
+	for i, name := range names {
+		types[i] = pipe.Type(ctx, name)
+		ttls[i] = pipe.PTTL(ctx, name)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	records := make([]*Record, 0, len(names))
+	values := make([]func() (any, error), 0, len(names))
+	pipe = c.Pipeline()
+	for i, name := range names {
+		datatype := types[i].Val()
+		if datatype == "none" {
+			continue // deleted or expired since it was scanned
+		}
+		ttl := ttls[i].Val()
+		r := &Record{Key: name, Type: datatype, TTL: -1}
+		if ttl > 0 {
+			r.TTL = ttl.Milliseconds()
+		}
+		records = append(records, r)
+		values = append(values, readValue(ctx, pipe, name, datatype))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	kept := records[:0]
+	for i, r := range records {
+		v, err := values[i]()
+		if errors.Is(err, redis.Nil) {
+			continue // deleted between the pipelines
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", r.Key, err)
+		}
+		r.Value = v
+		encodeRecord(r)
+		kept = append(kept, r)
+	}
+	return kept, nil
+}
+
+// readValue queues the command reading the value of a key of the type, and returns a function returning the
+// value, once the pipeline is executed. Values of types that cannot be exported, such as module types, are nil.
+func readValue(ctx context.Context, pipe redis.Pipeliner, name, datatype string) func() (any, error) {
+	switch datatype {
+	case "string":
+		cmd := pipe.Get(ctx, name)
+		return func() (any, error) { return cmd.Result() }
+	case "list":
+		cmd := pipe.LRange(ctx, name, 0, -1)
+		return func() (any, error) { return cmd.Result() }
+	case "set":
+		cmd := pipe.SMembers(ctx, name)
+		return func() (any, error) {
+			members, err := cmd.Result()
+			slices.Sort(members)
+			return members, err
+		}
+	case "hash":
+		cmd := pipe.HGetAll(ctx, name)
+		return func() (any, error) { return cmd.Result() }
+	case "zset":
+		cmd := pipe.ZRangeWithScores(ctx, name, 0, -1)
+		return func() (any, error) {
+			zs, err := cmd.Result()
+			entries := make([]ZEntry, len(zs))
+			for i, z := range zs {
+				entries[i] = ZEntry{Member: fmt.Sprint(z.Member), Score: z.Score}
+			}
+			return entries, err
+		}
+	case "stream":
+		cmd := pipe.XRange(ctx, name, "-", "+")
+		return func() (any, error) {
+			msgs, err := cmd.Result()
+			entries := make([]StreamEntry, len(msgs))
+			for i, m := range msgs {
+				entries[i] = StreamEntry{ID: m.ID, Fields: make(map[string]string, len(m.Values))}
+				for f, v := range m.Values {
+					entries[i].Fields[f] = fmt.Sprint(v)
+				}
+			}
+			return entries, err
+		}
+	default:
+		return func() (any, error) { return nil, nil }
+	}
+}
+
+// encodeRecord base64 encodes the key name and the strings of the value, if any of them is not valid UTF-8.
+func encodeRecord(r *Record) {
+	valid := utf8.ValidString(r.Key)
+	mapStrings(r.Value, func(s string) string {
+		valid = valid && utf8.ValidString(s)
+		return s
+	})
+	if valid {
+		return
+	}
+	encode := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+	r.Key = encode(r.Key)
+	r.Value = mapStrings(r.Value, encode)
+	r.Encoding = EncodingBase64
+}
+
+// mapStrings returns the value with f applied to each of its strings; e.g., to the fields and values of a hash.
+func mapStrings(v any, f func(string) string) any {
+	switch v := v.(type) {
+	case string:
+		return f(v)
+	case []string:
+		mapped := make([]string, len(v))
+		for i, s := range v {
+			mapped[i] = f(s)
+		}
+		return mapped
+	case map[string]string:
+		return mapMap(v, f)
+	case []ZEntry:
+		mapped := make([]ZEntry, len(v))
+		for i, z := range v {
+			mapped[i] = ZEntry{Member: f(z.Member), Score: z.Score}
+		}
+		return mapped
+	case []StreamEntry:
+		mapped := make([]StreamEntry, len(v))
+		for i, s := range v {
+			mapped[i] = StreamEntry{ID: s.ID, Fields: mapMap(s.Fields, f)}
+		}
+		return mapped
+	default:
+		return v
+	}
+}
+
+func mapMap(m map[string]string, f func(string) string) map[string]string {
+	mapped := make(map[string]string, len(m))
+	for k, v := range m {
+		mapped[f(k)] = f(v)
+	}
+	return mapped
+}
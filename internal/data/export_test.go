@@ -0,0 +1,121 @@
+package data //nolint:testpackage // white-box testing of internal package
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var exportRecords = []*Record{
+	{Key: "greeting", Type: "string", TTL: -1, Value: "hello"},
+	{Key: "user:1", Type: "hash", TTL: 60000, Value: map[string]string{"name": "ada", "age": "36"}},
+	{Key: "queue", Type: "list", TTL: -1, Value: []string{"a", "b"}},
+	{Key: "scores", Type: "zset", TTL: -1, Value: []ZEntry{{Member: "ada", Score: 1.5}, {Member: "max", Score: math.Inf(1)}, {Member: "min", Score: math.Inf(-1)}}},
+	{Key: "events", Type: "stream", TTL: -1, Value: []StreamEntry{{ID: "1-0", Fields: map[string]string{"kind": "login"}}}},
+}
+
+func export(t *testing.T, format string, records []*Record) string {
+	t.Helper()
+	var buf bytes.Buffer
+	e, err := NewExporter(&buf, format)
+	require.NoError(t, err)
+	for _, r := range records {
+		require.NoError(t, e.Write(r))
+	}
+	require.NoError(t, e.Close())
+	assert.Equal(t, len(records), e.Count())
+	return buf.String()
+}
+
+func TestExporterJSON(t *testing.T) {
+	t.Parallel()
+
+	var records []map[string]any
+	require.NoError(t, json.Unmarshal([]byte(export(t, FormatJSON, exportRecords)), &records))
+	require.Len(t, records, len(exportRecords))
+	assert.Equal(t, "user:1", records[1]["key"])
+	assert.InDelta(t, 60000, records[1]["ttl"], 0)
+	assert.Equal(t, map[string]any{"name": "ada", "age": "36"}, records[1]["value"])
+
+	assert.Equal(t, "[]\n", export(t, FormatJSON, nil))
+}
+
+func TestExporterNDJSON(t *testing.T) {
+	t.Parallel()
+
+	lines := strings.Split(strings.TrimSuffix(export(t, FormatNDJSON, exportRecords), "\n"), "\n")
+	require.Len(t, lines, len(exportRecords))
+	assert.JSONEq(t, `{"key":"greeting","type":"string","ttl":-1,"value":"hello"}`, lines[0])
+	assert.JSONEq(t, `{"key":"scores","type":"zset","ttl":-1,"value":[{"member":"ada","score":1.5},{"member":"max","score":"inf"},{"member":"min","score":"-inf"}]}`, lines[3])
+}
+
+func TestExporterCSV(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, strings.Join([]string{
+		"key,type,ttl,field,value",
+		"greeting,string,-1,,hello",
+		"user:1,hash,60000,age,36",
+		"user:1,hash,60000,name,ada",
+		"queue,list,-1,0,a",
+		"queue,list,-1,1,b",
+		"scores,zset,-1,ada,1.5",
+		"scores,zset,-1,max,inf",
+		"scores,zset,-1,min,-inf",
+		"events,stream,-1,1-0:kind,login",
+	}, "\n")+"\n", export(t, FormatCSV, exportRecords))
+
+	_, err := NewExporter(&bytes.Buffer{}, "xml")
+	require.Error(t, err)
+}
+
+func TestEncodeRecord(t *testing.T) {
+	t.Parallel()
+
+	r := &Record{Key: "plain", Value: map[string]string{"f": "v"}}
+	encodeRecord(r)
+	assert.Empty(t, r.Encoding)
+
+	r = &Record{Key: "bin", Value: []string{"ok", "\xff\xfe"}}
+	encodeRecord(r)
+	assert.Equal(t, EncodingBase64, r.Encoding)
+	assert.Equal(t, "Ymlu", r.Key)
+	assert.Equal(t, []string{"b2s=", "//4="}, r.Value)
+}
+
+func TestExport(t *testing.T) {
+	client, d := setupTest(t)
+	ctx := context.Background()
+
+	for i := range 3 {
+		require.NoError(t, client.HSet(ctx, fmt.Sprintf("export:%d", i), "field", "value").Err())
+	}
+	require.NoError(t, client.Set(ctx, "export:s", "v", time.Hour).Err())
+	require.NoError(t, client.ZAdd(ctx, "other", redis.Z{Score: 2, Member: "m"}, redis.Z{Score: math.Inf(1), Member: "top"}).Err())
+
+	var buf bytes.Buffer
+	e, err := NewExporter(&buf, FormatNDJSON)
+	require.NoError(t, err)
+	var progress []int
+	require.NoError(t, d.Export(ctx, NewScan("export:*", 10, WithLazyMetadata()), e, func(n int) { progress = append(progress, n) }))
+	require.NoError(t, e.Close())
+	assert.Equal(t, 4, e.Count())
+	assert.Equal(t, 4, progress[len(progress)-1])
+	assert.NotContains(t, buf.String(), "other")
+	assert.Contains(t, buf.String(), `"value":{"field":"value"}`)
+
+	buf.Reset()
+	e, err = NewExporter(&buf, FormatNDJSON)
+	require.NoError(t, err)
+	require.NoError(t, d.ExportKeys(ctx, []string{"other", "missing"}, e))
+	assert.JSONEq(t, `{"key":"other","type":"zset","ttl":-1,"value":[{"member":"m","score":2},{"member":"top","score":"inf"}]}`, buf.String())
+}
@@ -0,0 +1,187 @@
+package data
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/dustin/go-humanize"
+)
+
+// Filter narrows keys that were already scanned, without scanning again. It is built by ParseFilter from
+// whitespace-separated terms, and a key matches if it matches every term:
+//
+//	user        fuzzy match; the letters appear in the key name in order, ignoring case
+//	/^user:\d+$/ regular expression on the key name
+//	type:hash   data type
+//	size>1MB    memory usage, compared with <, <=, >, >=, = or !=
+//	ttl<60s     time to live, compared the same way; keys without an expiry have an infinite TTL,
+//	            and ttl:none only matches them
+type Filter struct {
+	terms []filterTerm
+}
+
+// filterTerm is a single term of a filter.
+type filterTerm struct {
+	match    func(k *Key) bool
+	metadata bool // true if the term needs metadata that lazily scanned keys are missing
+}
+
+// comparisons are the operators of size and ttl terms, longest first so that <= is not read as <.
+var comparisons = []string{"<=", ">=", "!=", "<", ">", "=", ":"}
+
+// ParseFilter parses a filter; see Filter. An empty filter matches every key.
+func ParseFilter(s string) (*Filter, error) {
+	f := &Filter{}
+	for _, field := range strings.Fields(s) {
+		term, err := parseFilterTerm(field)
+		if err != nil {
+			return nil, err
+		}
+		f.terms = append(f.terms, term)
+	}
+	return f, nil
+}
+
+func parseFilterTerm(field string) (filterTerm, error) {
+	if len(field) > 2 && strings.HasPrefix(field, "/") && strings.HasSuffix(field, "/") {
+		re, err := regexp.Compile(field[1 : len(field)-1])
+		if err != nil {
+			return filterTerm{}, fmt.Errorf("invalid regular expression %s: %w", field, err)
+		}
+		return filterTerm{match: func(k *Key) bool { return re.MatchString(k.Name) }}, nil
+	}
+
+	if datatype, ok := strings.CutPrefix(field, "type:"); ok {
+		return filterTerm{match: func(k *Key) bool { return k.Datatype == datatype }, metadata: true}, nil
+	}
+
+	for _, name := range []string{"size", "ttl"} {
+		rest, ok := strings.CutPrefix(field, name)
+		if !ok {
+			continue
+		}
+		for _, op := range comparisons {
+			value, ok := strings.CutPrefix(rest, op)
+			if !ok {
+				continue
+			}
+			if name == "size" {
+				return sizeTerm(op, value)
+			}
+			return ttlTerm(op, value)
+		}
+	}
+
+	return filterTerm{match: func(k *Key) bool { return fuzzyMatch(field, k.Name) }}, nil
+}
+
+func sizeTerm(op, value string) (filterTerm, error) {
+	size, err := humanize.ParseBytes(value)
+	if err != nil {
+		return filterTerm{}, fmt.Errorf("invalid size %q: %w", value, err)
+	}
+	return filterTerm{
+		match:    func(k *Key) bool { return !k.Partial && compare(op, k.Size, size) },
+		metadata: true,
+	}, nil
+}
+
+func ttlTerm(op, value string) (filterTerm, error) {
+	ttl, err := parseTTL(value)
+	if err != nil {
+		return filterTerm{}, err
+	}
+	return filterTerm{
+		match:    func(k *Key) bool { return !k.Partial && compare(op, TTLOrder(k.TTL), ttl) },
+		metadata: true,
+	}, nil
+}
+
+// parseTTL parses a duration such as 60s, 1h30m or 7d, or none for keys without an expiry.
+func parseTTL(value string) (time.Duration, error) {
+	if value == "none" {
+		return TTLOrder(-1), nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err == nil {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid TTL %q; e.g., 60s, 1h or none", value)
+	}
+	return ttl, nil
+}
+
+// TTLOrder returns the TTL for comparisons, where keys without an expiry have the longest TTL.
+func TTLOrder(ttl time.Duration) time.Duration {
+	if ttl < 0 {
+		return math.MaxInt64
+	}
+	return ttl
+}
+
+func compare[T cmp.Ordered](op string, a, b T) bool {
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "!=":
+		return a != b
+	default:
+		return a == b
+	}
+}
+
+// fuzzyMatch reports whether the runes of pattern appear in s in order, ignoring case.
+func fuzzyMatch(pattern, s string) bool {
+	p := []rune(pattern)
+	for _, r := range s {
+		if len(p) == 0 {
+			break
+		}
+		if unicode.ToLower(r) == unicode.ToLower(p[0]) {
+			p = p[1:]
+		}
+	}
+	return len(p) == 0
+}
+
+// Match reports whether the key matches every term. Keys scanned without metadata do not match
+// terms on their metadata until it is fetched.
+func (f *Filter) Match(k *Key) bool {
+	for _, t := range f.terms {
+		if !t.match(k) {
+			return false
+		}
+	}
+	return true
+}
+
+// Empty returns true if the filter has no terms.
+func (f *Filter) Empty() bool {
+	return len(f.terms) == 0
+}
+
+// NeedsMetadata returns true if any term matches on metadata, rather than the key name.
+func (f *Filter) NeedsMetadata() bool {
+	for _, t := range f.terms {
+		if t.metadata {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package data //nolint:testpackage // white-box testing of internal package
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	session := &Key{Name: "session:42", Datatype: "string", Size: 2_000_000, TTL: 30 * time.Second}
+	user := &Key{Name: "user:1", Datatype: "hash", Size: 500, TTL: -1}
+	lazy := &Key{Name: "user:2", Datatype: "hash", Partial: true}
+
+	tests := []struct {
+		filter string
+		want   []*Key
+	}{
+		{filter: "", want: []*Key{session, user, lazy}},
+		{filter: "usr", want: []*Key{user, lazy}},
+		{filter: "SES42", want: []*Key{session}},
+		{filter: `/^user:\d$/`, want: []*Key{user, lazy}},
+		{filter: "type:hash", want: []*Key{user, lazy}},
+		{filter: "size>1MB", want: []*Key{session}},
+		{filter: "size<=500B", want: []*Key{user}},
+		{filter: "ttl<60s", want: []*Key{session}},
+		{filter: "ttl>1d", want: []*Key{user}},
+		{filter: "ttl:none", want: []*Key{user}},
+		{filter: "ttl!=none", want: []*Key{session}},
+		{filter: "user type:hash size<1kb", want: []*Key{user}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.filter, func(t *testing.T) {
+			t.Parallel()
+			f, err := ParseFilter(test.filter)
+			require.NoError(t, err)
+			var got []*Key
+			for _, k := range []*Key{session, user, lazy} {
+				if f.Match(k) {
+					got = append(got, k)
+				}
+			}
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, filter := range []string{"/[/", "size>lots", "ttl<soon"} {
+		_, err := ParseFilter(filter)
+		assert.Error(t, err, filter)
+	}
+}
+
+func TestFilterNeedsMetadata(t *testing.T) {
+	t.Parallel()
+
+	for filter, want := range map[string]bool{
+		"":             false,
+		"user /^a/":    false,
+		"type:hash":    true,
+		"user size>1k": true,
+		"ttl<1m":       true,
+	} {
+		f, err := ParseFilter(filter)
+		require.NoError(t, err)
+		assert.Equal(t, want, f.NeedsMetadata(), filter)
+	}
+}
@@ -0,0 +1,153 @@
+package data
+
+import "strings"
+
+// matchGlob reports whether s matches the Redis glob pattern, using the same rules as the server:
+// * matches any sequence, ? matches one byte, [abc], [^abc] and [a-z] match classes, and \ escapes.
+// It is a port of stringmatchlen from Redis util.c. Like SCAN, a lone * matches every key, including "".
+func matchGlob(pattern, s string) bool {
+	if pattern == "*" {
+		return true
+	}
+	for len(pattern) > 0 && len(s) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for len(s) > 0 {
+				if matchGlob(pattern[1:], s) {
+					return true
+				}
+				s = s[1:]
+			}
+			return false
+		case '?':
+			s = s[1:]
+		case '[':
+			pattern = pattern[1:]
+			not := len(pattern) > 0 && pattern[0] == '^'
+			if not {
+				pattern = pattern[1:]
+			}
+			match := false
+			for {
+				if len(pattern) == 0 {
+					break
+				}
+				if pattern[0] == '\\' && len(pattern) >= 2 {
+					pattern = pattern[1:]
+					if pattern[0] == s[0] {
+						match = true
+					}
+				} else if pattern[0] == ']' {
+					break
+				} else if len(pattern) >= 3 && pattern[1] == '-' {
+					start, end := pattern[0], pattern[2]
+					if start > end {
+						start, end = end, start
+					}
+					pattern = pattern[2:]
+					if s[0] >= start && s[0] <= end {
+						match = true
+					}
+				} else if pattern[0] == s[0] {
+					match = true
+				}
+				pattern = pattern[1:]
+			}
+			if not {
+				match = !match
+			}
+			if !match {
+				return false
+			}
+			s = s[1:]
+			if len(pattern) == 0 {
+				// unterminated class; the server treats the end of the pattern as the end of the class
+				return len(s) == 0
+			}
+		case '\\':
+			if len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+			if pattern[0] != s[0] {
+				return false
+			}
+			s = s[1:]
+		default:
+			if pattern[0] != s[0] {
+				return false
+			}
+			s = s[1:]
+		}
+		pattern = pattern[1:]
+		if len(s) == 0 {
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			break
+		}
+	}
+	return len(pattern) == 0 && len(s) == 0
+}
+
+// IsGlob returns true if the pattern contains an unescaped glob metacharacter (*, ? or [).
+// Patterns without one match a single key, which can be looked up directly instead of scanned.
+func IsGlob(pattern string) bool {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++ // skip the escaped character
+		case '*', '?', '[':
+			return true
+		}
+	}
+	return false
+}
+
+// EscapeGlob escapes the glob metacharacters in s, so that it can be used as a pattern that only matches s.
+func EscapeGlob(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\', '*', '?', '[', ']':
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// unescapeGlob removes the backslash escapes from a pattern that is not a glob, returning the key it matches.
+func unescapeGlob(pattern string) string {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == '\\' && i+1 < len(pattern) {
+			i++
+		}
+		sb.WriteByte(pattern[i])
+	}
+	return sb.String()
+}
+
+// globPrefix returns the literal prefix of the pattern, up to its first unescaped metacharacter; every key matching
+// the pattern starts with it.
+func globPrefix(pattern string) string {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+		case '*', '?', '[':
+			return sb.String()
+		}
+		sb.WriteByte(pattern[i])
+	}
+	return sb.String()
+}
@@ -0,0 +1,106 @@
+package data //nolint:testpackage // white-box testing of internal package
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchGlob(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{pattern: "*", s: "anything", want: true},
+		{pattern: "*", s: "", want: true},
+		{pattern: "user:*", s: "user:1", want: true},
+		{pattern: "user:*", s: "order:1", want: false},
+		{pattern: "*:1", s: "user:1", want: true},
+		{pattern: "a**b", s: "axxb", want: true},
+		{pattern: "user:?23", s: "user:123", want: true},
+		{pattern: "user:?23", s: "user:23", want: false},
+		{pattern: "order:[ab]*", s: "order:a1", want: true},
+		{pattern: "order:[ab]*", s: "order:c1", want: false},
+		{pattern: "order:[^ab]*", s: "order:c1", want: true},
+		{pattern: "order:[^ab]*", s: "order:a1", want: false},
+		{pattern: "v[0-9]", s: "v7", want: true},
+		{pattern: "v[9-0]", s: "v7", want: true},
+		{pattern: "v[0-9]", s: "vx", want: false},
+		{pattern: `a\*b`, s: "a*b", want: true},
+		{pattern: `a\*b`, s: "axb", want: false},
+		{pattern: `a[\]]b`, s: "a]b", want: true},
+		{pattern: "exact", s: "exact", want: true},
+		{pattern: "exact", s: "exactly", want: false},
+		{pattern: "a[bc", s: "ab", want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.s, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.want, matchGlob(test.pattern, test.s))
+		})
+	}
+}
+
+func TestIsGlob(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{pattern: "user:*", want: true},
+		{pattern: "user:?23", want: true},
+		{pattern: "order:[ab]1", want: true},
+		{pattern: "user:123", want: false},
+		{pattern: `user:\*`, want: false},
+		{pattern: `a\?b\[c`, want: false},
+		{pattern: `a\\*`, want: true},
+		{pattern: "", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.want, IsGlob(test.pattern))
+		})
+	}
+}
+
+func TestEscapeGlob(t *testing.T) {
+	t.Parallel()
+
+	for _, key := range []string{"plain", "a*b", "what?", "[x]", `back\slash`, "*?[]"} {
+		escaped := EscapeGlob(key)
+		assert.False(t, IsGlob(escaped), escaped)
+		assert.True(t, matchGlob(escaped, key), escaped)
+		assert.Equal(t, key, unescapeGlob(escaped))
+	}
+	assert.False(t, matchGlob(EscapeGlob("a*b"), "axb"))
+}
+
+func TestGlobPrefix(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{pattern: "user:*", want: "user:"},
+		{pattern: "user:?23", want: "user:"},
+		{pattern: "[ab]1", want: ""},
+		{pattern: "user:123", want: "user:123"},
+		{pattern: `a\*b*`, want: "a*b"},
+		{pattern: "*", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.want, globPrefix(test.pattern))
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package data
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sethrylan/readis/internal/util"
+)
+
+// Hot key methods: OBJECT FREQ under an LFU maxmemory policy, or sampling the commands seen by MONITOR.
+const (
+	HotKeysByFreq    = "freq"
+	HotKeysByMonitor = "monitor"
+)
+
+// monitorBuffer is the number of MONITOR lines buffered while they are parsed.
+const monitorBuffer = 1000
+
+// monitorDrain is how long the lines sent by MONITOR after it is stopped are drained for.
+const monitorDrain = 5 * time.Second
+
+// HotKey is a frequently accessed key.
+type HotKey struct {
+	Name string `json:"name"`
+	Hits int64  `json:"hits"` // OBJECT FREQ, or the number of accesses seen by MONITOR
+}
+
+// NodeHotKeys is the hot keys of a node.
+type NodeHotKeys struct {
+	Node string   `json:"node"`
+	Keys []HotKey `json:"keys"` // the hottest first
+}
+
+// HotKeyReport is the hot keys of each node, collected by a HotKeyAnalysis or by [Data.SampleHotKeys].
+type HotKeyReport struct {
+	Method  string        `json:"method"`           // HotKeysByFreq or HotKeysByMonitor
+	Window  time.Duration `json:"window,omitempty"` // how long MONITOR was sampled for
+	Samples int64         `json:"samples"`          // the number of keys with a frequency, or of accesses seen by MONITOR
+	Nodes   []NodeHotKeys `json:"nodes"`            // ordered by address
+}
+
+// HotKeyAnalysis collects a HotKeyReport from scanned keys, by OBJECT FREQ. The keys need their frequency, which is
+// only available under an LFU maxmemory policy; see WithMetadata and [Data.LFU].
+type HotKeyAnalysis struct {
+	top    int
+	report HotKeyReport
+	nodes  map[string][]HotKey
+}
+
+// NewHotKeyAnalysis creates an analysis reporting the top keys of each node by frequency.
+func NewHotKeyAnalysis(top int) *HotKeyAnalysis {
+	return &HotKeyAnalysis{
+		top:    top,
+		report: HotKeyReport{Method: HotKeysByFreq},
+		nodes:  make(map[string][]HotKey),
+	}
+}
+
+// Add adds a scanned key to the report. Keys without a frequency are ignored.
+func (a *HotKeyAnalysis) Add(k *Key) {
+	if k.Details == nil || k.Details.Freq < 0 {
+		return
+	}
+	a.report.Samples++
+	a.nodes[k.Node] = insertTop(a.nodes[k.Node], HotKey{Name: k.Name, Hits: k.Details.Freq}, a.top, compareHits)
+}
+
+// Report returns the report.
+func (a *HotKeyAnalysis) Report() *HotKeyReport {
+	r := a.report
+	r.Nodes = nodeHotKeys(a.nodes)
+	return &r
+}
+
+func compareHits(a, b HotKey) int {
+	return cmp.Compare(b.Hits, a.Hits)
+}
+
+// nodeHotKeys returns the hot keys of each node, ordered by address.
+func nodeHotKeys(nodes map[string][]HotKey) []NodeHotKeys {
+	result := make([]NodeHotKeys, 0, len(nodes))
+	for _, node := range slices.Sorted(maps.Keys(nodes)) {
+		result = append(result, NodeHotKeys{Node: node, Keys: nodes[node]})
+	}
+	return result
+}
+
+// LFU returns true if every master has an LFU maxmemory policy, under which OBJECT FREQ is available.
+func (d *Data) LFU(ctx context.Context) (bool, error) {
+	if d.Offline() {
+		return false, ErrOffline
+	}
+	var mu sync.Mutex
+	lfu := true
+	err := d.forEachMaster(ctx, func(ctx context.Context, rc *redis.Client) error {
+		config, err := rc.ConfigGet(ctx, "maxmemory-policy").Result()
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		lfu = lfu && strings.HasSuffix(config["maxmemory-policy"], "-lfu")
+		return nil
+	})
+	return lfu && err == nil, err
+}
+
+// SampleHotKeys counts the keys accessed by the commands run on each master, as seen by MONITOR, for the duration of
+// the window, and returns the top keys of each node. MONITOR slows the server down while it runs, so the window
+// should be short. seen is called for each access, from the goroutine of the node.
+//
+// If ctx is canceled before the window is over, the keys seen so far are returned, with the error.
+func (d *Data) SampleHotKeys(ctx context.Context, window time.Duration, top int, seen func()) (*HotKeyReport, error) {
+	if d.Offline() {
+		return nil, ErrOffline
+	}
+	sampleCtx, cancel := context.WithTimeout(ctx, window)
+	defer cancel()
+
+	var mu sync.Mutex
+	counts := make(map[string]map[string]int64)
+	err := d.forEachMaster(sampleCtx, func(ctx context.Context, rc *redis.Client) error {
+		addr := rc.Options().Addr
+		node := make(map[string]int64)
+		err := monitorKeys(ctx, rc, d.db(), func(name string) {
+			node[name]++
+			seen()
+		})
+		mu.Lock()
+		defer mu.Unlock()
+		counts[addr] = node
+		return err
+	})
+
+	report := &HotKeyReport{Method: HotKeysByMonitor, Window: window}
+	nodes := make(map[string][]HotKey, len(counts))
+	for addr, node := range counts {
+		var keys []HotKey
+		for name, hits := range node {
+			report.Samples += hits
+			keys = insertTop(keys, HotKey{Name: name, Hits: hits}, top, compareHits)
+		}
+		nodes[addr] = keys
+	}
+	report.Nodes = nodeHotKeys(nodes)
+	if err == nil || (errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil) {
+		return report, nil
+	}
+	return report, err
+}
+
+// monitorKeys calls visit for each key accessed by the commands seen by MONITOR on the node, in the database db,
+// until ctx is done. MONITOR takes over its connection, so it runs on a connection of its own.
+func monitorKeys(ctx context.Context, rc *redis.Client, db int, visit func(name string)) error {
+	commands, err := rc.Command(ctx).Result()
+	if err != nil {
+		return err
+	}
+
+	opts := *rc.Options()
+	opts.PoolSize = 1
+	opts.MinIdleConns = 0
+	mc := redis.NewClient(&opts)
+	lines := make(chan string, monitorBuffer)
+	monitor := mc.Monitor(ctx, lines)
+	monitor.Start()
+	defer func() {
+		monitor.Stop()
+		_ = mc.Close()
+		// the monitor goroutine may still be sending a line
+		go func() {
+			timeout := time.After(monitorDrain)
+			for {
+				select {
+				case <-lines:
+				case <-timeout:
+					return
+				}
+			}
+		}()
+	}()
+
+	started := false
+	for {
+		select {
+		case <-ctx.Done():
+			if !started {
+				return errors.New("MONITOR did not start on " + opts.Addr + "; it may not be permitted")
+			}
+			return ctx.Err()
+		case line := <-lines:
+			if line == "OK" {
+				started = true
+				continue
+			}
+			lineDB, args, ok := parseMonitorLine(line)
+			if !ok || lineDB != db {
+				util.Debug("monitor: ", line)
+				continue
+			}
+			for _, name := range commandKeys(commands, args) {
+				visit(name)
+			}
+		}
+	}
+}
+
+// parseMonitorLine parses a line of MONITOR output; e.g., `1339518083.107412 [0 127.0.0.1:60866] "get" "key"`,
+// into the database number and the arguments of the command.
+func parseMonitorLine(line string) (db int, args []string, ok bool) {
+	_, rest, found := strings.Cut(line, " [")
+	if !found {
+		return 0, nil, false
+	}
+	client, rest, found := strings.Cut(rest, "] ")
+	if !found {
+		return 0, nil, false
+	}
+	dbField, _, _ := strings.Cut(client, " ")
+	db, err := strconv.Atoi(dbField)
+	if err != nil {
+		return 0, nil, false
+	}
+
+	for rest != "" {
+		if rest[0] != '"' {
+			return 0, nil, false
+		}
+		end := 1
+		for end < len(rest) && rest[end] != '"' {
+			if rest[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(rest) {
+			return 0, nil, false
+		}
+		arg, err := strconv.Unquote(rest[:end+1])
+		if err != nil {
+			return 0, nil, false
+		}
+		args = append(args, arg)
+		rest = strings.TrimPrefix(rest[end+1:], " ")
+	}
+	return db, args, len(args) > 0
+}
+
+// commandKeys returns the key arguments of a command, from the key positions given by COMMAND. Commands whose
+// keys are not at fixed positions (e.g., EVAL) have none.
+func commandKeys(commands map[string]*redis.CommandInfo, args []string) []string {
+	info, ok := commands[strings.ToLower(args[0])]
+	if !ok || info.FirstKeyPos <= 0 {
+		return nil
+	}
+	last := int(info.LastKeyPos)
+	if last < 0 {
+		last += len(args)
+	}
+	step := max(int(info.StepCount), 1)
+	var keys []string
+	for i := int(info.FirstKeyPos); i <= last && i < len(args); i += step {
+		keys = append(keys, args[i])
+	}
+	return keys
+}
@@ -0,0 +1,93 @@
+package data //nolint:testpackage // white-box testing of internal package
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMonitorLine(t *testing.T) {
+	t.Parallel()
+
+	db, args, ok := parseMonitorLine(`1339518083.107412 [0 127.0.0.1:60866] "get" "user:1"`)
+	require.True(t, ok)
+	assert.Equal(t, 0, db)
+	assert.Equal(t, []string{"get", "user:1"}, args)
+
+	db, args, ok = parseMonitorLine(`1339518083.107412 [3 lua] "set" "a \"quoted\" key" "\x00\n"`)
+	require.True(t, ok)
+	assert.Equal(t, 3, db)
+	assert.Equal(t, []string{"set", `a "quoted" key`, "\x00\n"}, args)
+
+	_, _, ok = parseMonitorLine("OK")
+	assert.False(t, ok)
+	_, _, ok = parseMonitorLine(`1339518083.107412 [0 127.0.0.1:60866] "unterminated`)
+	assert.False(t, ok)
+}
+
+func TestCommandKeys(t *testing.T) {
+	t.Parallel()
+
+	commands := map[string]*redis.CommandInfo{
+		"get":  {FirstKeyPos: 1, LastKeyPos: 1, StepCount: 1},
+		"mget": {FirstKeyPos: 1, LastKeyPos: -1, StepCount: 1},
+		"mset": {FirstKeyPos: 1, LastKeyPos: -1, StepCount: 2},
+		"eval": {FirstKeyPos: 0, LastKeyPos: 0, StepCount: 0},
+	}
+	assert.Equal(t, []string{"a"}, commandKeys(commands, []string{"GET", "a"}))
+	assert.Equal(t, []string{"a", "b", "c"}, commandKeys(commands, []string{"mget", "a", "b", "c"}))
+	assert.Equal(t, []string{"a", "b"}, commandKeys(commands, []string{"mset", "a", "1", "b", "2"}))
+	assert.Empty(t, commandKeys(commands, []string{"eval", "return 1", "0"}))
+	assert.Empty(t, commandKeys(commands, []string{"ping"}))
+}
+
+func TestHotKeyAnalysis(t *testing.T) {
+	t.Parallel()
+
+	a := NewHotKeyAnalysis(2)
+	a.Add(&Key{Name: "a", Node: "n2", Details: &KeyDetails{Freq: 5}})
+	a.Add(&Key{Name: "b", Node: "n1", Details: &KeyDetails{Freq: 1}})
+	a.Add(&Key{Name: "c", Node: "n1", Details: &KeyDetails{Freq: 200}})
+	a.Add(&Key{Name: "d", Node: "n1", Details: &KeyDetails{Freq: 7}})
+	a.Add(&Key{Name: "e", Node: "n1", Details: &KeyDetails{Freq: -1}})
+	a.Add(&Key{Name: "f", Node: "n1"})
+	r := a.Report()
+
+	assert.Equal(t, HotKeysByFreq, r.Method)
+	assert.Equal(t, int64(4), r.Samples)
+	assert.Equal(t, []NodeHotKeys{
+		{Node: "n1", Keys: []HotKey{{Name: "c", Hits: 200}, {Name: "d", Hits: 7}}},
+		{Node: "n2", Keys: []HotKey{{Name: "a", Hits: 5}}},
+	}, r.Nodes)
+}
+
+func TestSampleHotKeys(t *testing.T) {
+	client, d := setupTest(t)
+	ctx := context.Background()
+
+	require.NoError(t, client.Set(ctx, "hot", "v", 0).Err())
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				client.Get(ctx, "hot")
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+	}()
+	defer close(stop)
+
+	r, err := d.SampleHotKeys(ctx, time.Second, 5, func() {})
+	require.NoError(t, err)
+	assert.Equal(t, HotKeysByMonitor, r.Method)
+	require.Len(t, r.Nodes, 1)
+	require.NotEmpty(t, r.Nodes[0].Keys)
+	assert.Equal(t, "hot", r.Nodes[0].Keys[0].Name)
+}
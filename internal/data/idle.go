@@ -0,0 +1,169 @@
+package data
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// maxColdKeys bounds the number of cold keys listed by an IdleReport.
+const maxColdKeys = 100000
+
+// expireBatch is the number of keys expired per pipeline by ExpireCold.
+const expireBatch = 100
+
+// Coldness decides whether a key is cold: not accessed for at least MinIdle, from OBJECT IDLETIME, or, when the
+// maxmemory policy is LFU and the idle time is unavailable, with an OBJECT FREQ of at most MaxFreq.
+type Coldness struct {
+	MinIdle time.Duration `json:"minIdle"`
+	MaxFreq int64         `json:"maxFreq"`
+}
+
+// Cold returns whether the key is cold, and false for known if it has neither an idle time nor a frequency.
+func (c Coldness) Cold(d *KeyDetails) (cold, known bool) {
+	switch {
+	case d == nil:
+		return false, false
+	case d.Idle >= 0:
+		return d.Idle >= c.MinIdle, true
+	case d.Freq >= 0:
+		return d.Freq <= c.MaxFreq, true
+	default:
+		return false, false
+	}
+}
+
+// ColdKey is a cold key in an IdleReport.
+type ColdKey struct {
+	Name   string        `json:"name"`
+	Type   string        `json:"type"`
+	Memory uint64        `json:"memory"`
+	Idle   time.Duration `json:"idle"` // or -1 if unavailable
+	Freq   int64         `json:"freq"` // or -1 if unavailable
+}
+
+// IdleReport is the keys that were not accessed recently, collected by an IdleAnalysis.
+type IdleReport struct {
+	Coldness    Coldness      `json:"coldness"`
+	Keys        int           `json:"keys"`
+	Unavailable int           `json:"unavailable"` // keys with neither an idle time nor a frequency
+	Cold        int           `json:"cold"`
+	ColdMemory  uint64        `json:"coldMemory"`
+	Prefixes    []PrefixUsage `json:"prefixes"`  // the cold keys by prefix, largest first
+	ColdKeys    []ColdKey     `json:"coldKeys"`  // the first maxColdKeys cold keys found
+	Truncated   bool          `json:"truncated"` // true if more keys were cold than listed
+}
+
+// IdleAnalysis collects an IdleReport from scanned keys. The keys need their memory usage, idle time and frequency;
+// see WithMetadata.
+type IdleAnalysis struct {
+	top       int
+	delimiter string
+	depth     int
+	report    IdleReport
+	prefixes  map[string]*PrefixUsage
+}
+
+// NewIdleAnalysis creates an analysis reporting the keys that are cold, and their memory usage by the prefixes of
+// key names, up to depth segments separated by the delimiter.
+func NewIdleAnalysis(top int, delimiter string, depth int, c Coldness) *IdleAnalysis {
+	return &IdleAnalysis{
+		top:       top,
+		delimiter: delimiter,
+		depth:     depth,
+		report:    IdleReport{Coldness: c},
+		prefixes:  make(map[string]*PrefixUsage),
+	}
+}
+
+// Add adds a scanned key to the report.
+func (a *IdleAnalysis) Add(k *Key) {
+	r := &a.report
+	r.Keys++
+	cold, known := r.Coldness.Cold(k.Details)
+	if !known {
+		r.Unavailable++
+	}
+	if !cold {
+		return
+	}
+	r.Cold++
+	r.ColdMemory += k.Size
+	trackPrefix(a.prefixes, KeyPrefix(k.Name, a.delimiter, a.depth), func(prefix string) *PrefixUsage {
+		return &PrefixUsage{Prefix: prefix}
+	}).add(k)
+	if len(r.ColdKeys) >= maxColdKeys {
+		r.Truncated = true
+		return
+	}
+	r.ColdKeys = append(r.ColdKeys, ColdKey{Name: k.Name, Type: k.Datatype, Memory: k.Size, Idle: k.Details.Idle, Freq: k.Details.Freq})
+}
+
+// Report returns the report, with up to top prefixes.
+func (a *IdleAnalysis) Report() *IdleReport {
+	r := a.report
+	r.Prefixes = make([]PrefixUsage, 0, len(a.prefixes))
+	for _, p := range a.prefixes {
+		r.Prefixes = append(r.Prefixes, *p)
+	}
+	slices.SortFunc(r.Prefixes, func(a, b PrefixUsage) int {
+		return cmp.Or(cmp.Compare(b.Memory, a.Memory), strings.Compare(a.Prefix, b.Prefix))
+	})
+	r.Prefixes = r.Prefixes[:min(len(r.Prefixes), a.top)]
+	return &r
+}
+
+// expireColdScript sets the TTL of KEYS[1] to ARGV[1] milliseconds if the key is still cold: idle for at least
+// ARGV[2] seconds, or, if OBJECT IDLETIME is unavailable, with a frequency of at most ARGV[3]. Keys expiring
+// sooner keep their TTL. It returns 1 if the TTL was set.
+var expireColdScript = redis.NewScript(`
+local key = KEYS[1]
+local pttl = redis.call('PTTL', key)
+if pttl == -2 or (pttl >= 0 and pttl <= tonumber(ARGV[1])) then
+  return 0
+end
+local cold = false
+local ok, idle = pcall(redis.call, 'OBJECT', 'IDLETIME', key)
+if ok then
+  cold = idle >= tonumber(ARGV[2])
+else
+  local fok, freq = pcall(redis.call, 'OBJECT', 'FREQ', key)
+  cold = fok and freq <= tonumber(ARGV[3])
+end
+if not cold then
+  return 0
+end
+return redis.call('PEXPIRE', key, ARGV[1])
+`)
+
+// ExpireCold sets a TTL on the keys that are still cold, so that they are deleted unless they are read again,
+// or made persistent, before the TTL is up. Keys that were accessed since they were scanned are left alone.
+// It returns the number of keys given a TTL.
+func (d *Data) ExpireCold(ctx context.Context, names []string, c Coldness, ttl time.Duration) (int, error) {
+	if d.Offline() {
+		return 0, ErrOffline
+	}
+	expired := 0
+	for batch := range slices.Chunk(names, expireBatch) {
+		pipe := d.client().Pipeline()
+		cmds := make([]*redis.Cmd, len(batch))
+		for i, name := range batch {
+			cmds[i] = expireColdScript.Eval(ctx, pipe, []string{name},
+				ttl.Milliseconds(), int64(c.MinIdle.Seconds()), c.MaxFreq)
+		}
+		_, err := pipe.Exec(ctx)
+		for _, cmd := range cmds {
+			if n, _ := cmd.Int(); n == 1 {
+				expired++
+			}
+		}
+		if err != nil {
+			return expired, err
+		}
+	}
+	return expired, nil
+}
@@ -0,0 +1,72 @@
+package data //nolint:testpackage // white-box testing of internal package
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColdness(t *testing.T) {
+	t.Parallel()
+
+	c := Coldness{MinIdle: time.Hour, MaxFreq: 1}
+	tests := []struct {
+		details     *KeyDetails
+		cold, known bool
+	}{
+		{nil, false, false},
+		{&KeyDetails{Idle: 2 * time.Hour, Freq: -1}, true, true},
+		{&KeyDetails{Idle: time.Minute, Freq: -1}, false, true},
+		{&KeyDetails{Idle: -1, Freq: 0}, true, true},
+		{&KeyDetails{Idle: -1, Freq: 5}, false, true},
+		{&KeyDetails{Idle: -1, Freq: -1}, false, false},
+	}
+	for _, tt := range tests {
+		cold, known := c.Cold(tt.details)
+		assert.Equal(t, tt.cold, cold, "%+v", tt.details)
+		assert.Equal(t, tt.known, known, "%+v", tt.details)
+	}
+}
+
+func TestIdleAnalysis(t *testing.T) {
+	t.Parallel()
+
+	a := NewIdleAnalysis(5, ":", 1, Coldness{MinIdle: time.Hour})
+	a.Add(&Key{Name: "job:1", Datatype: "hash", Size: 300, Details: &KeyDetails{Idle: 48 * time.Hour, Freq: -1}})
+	a.Add(&Key{Name: "job:2", Datatype: "hash", Size: 200, Details: &KeyDetails{Idle: 2 * time.Hour, Freq: -1}})
+	a.Add(&Key{Name: "job:3", Datatype: "hash", Size: 100, Details: &KeyDetails{Idle: time.Second, Freq: -1}})
+	a.Add(&Key{Name: "tmp:1", Datatype: "string", Size: 50, Details: &KeyDetails{Idle: 3 * time.Hour, Freq: -1}})
+	a.Add(&Key{Name: "tmp:2", Datatype: "string", Size: 50})
+	r := a.Report()
+
+	assert.Equal(t, 5, r.Keys)
+	assert.Equal(t, 1, r.Unavailable)
+	assert.Equal(t, 3, r.Cold)
+	assert.Equal(t, uint64(550), r.ColdMemory)
+	assert.Equal(t, []PrefixUsage{{Prefix: "job:", Keys: 2, Memory: 500}, {Prefix: "tmp:", Keys: 1, Memory: 50}}, r.Prefixes)
+	require.Len(t, r.ColdKeys, 3)
+	assert.Equal(t, ColdKey{Name: "job:1", Type: "hash", Memory: 300, Idle: 48 * time.Hour, Freq: -1}, r.ColdKeys[0])
+	assert.False(t, r.Truncated)
+}
+
+func TestExpireCold(t *testing.T) {
+	client, d := setupTest(t)
+	ctx := context.Background()
+
+	require.NoError(t, client.Set(ctx, "cold:1", "v", 0).Err())
+	require.NoError(t, client.Set(ctx, "cold:2", "v", time.Minute).Err())
+
+	n, err := d.ExpireCold(ctx, []string{"cold:1", "cold:2", "cold:missing"}, Coldness{MinIdle: time.Hour}, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n, "keys accessed since the scan are not expired")
+	assert.Equal(t, time.Duration(-1), client.TTL(ctx, "cold:1").Val())
+
+	n, err = d.ExpireCold(ctx, []string{"cold:1", "cold:2", "cold:missing"}, Coldness{}, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "keys expiring sooner keep their TTL")
+	assert.Greater(t, client.TTL(ctx, "cold:1").Val(), 59*time.Minute)
+	assert.LessOrEqual(t, client.TTL(ctx, "cold:2").Val(), time.Minute)
+}
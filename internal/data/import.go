@@ -0,0 +1,297 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// importBatch is the number of records written per pipeline by an import.
+const importBatch = 500
+
+// importChunk is the most elements written by a single command, so that large collections are not sent as one
+// enormous command.
+const importChunk = 1000
+
+// Import modes, for keys that already exist.
+const (
+	ImportSkip      = "skip"      // existing keys are left as they are
+	ImportOverwrite = "overwrite" // existing keys are replaced
+	ImportFail      = "fail"      // the import stops at the first existing key
+)
+
+// ImportModes are the modes of an import.
+var ImportModes = []string{ImportSkip, ImportOverwrite, ImportFail}
+
+// ErrKeyExists is returned by an import in ImportFail mode when a key already exists.
+var ErrKeyExists = errors.New("key already exists")
+
+// ImportSummary counts the records of an import, and what was done with them.
+type ImportSummary struct {
+	Records     int      // the number of records read
+	New         int      // records of keys that did not exist
+	Existing    int      // records of keys that already existed
+	Written     int      // records written; with a dry run, that would be written
+	Skipped     int      // records of existing keys that were skipped
+	Unsupported int      // records without a value, such as exported module types, which are skipped
+	Samples     []string // the names of some existing keys
+}
+
+// RecordReader reads the records written by an Exporter in the JSON or NDJSON format, one at a time.
+type RecordReader struct {
+	dec   *json.Decoder
+	array bool // true if the records are in a JSON array
+	start bool // true once the start of the array has been read
+}
+
+// NewRecordReader creates a reader of the records in r, which is a JSON array of records or a record per line.
+func NewRecordReader(r io.Reader) *RecordReader {
+	br := bufio.NewReader(r)
+	rr := &RecordReader{}
+	for {
+		b, err := br.Peek(1)
+		if err != nil || !bytes.ContainsAny(b, " \t\r\n") {
+			rr.array = err == nil && b[0] == '['
+			break
+		}
+		_, _ = br.ReadByte()
+	}
+	rr.dec = json.NewDecoder(br)
+	return rr
+}
+
+// Next returns the next record, or io.EOF after the last record.
+func (rr *RecordReader) Next() (*Record, error) {
+	if rr.array && !rr.start {
+		if _, err := rr.dec.Token(); err != nil {
+			return nil, err
+		}
+		rr.start = true
+	}
+	if rr.array && !rr.dec.More() {
+		return nil, io.EOF
+	}
+
+	var raw struct {
+		Record
+		Value json.RawMessage `json:"value"`
+	}
+	if err := rr.dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	r := raw.Record
+	if r.Key == "" || r.Type == "" {
+		return nil, fmt.Errorf("record without a key or type, at offset %d", rr.dec.InputOffset())
+	}
+	value, err := decodeValue(r.Type, raw.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value of %s: %w", r.Key, err)
+	}
+	r.Value = value
+	if err := decodeRecord(&r); err != nil {
+		return nil, fmt.Errorf("invalid encoding of %s: %w", r.Key, err)
+	}
+	return &r, nil
+}
+
+// decodeValue decodes the value of a record of the type; see Record. Values of other types are nil.
+func decodeValue(datatype string, raw json.RawMessage) (any, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	switch datatype {
+	case "string":
+		return unmarshal[string](raw)
+	case "list", "set":
+		return unmarshal[[]string](raw)
+	case "hash":
+		return unmarshal[map[string]string](raw)
+	case "zset":
+		return unmarshal[[]ZEntry](raw)
+	case "stream":
+		return unmarshal[[]StreamEntry](raw)
+	default:
+		return nil, nil
+	}
+}
+
+func unmarshal[T any](raw json.RawMessage) (any, error) {
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// decodeRecord decodes the key name and the strings of the value of a base64 encoded record.
+func decodeRecord(r *Record) error {
+	switch r.Encoding {
+	case "":
+		return nil
+	case EncodingBase64:
+	default:
+		return fmt.Errorf("unknown encoding %q", r.Encoding)
+	}
+	var err error
+	decode := func(s string) string {
+		b, decodeErr := base64.StdEncoding.DecodeString(s)
+		err = errors.Join(err, decodeErr)
+		return string(b)
+	}
+	r.Key = decode(r.Key)
+	r.Value = mapStrings(r.Value, decode)
+	r.Encoding = ""
+	return err
+}
+
+// Import writes the records read from rr, a batch at a time, with what is done with existing keys set by the mode.
+// With a dry run, nothing is written, and the summary counts what would be written. progress, if set, is called
+// with the summary after each batch.
+//
+// In ImportFail mode, the import stops before the batch with the first existing key, returning ErrKeyExists,
+// so the records of earlier batches have been written; a dry run finds the existing keys first.
+func (d *Data) Import(ctx context.Context, rr *RecordReader, mode string, dryRun bool, progress func(*ImportSummary)) (*ImportSummary, error) {
+	if d.Offline() {
+		return nil, ErrOffline
+	}
+	if !slices.Contains(ImportModes, mode) {
+		return nil, fmt.Errorf("unknown import mode %q; the modes are %v", mode, ImportModes)
+	}
+	summary := &ImportSummary{}
+	batch := make([]*Record, 0, importBatch)
+	for {
+		r, err := rr.Next()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return summary, err
+		}
+		if r != nil {
+			summary.Records++
+			if r.Value == nil {
+				summary.Unsupported++
+			} else {
+				batch = append(batch, r)
+			}
+		}
+		if len(batch) == importBatch || (errors.Is(err, io.EOF) && len(batch) > 0) {
+			if batchErr := d.importBatch(ctx, batch, mode, dryRun, summary); batchErr != nil {
+				return summary, batchErr
+			}
+			batch = batch[:0]
+			if progress != nil {
+				progress(summary)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return summary, nil
+		}
+	}
+}
+
+// importBatch checks which keys of the batch exist, and writes the records as the mode says.
+func (d *Data) importBatch(ctx context.Context, batch []*Record, mode string, dryRun bool, summary *ImportSummary) error {
+	c := d.client()
+	pipe := c.Pipeline()
+	exists := make([]*redis.IntCmd, len(batch))
+	for i, r := range batch {
+		exists[i] = pipe.Exists(ctx, r.Key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	writes := make([]*Record, 0, len(batch))
+	for i, r := range batch {
+		if exists[i].Val() == 0 {
+			summary.New++
+			writes = append(writes, r)
+			continue
+		}
+		summary.Existing++
+		if len(summary.Samples) < maxSamples {
+			summary.Samples = append(summary.Samples, r.Key)
+		}
+		switch mode {
+		case ImportSkip:
+			summary.Skipped++
+		case ImportFail:
+			if !dryRun {
+				return fmt.Errorf("%w: %s", ErrKeyExists, r.Key)
+			}
+		default:
+			writes = append(writes, r)
+		}
+	}
+	if dryRun {
+		summary.Written += len(writes)
+		return nil
+	}
+
+	pipe = c.Pipeline()
+	for _, r := range writes {
+		writeRecord(ctx, pipe, r)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	summary.Written += len(writes)
+	return nil
+}
+
+// writeRecord queues the commands replacing the key with the record.
+func writeRecord(ctx context.Context, pipe redis.Pipeliner, r *Record) {
+	pipe.Del(ctx, r.Key)
+	switch v := r.Value.(type) {
+	case string:
+		pipe.Set(ctx, r.Key, v, 0)
+	case []string:
+		for chunk := range slices.Chunk(v, importChunk) {
+			args := make([]any, len(chunk))
+			for i, s := range chunk {
+				args[i] = s
+			}
+			if r.Type == "set" {
+				pipe.SAdd(ctx, r.Key, args...)
+			} else {
+				pipe.RPush(ctx, r.Key, args...)
+			}
+		}
+	case map[string]string:
+		fields := slices.Sorted(maps.Keys(v))
+		for chunk := range slices.Chunk(fields, importChunk) {
+			args := make([]any, 0, 2*len(chunk))
+			for _, f := range chunk {
+				args = append(args, f, v[f])
+			}
+			pipe.HSet(ctx, r.Key, args...)
+		}
+	case []ZEntry:
+		for chunk := range slices.Chunk(v, importChunk) {
+			members := make([]redis.Z, len(chunk))
+			for i, z := range chunk {
+				members[i] = redis.Z{Score: z.Score, Member: z.Member}
+			}
+			pipe.ZAdd(ctx, r.Key, members...)
+		}
+	case []StreamEntry:
+		for _, e := range v {
+			values := make([]any, 0, 2*len(e.Fields))
+			for _, f := range slices.Sorted(maps.Keys(e.Fields)) {
+				values = append(values, f, e.Fields[f])
+			}
+			pipe.XAdd(ctx, &redis.XAddArgs{Stream: r.Key, ID: e.ID, Values: values})
+		}
+	}
+	if r.TTL > 0 {
+		pipe.PExpire(ctx, r.Key, time.Duration(r.TTL)*time.Millisecond)
+	}
+}
@@ -0,0 +1,86 @@
+package data //nolint:testpackage // white-box testing of internal package
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readRecords(t *testing.T, input string) []*Record {
+	t.Helper()
+	rr := NewRecordReader(strings.NewReader(input))
+	var records []*Record
+	for {
+		r, err := rr.Next()
+		if errors.Is(err, io.EOF) {
+			return records
+		}
+		require.NoError(t, err)
+		records = append(records, r)
+	}
+}
+
+func TestRecordReader(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range []string{FormatJSON, FormatNDJSON} {
+		records := readRecords(t, export(t, format, exportRecords))
+		assert.Equal(t, exportRecords, records, format)
+	}
+	assert.Empty(t, readRecords(t, "[]"))
+	assert.Empty(t, readRecords(t, ""))
+
+	records := readRecords(t, `  {"key":"Ymlu","type":"set","ttl":-1,"encoding":"base64","value":["b2s=","//4="]}
+{"key":"module","type":"ReJSON-RL","ttl":-1,"value":null}`)
+	assert.Equal(t, []*Record{
+		{Key: "bin", Type: "set", TTL: -1, Value: []string{"ok", "\xff\xfe"}},
+		{Key: "module", Type: "ReJSON-RL", TTL: -1},
+	}, records)
+
+	_, err := NewRecordReader(strings.NewReader(`{"key":"a","type":"hash","value":"not a hash"}`)).Next()
+	require.Error(t, err)
+	_, err = NewRecordReader(strings.NewReader(`{"value":"no key"}`)).Next()
+	require.Error(t, err)
+	_, err = NewRecordReader(strings.NewReader(`{"key":"z","type":"zset","value":[{"member":"a","score":"high"}]}`)).Next()
+	require.ErrorContains(t, err, "invalid score")
+}
+
+func TestImport(t *testing.T) {
+	client, d := setupTest(t)
+	ctx := context.Background()
+
+	require.NoError(t, client.Set(ctx, "greeting", "existing", 0).Err())
+	input := export(t, FormatNDJSON, exportRecords)
+
+	summary, err := d.Import(ctx, NewRecordReader(strings.NewReader(input)), ImportFail, true, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 5, summary.Records)
+	assert.Equal(t, 1, summary.Existing)
+	assert.Equal(t, []string{"greeting"}, summary.Samples)
+	assert.Equal(t, int64(1), client.Exists(ctx, "greeting", "user:1").Val(), "a dry run writes nothing")
+
+	_, err = d.Import(ctx, NewRecordReader(strings.NewReader(input)), ImportFail, false, nil)
+	require.ErrorIs(t, err, ErrKeyExists)
+
+	summary, err = d.Import(ctx, NewRecordReader(strings.NewReader(input)), ImportSkip, false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 4, summary.Written)
+	assert.Equal(t, 1, summary.Skipped)
+	assert.Equal(t, "existing", client.Get(ctx, "greeting").Val())
+	assert.Equal(t, map[string]string{"name": "ada", "age": "36"}, client.HGetAll(ctx, "user:1").Val())
+	assert.Greater(t, client.PTTL(ctx, "user:1").Val(), 50*time.Second)
+	assert.Equal(t, []string{"a", "b"}, client.LRange(ctx, "queue", 0, -1).Val())
+	assert.Equal(t, int64(1), client.XLen(ctx, "events").Val())
+
+	summary, err = d.Import(ctx, NewRecordReader(strings.NewReader(input)), ImportOverwrite, false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 5, summary.Written)
+	assert.Equal(t, "hello", client.Get(ctx, "greeting").Val())
+	assert.Equal(t, []string{"a", "b"}, client.LRange(ctx, "queue", 0, -1).Val(), "overwritten lists are not appended to")
+}
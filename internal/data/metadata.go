@@ -0,0 +1,403 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sethrylan/readis/internal/util"
+)
+
+// Detail is optional key metadata, which is only fetched when it is shown.
+type Detail uint32
+
+const (
+	// DetailLength is the number of elements; e.g., HLEN or LLEN. For strings, it is STRLEN.
+	DetailLength Detail = 1 << iota
+	// DetailEncoding is the internal encoding, from OBJECT ENCODING.
+	DetailEncoding
+	// DetailIdle is the time since the key was last accessed, from OBJECT IDLETIME.
+	// It is unavailable when the maxmemory policy is LFU.
+	DetailIdle
+	// DetailFreq is the logarithmic access frequency counter, from OBJECT FREQ.
+	// It is only available when the maxmemory policy is LFU.
+	DetailFreq
+)
+
+// KeyDetails is the optional metadata of a key.
+type KeyDetails struct {
+	Length   int64         // -1 if not fetched
+	Encoding string        // "" if not fetched
+	Idle     time.Duration // -1 if not fetched or unavailable
+	Freq     int64         // -1 if not fetched or unavailable
+}
+
+// lengthCommands are the commands returning the number of elements of each type.
+var lengthCommands = map[string]string{
+	"string": "STRLEN",
+	"list":   "LLEN",
+	"set":    "SCARD",
+	"zset":   "ZCARD",
+	"hash":   "HLEN",
+	"stream": "XLEN",
+}
+
+// maxScriptKeys is the most keys whose metadata is collected by one run of metadataScript. The server runs nothing
+// else while a script runs, and MEMORY USAGE of large values is slow, so a page of keys is split into batches.
+const maxScriptKeys = 100
+
+// metadataScript returns a {type, pttl, memory usage, length, encoding, idle time, freq} tuple for each key, in
+// order. Keys are read from KEYS, so that a single key is routed by the cluster client, and then from ARGV[2:],
+// so that a batch of keys from one node is not rejected as CROSSSLOT. ARGV[1] has a flag for each optional value:
+// m (memory usage), l (length), e (encoding), i (idle time) and f (freq); values that are not fetched are -1 or "".
+// A flag s followed by a number sets the SAMPLES of MEMORY USAGE.
+//
+// The idle time and frequency are read first, since reading the length counts as an access.
+var metadataScript = redis.NewScript(`
+local lengths = {string = 'STRLEN', list = 'LLEN', set = 'SCARD', zset = 'ZCARD', hash = 'HLEN', stream = 'XLEN'}
+
+local function has(flag)
+  return string.find(ARGV[1], flag, 1, true) ~= nil
+end
+
+local samples = string.match(ARGV[1], 's(%d+)')
+
+local function object(subcommand, key)
+  local reply = redis.pcall('OBJECT', subcommand, key)
+  if type(reply) ~= 'number' then
+    return -1
+  end
+  return reply
+end
+
+local function metadata(key)
+  local datatype = redis.call('TYPE', key)['ok']
+  local size, length, encoding, idle, freq = -1, -1, '', -1, -1
+  if datatype ~= 'none' then
+    if has('i') then idle = object('IDLETIME', key) end
+    if has('f') then freq = object('FREQ', key) end
+    if has('e') then encoding = redis.call('OBJECT', 'ENCODING', key) or '' end
+    if has('m') and samples then
+      size = redis.call('MEMORY', 'USAGE', key, 'SAMPLES', samples) or -1
+    elseif has('m') then
+      size = redis.call('MEMORY', 'USAGE', key) or -1
+    end
+    if has('l') and lengths[datatype] then length = redis.call(lengths[datatype], key) end
+  end
+  return {datatype, redis.call('PTTL', key), size, length, encoding, idle, freq}
+end
+
+local result = {}
+for _, key in ipairs(KEYS) do
+  result[#result + 1] = metadata(key)
+end
+for i = 2, #ARGV do
+  result[#result + 1] = metadata(ARGV[i])
+end
+return result
+`)
+
+// SetMemoryUsage turns MEMORY USAGE on or off for the keys found by later scans. MEMORY USAGE is the most
+// expensive part of collecting metadata for huge keys; when it is off, the size of keys is 0.
+func (d *Data) SetMemoryUsage(on bool) {
+	d.noMemory.Store(!on)
+	d.cache.clear()
+}
+
+// MemoryUsage returns true if the size of keys is collected.
+func (d *Data) MemoryUsage() bool {
+	return !d.noMemory.Load()
+}
+
+// SetDetails sets the optional metadata collected for the keys found by later scans.
+func (d *Data) SetDetails(details Detail) {
+	d.details.Store(uint32(details))
+	d.cache.clear()
+}
+
+// Details returns the optional metadata that is collected.
+func (d *Data) Details() Detail {
+	return Detail(d.details.Load())
+}
+
+// metadataRequest is the metadata collected for keys, in addition to their type and TTL.
+type metadataRequest struct {
+	memory  bool
+	samples int // the SAMPLES of MEMORY USAGE, or 0 for the server default
+	details Detail
+}
+
+// metadataRequest returns the metadata collected for the keys in the key list.
+func (d *Data) metadataRequest() metadataRequest {
+	return metadataRequest{memory: d.MemoryUsage(), details: d.Details()}
+}
+
+// metadataFlags returns ARGV[1] of metadataScript.
+func (d *Data) metadataFlags() string {
+	return d.metadataRequest().flags()
+}
+
+func (r metadataRequest) flags() string {
+	var flags strings.Builder
+	flags.WriteString("-") // never empty
+	if r.memory {
+		flags.WriteString("m")
+	}
+	details := r.details
+	for _, f := range []struct {
+		detail Detail
+		flag   string
+	}{{DetailLength, "l"}, {DetailEncoding, "e"}, {DetailIdle, "i"}, {DetailFreq, "f"}} {
+		if details&f.detail != 0 {
+			flags.WriteString(f.flag)
+		}
+	}
+	if r.memory && r.samples > 0 {
+		flags.WriteString("s" + strconv.Itoa(r.samples))
+	}
+	return flags.String()
+}
+
+// metadata returns the type, TTL and memory usage of the keys, and any details, skipping keys that no longer exist.
+// The metadata is collected by a script per batch of up to maxScriptKeys keys; if scripting is unavailable (e.g.,
+// disabled by an ACL or a renamed command), the commands are pipelined instead, and the script is not tried again.
+func (d *Data) metadata(ctx context.Context, c redis.UniversalClient, names []string) ([]*Key, error) {
+	return d.requestMetadata(ctx, c, names, d.metadataRequest())
+}
+
+// scanMetadata collects the metadata of scanned keys, as requested by the scan, if it has its own request.
+func (d *Data) scanMetadata(ctx context.Context, c redis.UniversalClient, s *Scan, names []string) ([]*Key, error) {
+	if s.metadata != nil {
+		return d.requestMetadata(ctx, c, names, *s.metadata)
+	}
+	return d.metadata(ctx, c, names)
+}
+
+func (d *Data) requestMetadata(ctx context.Context, c redis.UniversalClient, names []string, req metadataRequest) ([]*Key, error) {
+	keys := make([]*Key, 0, len(names))
+	for len(names) > 0 && !d.noScripts.Load() {
+		batch := names[:min(len(names), maxScriptKeys)]
+		batchKeys, err := d.scriptMetadata(ctx, c, batch, req)
+		if scriptingUnavailable(err) {
+			util.Debug("metadata script: ", err.Error())
+			d.noScripts.Store(true)
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, batchKeys...)
+		names = names[len(batch):]
+	}
+	if len(names) == 0 {
+		return keys, nil
+	}
+	pipelined, err := d.requestPipelinedMetadata(ctx, c, names, req)
+	if err != nil {
+		return nil, err
+	}
+	return append(keys, pipelined...), nil
+}
+
+// scriptMetadata collects the metadata of the keys with a single run of metadataScript.
+func (d *Data) scriptMetadata(ctx context.Context, c redis.UniversalClient, names []string, req metadataRequest) ([]*Key, error) {
+	flags := req.flags()
+	var keys, args []string
+	if len(names) == 1 {
+		keys, args = names, []string{flags}
+	} else {
+		args = append([]string{flags}, names...)
+	}
+	argv := make([]any, len(args))
+	for i, a := range args {
+		argv[i] = a
+	}
+
+	reply, err := d.runMetadataScript(ctx, c, keys, argv...).Slice()
+	if err != nil {
+		return nil, err
+	}
+	return parseMetadata(names, reply, req.details != 0)
+}
+
+// runMetadataScript runs metadataScript with EVALSHA_RO, which a cluster client with replica reads sends to
+// a replica, or with EVALSHA if the server has no read-only variant (before Redis 7) or an ACL denies it.
+func (d *Data) runMetadataScript(ctx context.Context, c redis.UniversalClient, keys []string, args ...any) *redis.Cmd {
+	if !d.noReadOnlyScripts.Load() {
+		cmd := metadataScript.RunRO(ctx, c, keys, args...)
+		if !readOnlyScriptsUnavailable(cmd.Err()) {
+			return cmd
+		}
+		util.Debug("read-only metadata script: ", cmd.Err().Error())
+		d.noReadOnlyScripts.Store(true)
+	}
+	return metadataScript.Run(ctx, c, keys, args...)
+}
+
+// readOnlyScriptsUnavailable returns true if the error means that EVAL_RO and EVALSHA_RO cannot be run,
+// although EVAL may still be.
+func readOnlyScriptsUnavailable(err error) bool {
+	return (redis.HasErrorPrefix(err, "unknown command") || redis.HasErrorPrefix(err, "NOPERM")) &&
+		strings.Contains(strings.ToLower(err.Error()), "_ro")
+}
+
+// scriptingUnavailable returns true if the error means that scripts cannot be run: EVAL is renamed or not allowed
+// by an ACL, or scripting is disabled. Other errors, such as timeouts or LOADING, may not happen again.
+func scriptingUnavailable(err error) bool {
+	for _, prefix := range []string{"NOSCRIPT", "NOPERM", "unknown command"} {
+		if redis.HasErrorPrefix(err, prefix) {
+			return true
+		}
+	}
+	var rerr redis.Error
+	return errors.As(err, &rerr) && strings.Contains(strings.ToLower(rerr.Error()), "scripting is disabled")
+}
+
+// parseMetadata converts the reply of metadataScript into keys, with details if withDetails is true.
+func parseMetadata(names []string, reply []any, withDetails bool) ([]*Key, error) {
+	if len(reply) != len(names) {
+		return nil, fmt.Errorf("metadata script returned %d results for %d keys", len(reply), len(names))
+	}
+
+	keys := make([]*Key, 0, len(names))
+	for i, r := range reply {
+		tuple, ok := r.([]any)
+		if !ok || len(tuple) != 7 {
+			return nil, fmt.Errorf("unexpected metadata for %s: %v", names[i], r)
+		}
+		datatype, _ := tuple[0].(string)
+		pttl, _ := tuple[1].(int64)
+		size, _ := tuple[2].(int64)
+		if datatype == "none" || datatype == "" {
+			continue // deleted or expired since it was scanned
+		}
+		k := newKey(names[i], datatype, ttlFromMillis(pttl), size)
+		if withDetails {
+			length, _ := tuple[3].(int64)
+			encoding, _ := tuple[4].(string)
+			idle, _ := tuple[5].(int64)
+			freq, _ := tuple[6].(int64)
+			k.Details = &KeyDetails{Length: length, Encoding: encoding, Idle: idleFromSeconds(idle), Freq: freq}
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// pipelinedMetadata collects the metadata of the keys with pipelined commands. The length of keys depends on their
+// type, so it is collected by a second pipeline.
+func (d *Data) pipelinedMetadata(ctx context.Context, c redis.UniversalClient, names []string) ([]*Key, error) {
+	return d.requestPipelinedMetadata(ctx, c, names, d.metadataRequest())
+}
+
+func (d *Data) requestPipelinedMetadata(ctx context.Context, c redis.UniversalClient, names []string, req metadataRequest) ([]*Key, error) {
+	type keyCmds struct {
+		ttl      *redis.DurationCmd
+		datatype *redis.StatusCmd
+		size     *redis.IntCmd
+		encoding *redis.StringCmd
+		idle     *redis.DurationCmd
+		freq     *redis.IntCmd
+		length   *redis.IntCmd
+	}
+	memory, details := req.memory, req.details
+	cmds := make([]keyCmds, len(names))
+	// Errors are checked per command: OBJECT IDLETIME and OBJECT FREQ fail depending on the maxmemory policy.
+	_, _ = c.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, name := range names {
+			if details&DetailIdle != 0 {
+				cmds[i].idle = pipe.ObjectIdleTime(ctx, name)
+			}
+			if details&DetailFreq != 0 {
+				cmds[i].freq = pipe.ObjectFreq(ctx, name)
+			}
+			if details&DetailEncoding != 0 {
+				cmds[i].encoding = pipe.ObjectEncoding(ctx, name)
+			}
+			cmds[i].ttl = pipe.TTL(ctx, name)
+			cmds[i].datatype = pipe.Type(ctx, name)
+			if memory && req.samples > 0 {
+				cmds[i].size = pipe.MemoryUsage(ctx, name, req.samples)
+			} else if memory {
+				cmds[i].size = pipe.MemoryUsage(ctx, name)
+			}
+		}
+		return nil
+	})
+	for i := range cmds {
+		// redis.Nil means a key was deleted after it was found; the other keys are still returned.
+		if err := cmds[i].datatype.Err(); err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+	}
+
+	if details&DetailLength != 0 {
+		_, _ = c.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i, name := range names {
+				if cmd, ok := lengthCommands[cmds[i].datatype.Val()]; ok {
+					cmds[i].length = redis.NewIntCmd(ctx, cmd, name)
+					_ = pipe.Process(ctx, cmds[i].length)
+				}
+			}
+			return nil
+		})
+	}
+
+	keys := make([]*Key, 0, len(names))
+	for i, name := range names {
+		if cmds[i].datatype.Val() == "none" {
+			continue // deleted or expired since it was scanned
+		}
+		size := int64(-1)
+		if cmds[i].size != nil {
+			size = cmds[i].size.Val()
+		}
+		k := newKey(name, cmds[i].datatype.Val(), cmds[i].ttl.Val(), size)
+		if details != 0 {
+			k.Details = &KeyDetails{Length: -1, Idle: -1, Freq: -1}
+			if cmd := cmds[i].length; cmd != nil && cmd.Err() == nil {
+				k.Details.Length = cmd.Val()
+			}
+			if cmd := cmds[i].encoding; cmd != nil && cmd.Err() == nil {
+				k.Details.Encoding = cmd.Val()
+			}
+			if cmd := cmds[i].idle; cmd != nil && cmd.Err() == nil {
+				k.Details.Idle = cmd.Val()
+			}
+			if cmd := cmds[i].freq; cmd != nil && cmd.Err() == nil {
+				k.Details.Freq = cmd.Val()
+			}
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// newKey creates a key, ignoring a negative (unknown) size.
+func newKey(name, datatype string, ttl time.Duration, size int64) *Key {
+	k := &Key{Name: name, Datatype: datatype, TTL: ttl}
+	if size >= 0 {
+		k.Size = uint64(size) // #nosec G115 -- size is checked to be >= 0
+	}
+	return k
+}
+
+// ttlFromMillis converts a PTTL reply to the TTL of a key. As with TTL, -1 (no expiry) and -2 (no key) are kept as is.
+func ttlFromMillis(pttl int64) time.Duration {
+	if pttl < 0 {
+		return time.Duration(pttl)
+	}
+	return time.Duration(pttl) * time.Millisecond
+}
+
+// idleFromSeconds converts an OBJECT IDLETIME reply, keeping -1 (unavailable) as is.
+func idleFromSeconds(idle int64) time.Duration {
+	if idle < 0 {
+		return -1
+	}
+	return time.Duration(idle) * time.Second
+}
@@ -0,0 +1,206 @@
+package data //nolint:testpackage // white-box testing of internal package
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetadata(t *testing.T) {
+	t.Parallel()
+
+	reply := []any{
+		[]any{"hash", int64(60000), int64(64), int64(3), "listpack", int64(10), int64(-1)},
+		[]any{"none", int64(-2), int64(-1), int64(-1), "", int64(-1), int64(-1)},
+		[]any{"string", int64(-1), int64(-1), int64(-1), "", int64(-1), int64(-1)},
+	}
+	keys, err := parseMetadata([]string{"a", "b", "c"}, reply, false)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, Key{Name: "a", Datatype: "hash", Size: 64, TTL: time.Minute}, *keys[0])
+	assert.Equal(t, Key{Name: "c", Datatype: "string", Size: 0, TTL: -1}, *keys[1])
+
+	keys, err = parseMetadata([]string{"a", "b", "c"}, reply, true)
+	require.NoError(t, err)
+	assert.Equal(t, &KeyDetails{Length: 3, Encoding: "listpack", Idle: 10 * time.Second, Freq: -1}, keys[0].Details)
+
+	_, err = parseMetadata([]string{"a"}, nil, false)
+	require.Error(t, err)
+	_, err = parseMetadata([]string{"a"}, []any{"hash"}, false)
+	require.Error(t, err)
+	_, err = parseMetadata([]string{"a"}, []any{[]any{"hash", int64(1), int64(1)}}, false)
+	require.Error(t, err)
+}
+
+func TestMetadata(t *testing.T) {
+	c, d := setupTest(t)
+	ctx := t.Context()
+
+	require.NoError(t, c.Set(ctx, "meta:string", "value", time.Hour).Err())
+	require.NoError(t, c.HSet(ctx, "meta:hash", "f", "v").Err())
+
+	names := []string{"meta:string", "meta:gone", "meta:hash"}
+	scripted, err := d.metadata(ctx, d.rc, names)
+	require.NoError(t, err)
+	require.False(t, d.noScripts.Load())
+	require.False(t, d.noReadOnlyScripts.Load(), "Redis 7 runs EVALSHA_RO")
+
+	pipelined, err := d.pipelinedMetadata(ctx, d.rc, names)
+	require.NoError(t, err)
+
+	for _, keys := range [][]*Key{scripted, pipelined} {
+		require.Len(t, keys, 2)
+		assert.Equal(t, "meta:string", keys[0].Name)
+		assert.Equal(t, "string", keys[0].Datatype)
+		assert.InDelta(t, time.Hour.Seconds(), keys[0].TTL.Seconds(), 5)
+		assert.Positive(t, keys[0].Size)
+		assert.Equal(t, "meta:hash", keys[1].Name)
+		assert.Equal(t, time.Duration(-1), keys[1].TTL)
+	}
+
+	// a single key is passed in KEYS
+	keys, err := d.metadata(ctx, d.rc, []string{"meta:hash"})
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "hash", keys[0].Datatype)
+}
+
+func TestMetadataWithoutMemoryUsage(t *testing.T) {
+	c, d := setupTest(t)
+	ctx := t.Context()
+
+	require.NoError(t, c.Set(ctx, "meta:string", "value", 0).Err())
+
+	assert.True(t, d.MemoryUsage())
+	d.SetMemoryUsage(false)
+	assert.False(t, d.MemoryUsage())
+
+	for _, fetch := range []func() ([]*Key, error){
+		func() ([]*Key, error) { return d.metadata(ctx, d.rc, []string{"meta:string"}) },
+		func() ([]*Key, error) { return d.pipelinedMetadata(ctx, d.rc, []string{"meta:string"}) },
+	} {
+		keys, err := fetch()
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		assert.Zero(t, keys[0].Size)
+	}
+}
+
+func TestMetadataScriptingDisabled(t *testing.T) {
+	c, d := setupTest(t)
+	ctx := t.Context()
+
+	require.NoError(t, c.Set(ctx, "meta:string", "value", 0).Err())
+
+	// a user that may not run scripts
+	require.NoError(t, c.Do(ctx, "ACL", "SETUSER", "noscripts", "on", "nopass", "~*", "+@all", "-@scripting").Err())
+	t.Cleanup(func() {
+		_ = c.Do(context.Background(), "ACL", "DELUSER", "noscripts").Err() //nolint:usetesting // t.Context() is canceled before t.Cleanup runs
+	})
+	opts := *c.Options()
+	opts.Username, opts.Password = "noscripts", ""
+	rc := redis.NewClient(&opts)
+	t.Cleanup(func() { _ = rc.Close() })
+
+	keys, err := d.metadata(ctx, rc, []string{"meta:string", "meta:gone"})
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "string", keys[0].Datatype)
+	assert.True(t, d.noScripts.Load(), "the script is not tried again")
+}
+
+func TestMetadataBatches(t *testing.T) {
+	c, d := setupTest(t)
+	ctx := t.Context()
+
+	names := make([]string, 2*maxScriptKeys+1)
+	for i := range names {
+		names[i] = "meta:" + strconv.Itoa(i)
+		require.NoError(t, c.Set(ctx, names[i], "value", 0).Err())
+	}
+	keys, err := d.metadata(ctx, d.rc, names)
+	require.NoError(t, err)
+	require.Len(t, keys, len(names))
+	for i, k := range keys {
+		assert.Equal(t, names[i], k.Name)
+	}
+}
+
+func TestScriptingUnavailable(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, scriptingUnavailable(redisError("NOPERM User noscripts has no permissions to run the 'evalsha' command")))
+	assert.True(t, scriptingUnavailable(redisError("ERR unknown command 'evalsha', with args beginning with: ")))
+	assert.True(t, scriptingUnavailable(redisError("NOSCRIPT No matching script. Please use EVAL.")))
+	assert.True(t, scriptingUnavailable(redisError("ERR Scripting is disabled")))
+	assert.False(t, scriptingUnavailable(redisError("BUSY Redis is busy running a script")))
+	assert.False(t, scriptingUnavailable(redisError("LOADING Redis is loading the dataset in memory")))
+	assert.False(t, scriptingUnavailable(redisError("MOVED 3999 127.0.0.1:6381")))
+	assert.False(t, scriptingUnavailable(context.DeadlineExceeded))
+	assert.False(t, scriptingUnavailable(nil))
+}
+
+func TestReadOnlyScriptsUnavailable(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, readOnlyScriptsUnavailable(redisError("ERR unknown command 'evalsha_ro', with args beginning with: ")))
+	assert.True(t, readOnlyScriptsUnavailable(redisError("NOPERM User reader has no permissions to run the 'evalsha_ro' command")))
+	assert.False(t, readOnlyScriptsUnavailable(redisError("NOPERM User noscripts has no permissions to run the 'evalsha' command")))
+	assert.False(t, readOnlyScriptsUnavailable(redisError("ERR Scripting is disabled")))
+	assert.False(t, readOnlyScriptsUnavailable(context.DeadlineExceeded))
+	assert.False(t, readOnlyScriptsUnavailable(nil))
+}
+
+func TestMetadataFlags(t *testing.T) {
+	t.Parallel()
+
+	d := &Data{}
+	assert.Equal(t, "-m", d.metadataFlags())
+	d.SetMemoryUsage(false)
+	assert.Equal(t, "-", d.metadataFlags())
+	d.SetDetails(DetailLength | DetailFreq)
+	assert.Equal(t, DetailLength|DetailFreq, d.Details())
+	assert.Equal(t, "-lf", d.metadataFlags())
+
+	assert.Equal(t, "-mls10", metadataRequest{memory: true, samples: 10, details: DetailLength}.flags())
+	assert.Equal(t, "-", metadataRequest{samples: 10}.flags(), "samples are only sent with MEMORY USAGE")
+}
+
+func TestMetadataDetails(t *testing.T) {
+	c, d := setupTest(t)
+	ctx := t.Context()
+
+	require.NoError(t, c.Set(ctx, "meta:string", "value", 0).Err())
+	require.NoError(t, c.RPush(ctx, "meta:list", "a", "b", "c").Err())
+	require.NoError(t, c.HSet(ctx, "meta:hash", "f1", "v", "f2", "v").Err())
+	names := []string{"meta:string", "meta:list", "meta:hash"}
+
+	d.SetDetails(DetailLength | DetailEncoding | DetailIdle | DetailFreq)
+	scripted, err := d.metadata(ctx, d.rc, names)
+	require.NoError(t, err)
+	pipelined, err := d.pipelinedMetadata(ctx, d.rc, names)
+	require.NoError(t, err)
+
+	for _, keys := range [][]*Key{scripted, pipelined} {
+		require.Len(t, keys, 3)
+		for _, k := range keys {
+			require.NotNil(t, k.Details, k.Name)
+			assert.NotEmpty(t, k.Details.Encoding, k.Name)
+			assert.GreaterOrEqual(t, k.Details.Idle, time.Duration(0), "the default policy is LRU")
+			assert.Equal(t, int64(-1), k.Details.Freq, "OBJECT FREQ requires an LFU policy")
+		}
+		assert.Equal(t, int64(5), keys[0].Details.Length)
+		assert.Equal(t, int64(3), keys[1].Details.Length)
+		assert.Equal(t, int64(2), keys[2].Details.Length)
+	}
+
+	d.SetDetails(0)
+	keys, err := d.metadata(ctx, d.rc, names)
+	require.NoError(t, err)
+	assert.Nil(t, keys[0].Details)
+}
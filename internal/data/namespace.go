@@ -0,0 +1,68 @@
+package data
+
+import (
+	"slices"
+	"strings"
+)
+
+// Namespace is a node of the namespace tree, which groups keys by the prefixes of their names;
+// e.g., with the delimiter ":", the key "app:user:1" is in the namespace "app:user:", inside "app:".
+type Namespace struct {
+	Name     string       // the last segment of the prefix; e.g., "user"
+	Prefix   string       // the prefix of every key in the namespace, ending with the delimiter; "" for the root
+	Count    int          // the number of keys in the namespace, including nested namespaces
+	Size     uint64       // the memory usage of those keys, where known
+	Partial  int          // the number of those keys without a known memory usage
+	Children []*Namespace // nested namespaces, ordered by name
+	Keys     []*Key       // keys directly in the namespace, ordered by name
+}
+
+// NewNamespaceTree groups keys into namespaces by the delimiter, and returns the root namespace.
+func NewNamespaceTree(keys []*Key, delimiter string) *Namespace {
+	root := &Namespace{}
+	index := map[string]*Namespace{"": root}
+	for _, k := range keys {
+		n := root
+		n.add(k)
+		if delimiter != "" {
+			segments := strings.Split(k.Name, delimiter)
+			prefix := ""
+			for _, segment := range segments[:len(segments)-1] {
+				prefix += segment + delimiter
+				child, ok := index[prefix]
+				if !ok {
+					child = &Namespace{Name: segment, Prefix: prefix}
+					index[prefix] = child
+					n.Children = append(n.Children, child)
+				}
+				n = child
+				n.add(k)
+			}
+		}
+		n.Keys = append(n.Keys, k)
+	}
+	root.sort()
+	return root
+}
+
+func (n *Namespace) add(k *Key) {
+	n.Count++
+	if k.Partial {
+		n.Partial++
+	} else {
+		n.Size += k.Size
+	}
+}
+
+func (n *Namespace) sort() {
+	slices.SortFunc(n.Children, func(a, b *Namespace) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(n.Keys, func(a, b *Key) int { return strings.Compare(a.Name, b.Name) })
+	for _, c := range n.Children {
+		c.sort()
+	}
+}
+
+// Pattern returns the glob pattern matching every key in the namespace.
+func (n *Namespace) Pattern() string {
+	return EscapeGlob(n.Prefix) + "*"
+}
@@ -0,0 +1,61 @@
+package data //nolint:testpackage // white-box testing of internal package
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewNamespaceTree(t *testing.T) {
+	t.Parallel()
+
+	keys := []*Key{
+		{Name: "app:user:2", Size: 100},
+		{Name: "app:user:1", Size: 200},
+		{Name: "app:order:1", Partial: true},
+		{Name: "app:config", Size: 50},
+		{Name: "plain", Size: 10},
+	}
+	root := NewNamespaceTree(keys, ":")
+
+	assert.Equal(t, 5, root.Count)
+	assert.Equal(t, uint64(360), root.Size)
+	assert.Equal(t, 1, root.Partial)
+	require.Len(t, root.Keys, 1)
+	assert.Equal(t, "plain", root.Keys[0].Name)
+
+	require.Len(t, root.Children, 1)
+	app := root.Children[0]
+	assert.Equal(t, "app", app.Name)
+	assert.Equal(t, "app:", app.Prefix)
+	assert.Equal(t, 4, app.Count)
+	assert.Equal(t, uint64(350), app.Size)
+	require.Len(t, app.Keys, 1)
+	assert.Equal(t, "app:config", app.Keys[0].Name)
+
+	require.Len(t, app.Children, 2)
+	assert.Equal(t, "order", app.Children[0].Name)
+	assert.Equal(t, 1, app.Children[0].Partial)
+	user := app.Children[1]
+	assert.Equal(t, "app:user:", user.Prefix)
+	assert.Equal(t, 2, user.Count)
+	assert.Equal(t, uint64(300), user.Size)
+	assert.Equal(t, "app:user:1", user.Keys[0].Name, "keys are ordered by name")
+	assert.Equal(t, `app:user:*`, user.Pattern())
+}
+
+func TestNewNamespaceTreeDelimiter(t *testing.T) {
+	t.Parallel()
+
+	root := NewNamespaceTree([]*Key{{Name: "a/b"}, {Name: "a:b"}, {Name: "[x]/y"}}, "/")
+	require.Len(t, root.Children, 2)
+	assert.Equal(t, "[x]", root.Children[0].Name)
+	assert.Equal(t, `\[x\]/*`, root.Children[0].Pattern())
+	assert.Equal(t, "a", root.Children[1].Name)
+	assert.Equal(t, "a:b", root.Keys[0].Name)
+
+	root = NewNamespaceTree([]*Key{{Name: "a:b"}}, "")
+	assert.Empty(t, root.Children)
+	assert.Len(t, root.Keys, 1)
+}
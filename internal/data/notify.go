@@ -0,0 +1,169 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sethrylan/readis/internal/util"
+)
+
+// keyspaceFlags are the notify-keyspace-events flags needed to watch keys:
+// K enables keyspace (per-key) notifications and A enables all generic and type-specific events.
+const keyspaceFlags = "KA"
+
+// KeyEvent is a keyspace notification for a single key.
+type KeyEvent struct {
+	Key   string
+	Event string // the command or event that changed the key; e.g., set, hset, del, expired
+}
+
+// Removed returns true if the event means the key no longer exists.
+func (e KeyEvent) Removed() bool {
+	switch e.Event {
+	case "del", "expired", "evicted", "rename_from", "move_from":
+		return true
+	default:
+		return false
+	}
+}
+
+// KeyspaceEventsEnabled returns true if the server publishes the keyspace notifications needed by WatchKeyspace.
+// In cluster mode, every master must have them enabled.
+func (d *Data) KeyspaceEventsEnabled(ctx context.Context) (bool, error) {
+	if d.Offline() {
+		return false, ErrOffline
+	}
+	var mu sync.Mutex
+	enabled := true
+	err := d.forEachMaster(ctx, func(ctx context.Context, rc *redis.Client) error {
+		config, err := rc.ConfigGet(ctx, "notify-keyspace-events").Result()
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		enabled = enabled && withKeyspaceFlags(config["notify-keyspace-events"]) == config["notify-keyspace-events"]
+		return nil
+	})
+	return enabled, err
+}
+
+// EnableKeyspaceEvents adds the flags needed by WatchKeyspace to notify-keyspace-events, keeping any existing flags.
+func (d *Data) EnableKeyspaceEvents(ctx context.Context) error {
+	if d.Offline() {
+		return ErrOffline
+	}
+	return d.forEachMaster(ctx, func(ctx context.Context, rc *redis.Client) error {
+		config, err := rc.ConfigGet(ctx, "notify-keyspace-events").Result()
+		if err != nil {
+			return err
+		}
+		flags := withKeyspaceFlags(config["notify-keyspace-events"])
+		util.Debug("notify-keyspace-events: ", rc.Options().Addr, " ", flags)
+		return rc.ConfigSet(ctx, "notify-keyspace-events", flags).Err()
+	})
+}
+
+// withKeyspaceFlags returns flags with any missing keyspaceFlags appended.
+// A is an alias for "g$lshzxetd", so those individual flags are dropped when A is added.
+func withKeyspaceFlags(flags string) string {
+	if !strings.Contains(flags, "A") {
+		flags = strings.Map(func(r rune) rune {
+			if strings.ContainsRune("g$lshzxetd", r) {
+				return -1
+			}
+			return r
+		}, flags)
+	}
+	for _, f := range keyspaceFlags {
+		if !strings.ContainsRune(flags, f) {
+			flags += string(f)
+		}
+	}
+	return flags
+}
+
+// WatchKeyspace subscribes to keyspace notifications for keys matching pattern, and returns events via a channel.
+// The subscriptions are closed, and the channel closed, when ctx is canceled.
+//
+// Notifications are only published by the node that owns the key, so in cluster mode every master is subscribed.
+func (d *Data) WatchKeyspace(ctx context.Context, pattern string) (<-chan *KeyEvent, error) {
+	if d.Offline() {
+		return nil, ErrOffline
+	}
+	prefix := fmt.Sprintf("__keyspace@%d__:", d.db())
+	util.Debug("watch: ", prefix, pattern)
+
+	var mu sync.Mutex
+	var subs []*redis.PubSub
+	err := d.forEachMaster(ctx, func(ctx context.Context, rc *redis.Client) error {
+		ps := rc.PSubscribe(ctx, prefix+pattern)
+		mu.Lock()
+		subs = append(subs, ps)
+		mu.Unlock()
+		// Wait for the subscription confirmation
+		_, err := ps.Receive(ctx)
+		return err
+	})
+	if err != nil {
+		for _, ps := range subs {
+			_ = ps.Close()
+		}
+		return nil, err
+	}
+
+	ch := make(chan *KeyEvent)
+	var wg sync.WaitGroup
+	for _, ps := range subs {
+		wg.Go(func() {
+			defer func() {
+				_ = ps.Close()
+			}()
+			received := ps.Channel()
+			for {
+				select {
+				case msg, ok := <-received:
+					if !ok {
+						return
+					}
+					event := &KeyEvent{
+						Key:   strings.TrimPrefix(msg.Channel, prefix),
+						Event: msg.Payload,
+					}
+					select {
+					case ch <- event:
+					case <-ctx.Done():
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		})
+	}
+	go func() {
+		wg.Wait()
+		close(ch)
+	}()
+
+	return ch, nil
+}
+
+// db returns the selected database number. Cluster mode only supports database 0.
+func (d *Data) db() int {
+	if d.cluster {
+		return 0
+	}
+	return d.rc.Options().DB
+}
+
+// forEachMaster calls fn on each master in cluster mode, or on the single client in standalone mode.
+func (d *Data) forEachMaster(ctx context.Context, fn func(ctx context.Context, rc *redis.Client) error) error {
+	if d.cluster {
+		return d.cc.ForEachMaster(ctx, fn)
+	}
+	return fn(ctx, d.rc)
+}
@@ -0,0 +1,86 @@
+package data //nolint:testpackage // white-box testing of internal package
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithKeyspaceFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "", want: "KA"},
+		{input: "Ex", want: "EKA"},
+		{input: "AK", want: "AK"},
+		{input: "KEA", want: "KEA"},
+		{input: "Kg$", want: "KA"},
+		{input: "Kgm", want: "KmA"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.want, withKeyspaceFlags(test.input))
+		})
+	}
+}
+
+func TestKeyEventRemoved(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, KeyEvent{Key: "k", Event: "del"}.Removed())
+	assert.True(t, KeyEvent{Key: "k", Event: "expired"}.Removed())
+	assert.False(t, KeyEvent{Key: "k", Event: "set"}.Removed())
+	assert.False(t, KeyEvent{Key: "k", Event: "hset"}.Removed())
+}
+
+func TestWatchKeyspace(t *testing.T) {
+	c, d := setupTest(t)
+	ctx := t.Context()
+
+	require.NoError(t, d.EnableKeyspaceEvents(ctx))
+	enabled, err := d.KeyspaceEventsEnabled(ctx)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	ch, err := d.WatchKeyspace(ctx, "live:*")
+	require.NoError(t, err)
+
+	require.NoError(t, c.Set(ctx, "other", "ignored", 0).Err())
+	require.NoError(t, c.Set(ctx, "live:1", "value", 0).Err())
+	require.NoError(t, c.Del(ctx, "live:1").Err())
+
+	for _, want := range []string{"set", "del"} {
+		select {
+		case event := <-ch:
+			assert.Equal(t, "live:1", event.Key)
+			assert.Equal(t, want, event.Event)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s event", want)
+		}
+	}
+}
+
+func TestKeyInfo(t *testing.T) {
+	c, d := setupTest(t)
+	ctx := t.Context()
+
+	require.NoError(t, c.HSet(ctx, "info-key", "field", "value").Err())
+
+	k, err := d.KeyInfo(ctx, "info-key")
+	require.NoError(t, err)
+	require.NotNil(t, k)
+	assert.Equal(t, "hash", k.Datatype)
+	assert.Equal(t, time.Duration(-1), k.TTL)
+	assert.Positive(t, k.Size)
+
+	k, err = d.KeyInfo(ctx, "missing-key")
+	require.NoError(t, err)
+	assert.Nil(t, k)
+}
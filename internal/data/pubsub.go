@@ -0,0 +1,235 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sethrylan/readis/internal/util"
+)
+
+// SubscribeMode selects the pub/sub command used to subscribe.
+type SubscribeMode int
+
+const (
+	// SubscribeChannels subscribes to exact channel names with SUBSCRIBE.
+	SubscribeChannels SubscribeMode = iota
+	// SubscribePatterns subscribes to glob patterns with PSUBSCRIBE.
+	SubscribePatterns
+	// SubscribeShards subscribes to sharded channels with SSUBSCRIBE.
+	SubscribeShards
+)
+
+func (m SubscribeMode) String() string {
+	switch m {
+	case SubscribePatterns:
+		return "psubscribe"
+	case SubscribeShards:
+		return "ssubscribe"
+	default:
+		return "subscribe"
+	}
+}
+
+// Channel is an active pub/sub channel and its number of subscribers.
+type Channel struct {
+	Name        string
+	Subscribers int64
+	Sharded     bool // true for channels reported by PUBSUB SHARDCHANNELS
+}
+
+// Message is a pub/sub message received from a subscription.
+type Message struct {
+	Channel  string
+	Pattern  string // the matching pattern, for messages received with PSUBSCRIBE
+	Payload  string
+	Received time.Time
+}
+
+// Channels returns the active channels matching pattern, with subscriber counts, sorted by name.
+//
+// In cluster mode, regular channels are only known to the node holding the subscriber connection,
+// so every node is asked and the results are merged. Sharded channels are included from each master.
+func (d *Data) Channels(ctx context.Context, pattern string) ([]Channel, error) {
+	if d.Offline() {
+		return nil, ErrOffline
+	}
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	if !d.cluster {
+		return channelsForNode(ctx, d.rc, pattern, false)
+	}
+
+	type channelKey struct {
+		name    string
+		sharded bool
+	}
+	var mu sync.Mutex
+	merged := make(map[channelKey]*Channel)
+	merge := func(channels []Channel) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, c := range channels {
+			k := channelKey{name: c.Name, sharded: c.Sharded}
+			if existing, ok := merged[k]; ok {
+				existing.Subscribers += c.Subscribers
+				continue
+			}
+			merged[k] = &c
+		}
+	}
+
+	err := d.cc.ForEachShard(ctx, func(ctx context.Context, rc *redis.Client) error {
+		channels, err := channelsForNode(ctx, rc, pattern, false)
+		if err != nil {
+			return err
+		}
+		merge(channels)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.cc.ForEachMaster(ctx, func(ctx context.Context, rc *redis.Client) error {
+		channels, err := channelsForNode(ctx, rc, pattern, true)
+		if err != nil {
+			return err
+		}
+		merge(channels)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Channel, 0, len(merged))
+	for _, c := range merged {
+		result = append(result, *c)
+	}
+	sortChannels(result)
+	return result, nil
+}
+
+// channelsForNode lists channels on a single node, using PUBSUB SHARDCHANNELS when sharded is true.
+func channelsForNode(ctx context.Context, rc *redis.Client, pattern string, sharded bool) ([]Channel, error) {
+	var names []string
+	var err error
+	if sharded {
+		names, err = rc.PubSubShardChannels(ctx, pattern).Result()
+	} else {
+		names, err = rc.PubSubChannels(ctx, pattern).Result()
+	}
+	if err != nil || len(names) == 0 {
+		return nil, err
+	}
+
+	var counts map[string]int64
+	if sharded {
+		counts, err = rc.PubSubShardNumSub(ctx, names...).Result()
+	} else {
+		counts, err = rc.PubSubNumSub(ctx, names...).Result()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	channels := make([]Channel, 0, len(names))
+	for _, name := range names {
+		channels = append(channels, Channel{Name: name, Subscribers: counts[name], Sharded: sharded})
+	}
+	sortChannels(channels)
+	return channels, nil
+}
+
+func sortChannels(channels []Channel) {
+	sort.Slice(channels, func(i, j int) bool {
+		if channels[i].Name == channels[j].Name {
+			return !channels[i].Sharded
+		}
+		return channels[i].Name < channels[j].Name
+	})
+}
+
+// Publish posts a message to a channel and returns the number of clients that received it.
+// If sharded is true, SPUBLISH is used so that the message is routed to the shard owning the channel.
+func (d *Data) Publish(ctx context.Context, channel, message string, sharded bool) (int64, error) {
+	if d.Offline() {
+		return 0, ErrOffline
+	}
+	if sharded {
+		return d.client().SPublish(ctx, channel, message).Result()
+	}
+	return d.client().Publish(ctx, channel, message).Result()
+}
+
+// SubscribeAsync subscribes to the given channels or patterns and returns received messages via a channel.
+// The subscription is closed, and the channel closed, when ctx is canceled.
+//
+// In cluster mode, sharded subscriptions are routed by the slot of the first channel, so all channels
+// in a single SubscribeShards call must hash to the same slot.
+func (d *Data) SubscribeAsync(ctx context.Context, mode SubscribeMode, channels ...string) (<-chan *Message, error) {
+	if d.Offline() {
+		return nil, ErrOffline
+	}
+	if len(channels) == 0 {
+		return nil, errors.New("no channels given")
+	}
+	util.Debug(mode.String(), ": ", strings.Join(channels, " "))
+
+	var ps *redis.PubSub
+	switch mode {
+	case SubscribePatterns:
+		ps = d.client().PSubscribe(ctx, channels...)
+	case SubscribeShards:
+		ps = d.client().SSubscribe(ctx, channels...)
+	default:
+		ps = d.client().Subscribe(ctx, channels...)
+	}
+
+	// Wait for the subscription confirmation, so that errors are returned to the caller
+	// rather than silently retried in the background.
+	if _, err := ps.Receive(ctx); err != nil {
+		_ = ps.Close()
+		return nil, err
+	}
+
+	ch := make(chan *Message)
+	go func() {
+		defer func() {
+			_ = ps.Close()
+			close(ch)
+		}()
+
+		received := ps.Channel()
+		for {
+			select {
+			case msg, ok := <-received:
+				if !ok {
+					return
+				}
+				m := &Message{
+					Channel:  msg.Channel,
+					Pattern:  msg.Pattern,
+					Payload:  msg.Payload,
+					Received: time.Now(),
+				}
+				select {
+				case ch <- m:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
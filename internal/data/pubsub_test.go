@@ -0,0 +1,96 @@
+package data //nolint:testpackage // white-box testing of internal package
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribeAsync(t *testing.T) {
+	_, d := setupTest(t)
+	ctx := t.Context()
+
+	t.Run("subscribe", func(t *testing.T) {
+		ch, err := d.SubscribeAsync(ctx, SubscribeChannels, "events")
+		require.NoError(t, err)
+
+		n, err := d.Publish(ctx, "events", "hello", false)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		select {
+		case msg := <-ch:
+			assert.Equal(t, "events", msg.Channel)
+			assert.Equal(t, "hello", msg.Payload)
+			assert.Empty(t, msg.Pattern)
+			assert.False(t, msg.Received.IsZero())
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for message")
+		}
+	})
+
+	t.Run("psubscribe", func(t *testing.T) {
+		ch, err := d.SubscribeAsync(ctx, SubscribePatterns, "orders:*")
+		require.NoError(t, err)
+
+		_, err = d.Publish(ctx, "orders:1", "created", false)
+		require.NoError(t, err)
+
+		select {
+		case msg := <-ch:
+			assert.Equal(t, "orders:1", msg.Channel)
+			assert.Equal(t, "orders:*", msg.Pattern)
+			assert.Equal(t, "created", msg.Payload)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for message")
+		}
+	})
+
+	t.Run("no channels", func(t *testing.T) {
+		_, err := d.SubscribeAsync(ctx, SubscribeChannels)
+		assert.Error(t, err)
+	})
+}
+
+func TestSubscribeAsyncCancel(t *testing.T) {
+	_, d := setupTest(t)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	ch, err := d.SubscribeAsync(ctx, SubscribeChannels, "cancelled")
+	require.NoError(t, err)
+
+	cancel()
+	select {
+	case _, ok := <-ch:
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel was not closed after cancel")
+	}
+}
+
+func TestChannels(t *testing.T) {
+	_, d := setupTest(t)
+	ctx := t.Context()
+
+	_, err := d.SubscribeAsync(ctx, SubscribeChannels, "beta", "alpha")
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		channels, err := d.Channels(ctx, "*")
+		return err == nil && len(channels) == 2
+	}, 5*time.Second, 50*time.Millisecond)
+
+	channels, err := d.Channels(ctx, "*")
+	require.NoError(t, err)
+	assert.Equal(t, []Channel{
+		{Name: "alpha", Subscribers: 1},
+		{Name: "beta", Subscribers: 1},
+	}, channels)
+
+	channels, err = d.Channels(ctx, "al*")
+	require.NoError(t, err)
+	assert.Len(t, channels, 1)
+}
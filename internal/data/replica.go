@@ -0,0 +1,192 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sethrylan/readis/internal/util"
+)
+
+// Option configures Data.
+type Option func(*Data)
+
+// WithReplicaReads sends reads (SCAN, TYPE, TTL, MEMORY USAGE and key values) to replicas, while writes still go
+// to the primary. In cluster mode, keyed reads are routed to the lowest latency node of each shard, and scans
+// prefer an online replica of each shard. In standalone mode, reads go to the replica at addr (host:port), or,
+// if addr is "", to a replica discovered from INFO replication on the primary.
+func WithReplicaReads(addr string) Option {
+	return func(d *Data) {
+		d.replicaReads = true
+		d.replicaAddr = addr
+	}
+}
+
+// replicaState is the standalone replica used for reads.
+type replicaState struct {
+	mu         sync.Mutex
+	replica    *redis.Client // nil until a replica is connected
+	discovered bool          // true once discovery has been attempted
+}
+
+// ReplicaReads returns true if reads are sent to replicas.
+func (d *Data) ReplicaReads() bool {
+	return d.replicaReads
+}
+
+// ReadNode describes the node serving reads.
+func (d *Data) ReadNode() string {
+	switch {
+	case d.src != nil:
+		return d.src.name()
+	case d.cluster && d.replicaReads:
+		return "replicas"
+	case d.cluster:
+		return "masters"
+	}
+	d.replicaState.mu.Lock()
+	defer d.replicaState.mu.Unlock()
+	if d.replicaState.replica != nil {
+		return d.replicaState.replica.Options().Addr
+	}
+	return d.rc.Options().Addr
+}
+
+// reader returns the client for reads.
+func (d *Data) reader(ctx context.Context) redis.UniversalClient {
+	if d.cluster {
+		return d.cc
+	}
+	return d.standaloneReader(ctx)
+}
+
+// standaloneReader returns the standalone client for reads. With replica reads, the replica is discovered on
+// the first call; if no replica is found, reads go to the primary.
+func (d *Data) standaloneReader(ctx context.Context) *redis.Client {
+	if !d.replicaReads {
+		return d.rc
+	}
+
+	r := &d.replicaState
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.replica == nil && !r.discovered {
+		addr := d.replicaAddr
+		if addr == "" {
+			addr = discoverReplica(ctx, d.rc)
+		}
+		if addr != "" {
+			opts := *d.rc.Options()
+			opts.Addr = addr
+			r.replica = redis.NewClient(&opts)
+		}
+		r.discovered = ctx.Err() == nil
+	}
+	if r.replica != nil {
+		return r.replica
+	}
+	return d.rc
+}
+
+// discoverReplica returns the address of an online replica of the primary, or "" if there is none.
+func discoverReplica(ctx context.Context, rc *redis.Client) string {
+	info, err := rc.Info(ctx, "replication").Result()
+	if err != nil {
+		util.Debug("replica discovery: ", err.Error())
+		return ""
+	}
+	replicas := parseReplicas(info)
+	if len(replicas) == 0 {
+		util.Debug("replica discovery: no online replicas")
+		return ""
+	}
+	return replicas[0]
+}
+
+// parseReplicas returns the addresses of the online replicas listed in INFO replication; e.g.,
+// "slave0:ip=10.0.0.2,port=6379,state=online,offset=1,lag=0".
+func parseReplicas(info string) []string {
+	var replicas []string
+	for line := range strings.Lines(info) {
+		name, value, found := strings.Cut(strings.TrimSpace(line), ":")
+		if !found || !strings.HasPrefix(name, "slave") {
+			continue
+		}
+		fields := make(map[string]string)
+		for field := range strings.SplitSeq(value, ",") {
+			k, v, _ := strings.Cut(field, "=")
+			fields[k] = v
+		}
+		if fields["ip"] == "" || fields["port"] == "" || fields["state"] != "online" {
+			continue
+		}
+		replicas = append(replicas, net.JoinHostPort(fields["ip"], fields["port"]))
+	}
+	return replicas
+}
+
+// forEachScanNode calls fn concurrently for one node of each shard: the master or, with replica reads,
+// an online replica if the shard has one.
+func (d *Data) forEachScanNode(ctx context.Context, fn func(ctx context.Context, rc *redis.Client) error) error {
+	if !d.replicaReads {
+		return d.cc.ForEachMaster(ctx, fn)
+	}
+
+	shards, err := d.shards(ctx)
+	if err != nil {
+		return err
+	}
+	clients, err := d.clients(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(shards))
+	for _, shard := range shards {
+		rc, err := scanClient(clients, shard)
+		if err != nil {
+			return err
+		}
+		wg.Go(func() {
+			if err := fn(ctx, rc); err != nil {
+				errCh <- err
+			}
+		})
+	}
+	wg.Wait()
+	close(errCh)
+	return <-errCh
+}
+
+// scanClient returns the connection to the node that reads for the shard, or to its master if there is no
+// connection by that address; e.g., when CLUSTER SHARDS reports a hostname for a node known by its IP.
+func scanClient(clients map[string]*redis.Client, shard Shard) (*redis.Client, error) {
+	if rc, ok := clients[readAddr(shard)]; ok {
+		return rc, nil
+	}
+	if m := shard.Master(); m != nil {
+		if rc, ok := clients[m.Addr]; ok {
+			return rc, nil
+		}
+		return nil, fmt.Errorf("no connection to %s or its replicas", m.Addr)
+	}
+	return nil, errors.New("no connection to a shard without a master")
+}
+
+// readAddr returns the address of the first online replica of the shard, or of the master if there is none.
+func readAddr(shard Shard) string {
+	for _, n := range shard.Nodes {
+		if n.Role != "master" && n.Health == "online" {
+			return n.Addr
+		}
+	}
+	if m := shard.Master(); m != nil {
+		return m.Addr
+	}
+	return ""
+}
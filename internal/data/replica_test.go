@@ -0,0 +1,88 @@
+package data //nolint:testpackage // white-box testing of internal package
+
+import (
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReplicas(t *testing.T) {
+	t.Parallel()
+
+	info := "# Replication\r\n" +
+		"role:master\r\n" +
+		"connected_slaves:3\r\n" +
+		"slave0:ip=10.0.0.2,port=6379,state=online,offset=1,lag=0\r\n" +
+		"slave1:ip=10.0.0.3,port=6380,state=wait_bgsave,offset=0,lag=0\r\n" +
+		"slave2:ip=::1,port=6381,state=online,offset=1,lag=1\r\n" +
+		"master_repl_offset:1\r\n"
+	assert.Equal(t, []string{"10.0.0.2:6379", "[::1]:6381"}, parseReplicas(info))
+
+	replicaInfo := "# Replication\r\nrole:slave\r\nmaster_host:10.0.0.1\r\nslave_read_only:1\r\nslave_priority:100\r\n"
+	assert.Empty(t, parseReplicas(replicaInfo))
+}
+
+func TestReadAddr(t *testing.T) {
+	t.Parallel()
+
+	shards := parseClusterNodes(clusterNodes)
+	assert.Equal(t, "127.0.0.1:30002", readAddr(shards[0]), "failed replicas are skipped")
+	assert.Equal(t, "127.0.0.1:30006", readAddr(shards[1]))
+	assert.Equal(t, "127.0.0.1:30004", readAddr(shards[2]))
+	assert.Empty(t, readAddr(Shard{}))
+}
+
+func TestScanClient(t *testing.T) {
+	t.Parallel()
+
+	shard := parseClusterNodes(clusterNodes)[1]
+	master := redis.NewClient(&redis.Options{Addr: shard.Master().Addr})
+	replica := redis.NewClient(&redis.Options{Addr: readAddr(shard)})
+	t.Cleanup(func() {
+		_ = master.Close()
+		_ = replica.Close()
+	})
+
+	rc, err := scanClient(map[string]*redis.Client{master.Options().Addr: master, replica.Options().Addr: replica}, shard)
+	require.NoError(t, err)
+	assert.Same(t, replica, rc)
+
+	rc, err = scanClient(map[string]*redis.Client{master.Options().Addr: master}, shard)
+	require.NoError(t, err)
+	assert.Same(t, master, rc, "falls back to the master when the replica is known by another address")
+
+	_, err = scanClient(map[string]*redis.Client{}, shard)
+	require.Error(t, err)
+	_, err = scanClient(map[string]*redis.Client{}, Shard{})
+	require.Error(t, err)
+}
+
+func TestReplicaReads(t *testing.T) {
+	c, _ := setupTest(t)
+	ctx := t.Context()
+
+	require.NoError(t, c.Set(ctx, "replica:key", "v", 0).Err())
+
+	// the test server has no replicas, so reads fall back to the primary
+	d, err := NewData(testConnStr, false, WithReplicaReads(""))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = d.Close() })
+
+	assert.True(t, d.ReplicaReads())
+	assert.Equal(t, int64(1), d.TotalKeys(ctx))
+	assert.Equal(t, c.Options().Addr, d.ReadNode())
+
+	// an explicit replica is used without discovery
+	opts, err := redis.ParseURL(testConnStr)
+	require.NoError(t, err)
+	explicit, err := NewData(testConnStr, false, WithReplicaReads(opts.Addr))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = explicit.Close() })
+
+	k, err := explicit.KeyInfo(ctx, "replica:key")
+	require.NoError(t, err)
+	assert.Equal(t, "string", k.Datatype)
+	assert.Same(t, explicit.replicaState.replica, explicit.reader(ctx))
+}
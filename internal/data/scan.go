@@ -2,9 +2,13 @@ package data
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"sort"
 	"strconv"
-	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/sethrylan/readis/internal/util"
@@ -15,17 +19,157 @@ type Scan struct {
 	pageSize int
 	pattern  string
 	scanning atomic.Bool
-	iters    map[string]*redis.ScanIterator
+
+	mu    sync.Mutex           // guards iters and the throttle state, which are shared by the per-shard scans
+	iters map[string]*nodeScan // scan state by node address
+
+	throttle  Throttle
+	waiting   atomic.Int32         // number of nodes waiting because of the throttle
+	nextRead  time.Time            // when the next batch may be read under the keys per second limit
+	lastBatch map[string]time.Time // when each node's last batch was read, for the pause between batches
+	reads     []batchRead          // batches read within the rate window
+	firstRead time.Time            // when the first batch in the rate window was read
+
+	exact      bool             // if true, the pattern is a key name rather than a glob
+	keyType    string           // if set, only keys of this type are found
+	lazy       bool             // if true, scanned keys are sent without metadata
+	metadata   *metadataRequest // if set, the metadata collected for scanned keys, instead of the key list's
+	node       string           // if set, only this cluster node is scanned
+	slots      []int64          // if set, keys are listed from these hash slots instead of scanned
+	slotIdx    int              // index of the next slot to list
+	slotOffset int              // number of keys already listed from the slot at slotIdx
+}
+
+// NodeProgress is the progress of a scan on a single node.
+type NodeProgress struct {
+	Addr      string
+	Found     int  // number of keys found on the node, across all pages
+	Scanning  bool // true while a page is being scanned on the node
+	Exhausted bool // true once the node's SCAN cursor has returned to 0 and every key has been read
+}
+
+// nodeScan is the SCAN cursor and progress for a single node.
+type nodeScan struct {
+	scanCursor uint64   // the cursor of the next SCAN
+	done       bool     // true once the SCAN cursor has returned to 0
+	pending    []string // keys returned by the last SCAN beyond the page size, sent first with the next page
+	typed      bool     // true if the server filters keys by type
+	cursor     int      // the position of the next key, when keys are read from a source rather than scanned
+	NodeProgress
+}
+
+// ScanOption configures a Scan.
+type ScanOption func(*Scan)
+
+// WithExact treats the pattern as an exact key name, so that keys containing glob metacharacters
+// can be looked up directly.
+func WithExact() ScanOption {
+	return func(s *Scan) {
+		s.exact = true
+	}
+}
+
+// KeyTypes are the core data types that a scan can be filtered by.
+var KeyTypes = []string{"string", "list", "set", "zset", "hash", "stream"}
+
+// WithType only finds keys of the given type; e.g., hash or stream. The TYPE option of SCAN is used when
+// the server supports it (Redis 6+), and keys are always filtered by type on the client as well.
+func WithType(keyType string) ScanOption {
+	return func(s *Scan) {
+		s.keyType = keyType
+	}
+}
+
+// WithLazyMetadata sends scanned keys with only their names, and their type if the server filtered by type,
+// so that metadata can be fetched later for the keys being shown; see [Data.KeysInfo]. Keys that are looked up
+// by name or listed from hash slots still have their metadata.
+func WithLazyMetadata() ScanOption {
+	return func(s *Scan) {
+		s.lazy = true
+	}
+}
+
+// WithMetadata collects the memory usage and the details of scanned keys, whatever is collected for the key list.
+// samples sets the SAMPLES of MEMORY USAGE, or 0 for the server default.
+func WithMetadata(samples int, details Detail) ScanOption {
+	return func(s *Scan) {
+		s.metadata = &metadataRequest{memory: true, samples: samples, details: details}
+	}
+}
+
+// WithNode restricts a cluster scan to the node with the given address (host:port).
+func WithNode(addr string) ScanOption {
+	return func(s *Scan) {
+		s.node = addr
+	}
+}
+
+// WithSlots lists the keys in the given hash slots with CLUSTER GETKEYSINSLOT, instead of scanning every master.
+// Keys are filtered by the scan pattern on the client.
+func WithSlots(ranges ...SlotRange) ScanOption {
+	return func(s *Scan) {
+		for _, r := range ranges {
+			for slot := r.Start; slot <= r.End; slot++ {
+				s.slots = append(s.slots, slot)
+			}
+		}
+	}
 }
 
 // NewScan creates a new Scan instance with the given pattern and page size.
-func NewScan(pattern string, pageSize int) *Scan {
+func NewScan(pattern string, pageSize int, opts ...ScanOption) *Scan {
 	util.Debug("new scan: ", pattern, strconv.Itoa(pageSize))
-	return &Scan{
+	s := &Scan{
 		pageSize: pageSize,
 		pattern:  pattern,
-		iters:    make(map[string]*redis.ScanIterator),
+		iters:    make(map[string]*nodeScan),
+
+		lastBatch: make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
+}
+
+// Scope describes the part of the keyspace being scanned, or returns "" if every master is scanned.
+func (s *Scan) Scope() string {
+	switch {
+	case len(s.slots) == 1:
+		return fmt.Sprintf("slot %d", s.slots[0])
+	case len(s.slots) > 1:
+		return fmt.Sprintf("%d slots", len(s.slots))
+	case s.node != "":
+		return "node " + s.node
+	default:
+		return ""
+	}
+}
+
+// Pattern returns the glob pattern matching the scanned keys. For exact scans, the key name is escaped.
+func (s *Scan) Pattern() string {
+	if s.exact {
+		return EscapeGlob(s.pattern)
+	}
+	return s.pattern
+}
+
+// Matches returns true if the key name matches the pattern of the scan.
+func (s *Scan) Matches(name string) bool {
+	return matchGlob(s.Pattern(), name)
+}
+
+// isGlob returns true if keys are found by scanning, rather than by looking up a single key.
+func (s *Scan) isGlob() bool {
+	return !s.exact && IsGlob(s.pattern)
+}
+
+// keyName returns the key name for exact lookups.
+func (s *Scan) keyName() string {
+	if s.exact {
+		return s.pattern
+	}
+	return unescapeGlob(s.pattern)
 }
 
 // Scanning returns true if a scan is currently in progress.
@@ -35,26 +179,277 @@ func (s *Scan) Scanning() bool {
 
 // HasMore returns true if there may be more keys to scan.
 func (s *Scan) HasMore() bool {
-	return strings.Contains(s.pattern, "*")
+	if len(s.slots) > 0 {
+		return s.slotIdx < len(s.slots)
+	}
+	if s.node == "" && !s.isGlob() {
+		return false // exact key lookups are complete after the first call
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.iters) == 0 {
+		return true // not started
+	}
+	for _, n := range s.iters {
+		if !n.Exhausted {
+			return true
+		}
+	}
+	return false
 }
 
-// PipelinedCmds executes pipelined commands to fetch key metadata.
-func (s *Scan) PipelinedCmds(ctx context.Context, rc *redis.Client) ([]redis.Cmder, error) {
-	var numFound int
+// Exhausted returns true if every matching key has been found.
+func (s *Scan) Exhausted() bool {
+	return !s.HasMore()
+}
+
+// Progress returns the progress of the scan on each node, ordered by address.
+func (s *Scan) Progress() []NodeProgress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	progress := make([]NodeProgress, 0, len(s.iters))
+	for _, n := range s.iters {
+		progress = append(progress, n.NodeProgress)
+	}
+	sort.Slice(progress, func(i, j int) bool {
+		return progress[i].Addr < progress[j].Addr
+	})
+	return progress
+}
+
+// nodeScan returns the scan state for a node, creating it on the first call.
+func (s *Scan) nodeScan(addr string) *nodeScan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.iters[addr] == nil {
+		util.Debug("new node scan: ", addr)
+		s.iters[addr] = &nodeScan{typed: s.keyType != "", NodeProgress: NodeProgress{Addr: addr}}
+	}
+	return s.iters[addr]
+}
 
-	if s.iters[rc.Options().Addr] == nil {
-		util.Debug("new iterator: ", rc.Options().Addr)
-		s.iters[rc.Options().Addr] = rc.Scan(ctx, 0, s.pattern, int64(s.pageSize)).Iterator()
+// sourceScan returns the scan state for a source of keys other than a Redis server, such as an RDB file.
+func (s *Scan) sourceScan(name string) *nodeScan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.iters[name] == nil {
+		s.iters[name] = &nodeScan{NodeProgress: NodeProgress{Addr: name}}
 	}
-	iter := s.iters[rc.Options().Addr]
+	return s.iters[name]
+}
 
-	return rc.Pipelined(ctx, func(pipe redis.Pipeliner) error {
-		for iter.Next(ctx) && numFound < s.pageSize {
-			numFound++
-			pipe.TTL(ctx, iter.Val())
-			pipe.Type(ctx, iter.Val())
-			pipe.MemoryUsage(ctx, iter.Val())
+// scan runs one SCAN on a node from its cursor, with the TYPE option if a type is set. Servers older than Redis 6
+// reject TYPE with a syntax error, in which case the node falls back to a plain SCAN and keys are only filtered
+// on the client.
+func (s *Scan) scan(ctx context.Context, rc *redis.Client, n *nodeScan) ([]string, uint64, error) {
+	count := int64(s.pageSize)
+	if s.isTyped(n) {
+		keys, cursor, err := rc.ScanType(ctx, n.scanCursor, s.Pattern(), count, s.keyType).Result()
+		if !isSyntaxError(err) {
+			return keys, cursor, err
 		}
-		return iter.Err()
-	})
+		util.Debug("scan type unsupported: ", n.Addr, " ", err.Error())
+		s.mu.Lock()
+		n.typed = false
+		s.mu.Unlock()
+	}
+	return rc.Scan(ctx, n.scanCursor, s.Pattern(), count).Result()
+}
+
+func (s *Scan) isTyped(n *nodeScan) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return n.typed
+}
+
+// isSyntaxError returns true if the server rejected the arguments of a command, as servers reject options
+// added in later versions.
+func isSyntaxError(err error) bool {
+	return redis.HasErrorPrefix(err, "syntax error")
+}
+
+// sendsPartialKeys returns true if the keys scanned on a node are sent without metadata. With a type filter,
+// metadata is still needed to filter on the client unless the server filters by type.
+func (s *Scan) sendsPartialKeys(addr string) bool {
+	if !s.lazy {
+		return false
+	}
+	if s.keyType == "" {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, ok := s.iters[addr]
+	return ok && n.typed
+}
+
+// matchesType returns true if the key should be found by the scan.
+func (s *Scan) matchesType(k *Key) bool {
+	return s.keyType == "" || k.Datatype == s.keyType || k.Datatype == "error"
+}
+
+// InScan returns true if the key belongs to the scan: its name matches the pattern, its type matches the type
+// filter and, for scans of a cluster node or of hash slots, it is stored in that part of the keyspace.
+func (d *Data) InScan(ctx context.Context, s *Scan, k *Key) (bool, error) {
+	if !s.Matches(k.Name) || !s.matchesType(k) {
+		return false, nil
+	}
+	if s.node == "" && len(s.slots) == 0 {
+		return true, nil
+	}
+	slot, err := d.KeySlot(ctx, k.Name)
+	if err != nil {
+		return false, err
+	}
+	if len(s.slots) > 0 {
+		return slices.Contains(s.slots, slot), nil
+	}
+	shards, err := d.shards(ctx)
+	if err != nil {
+		return false, err
+	}
+	shard := ShardForSlot(shards, slot)
+	return shard != nil && slices.ContainsFunc(shard.Nodes, func(n Node) bool { return n.Addr == s.node }), nil
+}
+
+// updateProgress records that a node started or finished scanning a page.
+func (s *Scan) updateProgress(n *nodeScan, found int, scanning, exhausted bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n.Found += found
+	n.Scanning = scanning
+	n.Exhausted = exhausted
+}
+
+// NextKeys returns the names of the next batch of keys scanned on a node.
+// It is safe to call concurrently for different nodes, and returns no keys once the node is exhausted.
+//
+// A sparse pattern can take many SCAN calls to fill a page, so the throttle is applied before each SCAN, and
+// the keys per second limit and the rate count the keys the server examines (the COUNT of each SCAN) rather
+// than the keys that match.
+func (s *Scan) NextKeys(ctx context.Context, rc *redis.Client) ([]string, error) {
+	var names []string
+	exhausted := false
+
+	n := s.nodeScan(rc.Options().Addr)
+	if s.exhausted(n) {
+		return nil, nil
+	}
+	s.updateProgress(n, 0, true, false)
+	defer func() {
+		s.updateProgress(n, len(names), false, exhausted)
+	}()
+
+	// Keys beyond the page size are kept for the next page, so that no key is skipped between pages
+	for {
+		take := min(len(n.pending), s.pageSize-len(names))
+		names = append(names, n.pending[:take]...)
+		n.pending = n.pending[take:]
+		if len(names) >= s.pageSize || n.done {
+			break
+		}
+
+		if err := s.beforeBatch(ctx, rc); err != nil {
+			return names, err
+		}
+		if err := s.waitForKeys(ctx, s.pageSize); err != nil {
+			return names, err
+		}
+		keys, cursor, err := s.scan(ctx, rc, n)
+		s.afterBatch(n.Addr, s.pageSize)
+		if err != nil {
+			return names, err
+		}
+		n.pending = append(n.pending, keys...)
+		n.scanCursor, n.done = cursor, cursor == 0
+	}
+	exhausted = n.done && len(n.pending) == 0
+	return names, nil
+}
+
+func (s *Scan) exhausted(n *nodeScan) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return n.Exhausted
+}
+
+// slotKeys lists keys in the scan's hash slots with CLUSTER GETKEYSINSLOT, continuing from where the last call
+// stopped, and collects key metadata from the master that owns each slot.
+//
+// CLUSTER GETKEYSINSLOT has no cursor, so paging re-reads the keys already listed from a slot and skips them.
+func (d *Data) slotKeys(ctx context.Context, s *Scan) ([]*Key, error) {
+	if !d.cluster {
+		return nil, ErrNotCluster
+	}
+
+	shards, err := d.shards(ctx)
+	if err != nil {
+		return nil, err
+	}
+	masters, err := d.clients(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	pattern := s.Pattern()
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	var found []*Key
+	var numFound int
+	for s.slotIdx < len(s.slots) && numFound < s.pageSize {
+		slot := s.slots[s.slotIdx]
+		shard := ShardForSlot(shards, slot)
+		if shard == nil || shard.Master() == nil {
+			s.slotIdx, s.slotOffset = s.slotIdx+1, 0
+			continue
+		}
+		rc, ok := masters[shard.Master().Addr]
+		if !ok {
+			return found, fmt.Errorf("no connection to %s for slot %d", shard.Master().Addr, slot)
+		}
+		if err := s.beforeBatch(ctx, rc); err != nil {
+			return found, err
+		}
+
+		count := s.slotOffset + s.pageSize - numFound
+		if err := s.waitForKeys(ctx, count); err != nil {
+			return found, err
+		}
+		keys, err := rc.ClusterGetKeysInSlot(ctx, int(slot), count).Result()
+		s.afterBatch(rc.Options().Addr, len(keys))
+		if err != nil {
+			return found, err
+		}
+		listed := keys[min(s.slotOffset, len(keys)):]
+		if len(keys) < count {
+			s.slotIdx, s.slotOffset = s.slotIdx+1, 0 // the slot is exhausted
+		} else {
+			s.slotOffset += len(listed)
+		}
+
+		var matched []string
+		for _, k := range listed {
+			if matchGlob(pattern, k) {
+				matched = append(matched, k)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		numFound += len(matched)
+
+		slotKeys, err := d.scanMetadata(ctx, rc, s, matched)
+		found = append(found, slotKeys...)
+		if err != nil {
+			return found, err
+		}
+	}
+	return found, nil
 }
@@ -1,6 +1,7 @@
 package data //nolint:testpackage // white-box testing of internal package
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"testing"
@@ -67,8 +68,8 @@ func TestScanAsync(t *testing.T) {
 				}
 			}
 
-			// the iterator will sometimes return `Next()==false` even when there are 7 or 8 keys that should be found on the last loop
-			assert.GreaterOrEqual(t, len(keys), total-9)
+			// no key is skipped between pages; SCAN may return a key more than once while the keyspace is rehashing
+			assert.GreaterOrEqual(t, len(keys), total)
 			assert.False(t, s.scanning.Load())
 		})
 	}
@@ -109,3 +110,254 @@ func TestScanAsyncNonexistent(t *testing.T) {
 
 	assert.Empty(t, keys)
 }
+
+func TestScanOptions(t *testing.T) {
+	t.Parallel()
+
+	s := NewScan("*", 10)
+	assert.Empty(t, s.Scope())
+
+	s = NewScan("*", 10, WithNode("127.0.0.1:30001"))
+	assert.Equal(t, "node 127.0.0.1:30001", s.Scope())
+
+	s = NewScan("*", 10, WithSlots(SlotRange{Start: 5, End: 5}))
+	assert.Equal(t, "slot 5", s.Scope())
+	assert.True(t, s.HasMore())
+
+	s = NewScan("user:*", 10, WithSlots(SlotRange{Start: 0, End: 2}, SlotRange{Start: 10, End: 10}))
+	assert.Equal(t, []int64{0, 1, 2, 10}, s.slots)
+	assert.Equal(t, "4 slots", s.Scope())
+
+	s.slotIdx = len(s.slots)
+	assert.False(t, s.HasMore())
+}
+
+func TestScanAsyncClusterOptionsStandalone(t *testing.T) {
+	_, d := setupTest(t)
+
+	for _, opt := range []ScanOption{WithNode("127.0.0.1:30001"), WithSlots(SlotRange{Start: 0, End: 0})} {
+		keys := make([]*Key, 0, 1)
+		for key := range d.ScanAsync(t.Context(), NewScan("*", 10, opt)) {
+			keys = append(keys, key)
+		}
+		require.Len(t, keys, 1)
+		assert.Equal(t, "error", keys[0].Datatype)
+		assert.Equal(t, ErrNotCluster.Error(), keys[0].Name)
+	}
+}
+
+func TestScanProgress(t *testing.T) {
+	c, d := setupTest(t)
+
+	for i := range 50 {
+		require.NoError(t, c.Set(t.Context(), "progress:"+strconv.Itoa(i), "v", 0).Err())
+	}
+
+	s := NewScan("progress:*", 100)
+	found := 0
+	for range d.ScanAsync(t.Context(), s) {
+		found++
+	}
+
+	progress := s.Progress()
+	require.Len(t, progress, 1)
+	assert.Equal(t, found, progress[0].Found)
+	assert.False(t, progress[0].Scanning)
+}
+
+func TestScanExhausted(t *testing.T) {
+	c, d := setupTest(t)
+
+	for i := range 20 {
+		require.NoError(t, c.Set(t.Context(), "exhausted:"+strconv.Itoa(i), "v", 0).Err())
+	}
+
+	s := NewScan("exhausted:*", 100)
+	assert.True(t, s.HasMore(), "a scan that has not started may have more keys")
+
+	found := 0
+	for range d.ScanAsync(t.Context(), s) {
+		found++
+	}
+	assert.Equal(t, 20, found)
+	assert.True(t, s.Exhausted())
+	assert.False(t, s.HasMore())
+
+	// scanning an exhausted scan finds nothing more
+	for range d.ScanAsync(t.Context(), s) {
+		t.Fatal("unexpected key after the scan was exhausted")
+	}
+	assert.True(t, s.Progress()[0].Exhausted)
+}
+
+func TestScanHasMore(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, NewScan("exact-key", 10).HasMore())
+	assert.True(t, NewScan("prefix:*", 10).HasMore())
+	assert.True(t, NewScan("exact-key", 10, WithNode("127.0.0.1:30001")).HasMore())
+}
+
+func TestScanAsyncGlob(t *testing.T) {
+	c, d := setupTest(t)
+	ctx := t.Context()
+
+	for _, k := range []string{"user:123", "user:223", "user:1234", "order:a1", "order:c1", "star*key"} {
+		require.NoError(t, c.Set(ctx, k, "v", 0).Err())
+	}
+
+	scan := func(s *Scan) []string {
+		var names []string
+		for key := range d.ScanAsync(ctx, s) {
+			names = append(names, key.Name)
+		}
+		return names
+	}
+
+	assert.ElementsMatch(t, []string{"user:123", "user:223"}, scan(NewScan("user:?23", 100)))
+	assert.ElementsMatch(t, []string{"order:a1"}, scan(NewScan("order:[ab]*", 100)))
+	assert.ElementsMatch(t, []string{"star*key"}, scan(NewScan(`star\*key`, 100)))
+	assert.ElementsMatch(t, []string{"star*key"}, scan(NewScan("star*key", 100, WithExact())))
+	assert.Empty(t, scan(NewScan("user:*", 100, WithExact())))
+}
+
+func TestScanAsyncType(t *testing.T) {
+	c, d := setupTest(t)
+	ctx := t.Context()
+
+	require.NoError(t, c.Set(ctx, "typed:string", "v", 0).Err())
+	require.NoError(t, c.HSet(ctx, "typed:hash", "f", "v").Err())
+	require.NoError(t, c.RPush(ctx, "typed:list", "v").Err())
+
+	var names []string
+	for key := range d.ScanAsync(ctx, NewScan("typed:*", 100, WithType("hash"))) {
+		assert.Equal(t, "hash", key.Datatype)
+		names = append(names, key.Name)
+	}
+	assert.Equal(t, []string{"typed:hash"}, names)
+
+	// exact lookups are filtered on the client
+	var keys []*Key
+	for key := range d.ScanAsync(ctx, NewScan("typed:string", 100, WithExact(), WithType("hash"))) {
+		keys = append(keys, key)
+	}
+	assert.Empty(t, keys)
+}
+
+func TestScanMatchesType(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, NewScan("*", 10).matchesType(&Key{Datatype: "set"}))
+	s := NewScan("*", 10, WithType("set"))
+	assert.True(t, s.matchesType(&Key{Datatype: "set"}))
+	assert.False(t, s.matchesType(&Key{Datatype: "hash"}))
+	assert.True(t, s.matchesType(&Key{Datatype: "error"}), "errors are always sent")
+}
+
+// redisError is an error replied by a server.
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+
+func (redisError) RedisError() {}
+
+func TestIsSyntaxError(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isSyntaxError(redisError("ERR syntax error")), "the reply of servers older than Redis 6 to SCAN TYPE")
+	assert.False(t, isSyntaxError(redisError("NOPERM User default has no permissions to run the 'scan' command")))
+	assert.False(t, isSyntaxError(redisError("LOADING Redis is loading the dataset in memory")))
+	assert.False(t, isSyntaxError(context.DeadlineExceeded))
+	assert.False(t, isSyntaxError(nil))
+}
+
+func TestScanMatches(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, NewScan("user:*", 10).Matches("user:1"))
+	assert.False(t, NewScan("user:*", 10).Matches("order:1"))
+	assert.True(t, NewScan("a*b", 10, WithExact()).Matches("a*b"))
+	assert.False(t, NewScan("a*b", 10, WithExact()).Matches("axb"))
+}
+
+func TestInScan(t *testing.T) {
+	t.Parallel()
+
+	d := &Data{}
+	s := NewScan("user:*", 10, WithType("hash"))
+	for _, tt := range []struct {
+		key  Key
+		want bool
+	}{
+		{Key{Name: "user:1", Datatype: "hash"}, true},
+		{Key{Name: "user:1", Datatype: "string"}, false},
+		{Key{Name: "order:1", Datatype: "hash"}, false},
+	} {
+		in, err := d.InScan(t.Context(), s, &tt.key)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, in, tt.key)
+	}
+
+	_, err := d.InScan(t.Context(), NewScan("*", 10, WithSlots(SlotRange{Start: 0, End: 1})), &Key{Name: "user:1"})
+	require.ErrorIs(t, err, ErrNotCluster)
+}
+
+func TestScanThrottle(t *testing.T) {
+	c, d := setupTest(t)
+	ctx := t.Context()
+
+	for i := range 20 {
+		require.NoError(t, c.Set(ctx, "throttle:"+strconv.Itoa(i), "v", 0).Err())
+	}
+
+	s := NewScan("throttle:*", 10, WithThrottle(Throttle{KeysPerSecond: 100}))
+	start := time.Now()
+	var found int
+	for s.HasMore() {
+		for range d.ScanAsync(ctx, s) {
+			found++
+		}
+	}
+	assert.Equal(t, 20, found)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond, "the second batch waits for the first")
+	assert.Positive(t, s.Rate())
+	assert.False(t, s.Throttled())
+}
+
+func TestScanThrottleSparse(t *testing.T) {
+	c, d := setupTest(t)
+	ctx := t.Context()
+
+	for i := range 200 {
+		require.NoError(t, c.Set(ctx, "haystack:"+strconv.Itoa(i), "v", 0).Err())
+	}
+	require.NoError(t, c.Set(ctx, "needle", "v", 0).Err())
+
+	// A page of one match takes many SCAN calls, and each of them is throttled and counted
+	s := NewScan("needle*", 10, WithThrottle(Throttle{Pause: 5 * time.Millisecond}))
+	start := time.Now()
+	var found int
+	for s.HasMore() {
+		for range d.ScanAsync(ctx, s) {
+			found++
+		}
+	}
+	assert.Equal(t, 1, found)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond, "the pause applies between SCAN calls")
+	assert.GreaterOrEqual(t, s.Rate(), 100.0, "the rate counts scanned keys, not matches")
+}
+
+func TestScanMaxLatency(t *testing.T) {
+	c, d := setupTest(t)
+	ctx := t.Context()
+
+	require.NoError(t, c.Set(ctx, "latency:1", "v", 0).Err())
+
+	var keys []*Key
+	for key := range d.ScanAsync(ctx, NewScan("latency:*", 10, WithThrottle(Throttle{MaxLatency: time.Nanosecond}))) {
+		keys = append(keys, key)
+	}
+	require.Len(t, keys, 1)
+	assert.Equal(t, "error", keys[0].Datatype)
+	assert.Contains(t, keys[0].Name, ErrLatencyExceeded.Error())
+}
@@ -0,0 +1,184 @@
+package data
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sethrylan/readis/internal/rdb"
+)
+
+// snapshotProgressInterval is the number of keys read between calls of the progress function of OpenRDB.
+const snapshotProgressInterval = 10000
+
+// snapshot is a source of keys that reads an RDB file. The keys are indexed when the file is opened, and values
+// are read from the file when they are fetched, so that only the metadata of the keys is held in memory.
+type snapshot struct {
+	path    string
+	file    *os.File
+	created time.Time               // when the snapshot was made
+	names   []string                // the names of the keys, sorted
+	index   map[string]*snapshotKey // the keys, by name
+}
+
+// snapshotKey is a key of a snapshot, with the offset of its value in the file.
+type snapshotKey struct {
+	Key
+	offset int64
+}
+
+// OpenRDB creates a Data that browses the keys of database db in an RDB file, such as a dump.rdb copied from a
+// server, without a Redis server. The file is read once to index its keys, and progress, if set, is called with
+// the number of keys read as it is.
+//
+// TTLs are relative to when the snapshot was made, and keys that had already expired are omitted, as Redis omits
+// them when loading the file. Sizes are of the keys and values in the file, which are more compact than in memory.
+// The encoding, idle time and frequency of keys are as they were saved.
+func OpenRDB(path string, db int, progress func(int)) (*Data, error) {
+	f, err := os.Open(path) // #nosec G304 -- the file is given by the user
+	if err != nil {
+		return nil, err
+	}
+	sn, err := indexSnapshot(f, db, progress)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	sn.path, sn.file = path, f
+	return &Data{src: sn}, nil
+}
+
+// indexSnapshot reads the keys of database db in the RDB file.
+func indexSnapshot(r io.Reader, db int, progress func(int)) (*snapshot, error) {
+	rr := rdb.NewReader(bufio.NewReader(r))
+	sn := &snapshot{index: make(map[string]*snapshotKey)}
+	read := 0
+	for {
+		e, err := rr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if read++; progress != nil && read%snapshotProgressInterval == 0 {
+			progress(read)
+		}
+		if sn.created.IsZero() {
+			sn.created = rr.Created()
+			if sn.created.IsZero() {
+				sn.created = time.Now() // files of Redis 3 and older do not record when they were made
+			}
+		}
+		if e.DB != db {
+			continue
+		}
+		ttl := time.Duration(-1)
+		if !e.Expiry.IsZero() {
+			ttl = e.Expiry.Sub(sn.created)
+			if ttl <= 0 {
+				continue // expired before the snapshot was made
+			}
+		}
+		sn.index[e.Key] = &snapshotKey{
+			Key: Key{
+				Name:     e.Key,
+				Datatype: e.Type,
+				Size:     uint64(e.Size), // #nosec G115 -- sizes are positive
+				TTL:      ttl,
+				Details:  &KeyDetails{Length: e.Length, Encoding: e.Encoding, Idle: e.Idle, Freq: e.Freq},
+			},
+			offset: e.Offset,
+		}
+	}
+	if progress != nil {
+		progress(read)
+	}
+	sn.names = slices.Sorted(maps.Keys(sn.index))
+	return sn, nil
+}
+
+func (sn *snapshot) name() string {
+	return sn.path + " · " + sn.created.Format("2006-01-02 15:04")
+}
+
+func (sn *snapshot) close() error {
+	return sn.file.Close()
+}
+
+func (sn *snapshot) count() int64 {
+	return int64(len(sn.names))
+}
+
+// scan returns the next page of keys matching the scan, in the order of their names. Since the names are sorted,
+// only the names starting with the literal prefix of the pattern are read.
+func (sn *snapshot) scan(s *Scan) ([]*Key, error) {
+	if len(s.slots) > 0 || s.node != "" {
+		return nil, ErrNotCluster
+	}
+	if !s.isGlob() {
+		return sn.keys([]string{s.keyName()}), nil
+	}
+
+	n := s.sourceScan(sn.path)
+	prefix := globPrefix(s.Pattern())
+	if n.cursor == 0 {
+		n.cursor = sort.SearchStrings(sn.names, prefix)
+	}
+	var found []*Key
+	for n.cursor < len(sn.names) && len(found) < s.pageSize {
+		name := sn.names[n.cursor]
+		if !strings.HasPrefix(name, prefix) {
+			n.cursor = len(sn.names)
+			break
+		}
+		n.cursor++
+		if k := sn.index[name].Key; s.Matches(name) && s.matchesType(&k) {
+			found = append(found, &k)
+		}
+	}
+	s.updateProgress(n, len(found), false, n.cursor == len(sn.names))
+	return found, nil
+}
+
+func (sn *snapshot) keys(names []string) []*Key {
+	keys := make([]*Key, 0, len(names))
+	for _, name := range names {
+		if k, ok := sn.index[name]; ok {
+			key := k.Key
+			keys = append(keys, &key)
+		}
+	}
+	return keys
+}
+
+func (sn *snapshot) value(name string) (any, error) {
+	k, ok := sn.index[name]
+	if !ok {
+		return nil, fmt.Errorf("no key %q in %s", name, sn.path)
+	}
+	e, err := rdb.ReadValue(sn.file, k.offset)
+	if err != nil {
+		return nil, err
+	}
+	if members, ok := e.Value.([]rdb.ZMember); ok {
+		entries := make([]ZEntry, len(members))
+		for i, m := range members {
+			entries[i] = ZEntry{Member: m.Member, Score: m.Score}
+		}
+		// skiplists are saved from the highest score down; sort as ZRANGE does
+		slices.SortFunc(entries, func(a, b ZEntry) int {
+			return cmp.Or(cmp.Compare(a.Score, b.Score), strings.Compare(a.Member, b.Member))
+		})
+		return entries, nil
+	}
+	return e.Value, nil
+}
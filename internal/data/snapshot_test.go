@@ -0,0 +1,271 @@
+package data //nolint:testpackage // white-box testing of internal package
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	redisTestContainers "github.com/testcontainers/testcontainers-go/modules/redis"
+)
+
+// writeRDB writes an RDB file made at ctime, with strings, a hash and a sorted set, in databases 0 and 1.
+func writeRDB(t *testing.T, ctime time.Time) string {
+	t.Helper()
+	var b bytes.Buffer
+	str := func(s string) {
+		b.WriteByte(byte(len(s))) // the strings are shorter than 64 bytes
+		b.WriteString(s)
+	}
+	expire := func(at time.Time) {
+		b.WriteByte(0xfc)
+		b.Write(binary.LittleEndian.AppendUint64(nil, uint64(at.UnixMilli())))
+	}
+
+	b.WriteString("REDIS0011")
+	b.WriteByte(0xfa) // aux
+	str("ctime")
+	str(strconv.FormatInt(ctime.Unix(), 10))
+	b.WriteByte(0xfe) // select db 0
+	b.WriteByte(0)
+
+	expire(ctime.Add(time.Hour))
+	b.WriteByte(0) // string
+	str("user:1:name")
+	str("ada")
+
+	b.WriteByte(4) // hash
+	str("user:1")
+	b.WriteByte(2)
+	str("name")
+	str("ada")
+	str("age")
+	str("36")
+
+	b.WriteByte(5) // sorted set with binary scores
+	str("scores")
+	b.WriteByte(1)
+	str("ada")
+	b.Write(binary.LittleEndian.AppendUint64(nil, 0x3ff8000000000000)) // 1.5
+
+	expire(ctime.Add(-time.Minute))
+	b.WriteByte(0)
+	str("user:expired")
+	str("gone")
+
+	b.WriteByte(0xfe) // select db 1
+	b.WriteByte(1)
+	b.WriteByte(0)
+	str("user:other")
+	str("db1")
+
+	b.WriteByte(0xff)
+	b.Write(make([]byte, 8)) // no checksum
+
+	path := filepath.Join(t.TempDir(), "dump.rdb")
+	require.NoError(t, os.WriteFile(path, b.Bytes(), 0o600))
+	return path
+}
+
+func TestOpenRDB(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ctime := time.Unix(1_700_000_000, 0)
+	d, err := OpenRDB(writeRDB(t, ctime), 0, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = d.Close() })
+
+	assert.True(t, d.Offline())
+	assert.Contains(t, d.URI(), "dump.rdb")
+	assert.Equal(t, int64(3), d.TotalKeys(ctx), "the expired key and the key of db 1 are omitted")
+
+	var names []string
+	require.NoError(t, d.ScanAll(ctx, NewScan("user:*", 1), func(k *Key) { names = append(names, k.Name) }))
+	assert.Equal(t, []string{"user:1", "user:1:name"}, names)
+
+	names = nil
+	require.NoError(t, d.ScanAll(ctx, NewScan("*", 10, WithType("zset")), func(k *Key) { names = append(names, k.Name) }))
+	assert.Equal(t, []string{"scores"}, names)
+
+	k, err := d.KeyInfo(ctx, "user:1:name")
+	require.NoError(t, err)
+	assert.Equal(t, "string", k.Datatype)
+	assert.Equal(t, time.Hour, k.TTL)
+	assert.Equal(t, "embstr", k.Details.Encoding)
+	assert.Positive(t, k.Size)
+
+	keys, err := d.KeysInfo(ctx, []string{"user:1", "missing"})
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, time.Duration(-1), keys[0].TTL)
+	assert.Equal(t, int64(2), keys[0].Details.Length)
+
+	markdown, err := d.Fetch(ctx, *keys[0])
+	require.NoError(t, err)
+	assert.Equal(t, "| field | value |\n| --- | --- |\n| age | 36 |\n| name | ada |\n", markdown)
+	markdown, err = d.Fetch(ctx, Key{Name: "scores", Datatype: "zset"})
+	require.NoError(t, err)
+	assert.Contains(t, markdown, "| 1.500000 | `ada` |")
+
+	exact := NewScan("user:1", 10, WithExact())
+	keys = nil
+	require.NoError(t, d.ScanAll(ctx, exact, func(k *Key) { keys = append(keys, k) }))
+	require.Len(t, keys, 1)
+
+	require.ErrorContains(t, d.ScanAll(ctx, NewScan("*", 10, WithSlots(SlotRange{Start: 0, End: 1})), func(*Key) {}), ErrNotCluster.Error())
+
+	// operations that need a server are refused, rather than using the missing client
+	assert.Contains(t, d.ReadNode(), "dump.rdb")
+	_, err = d.Channels(ctx, "*")
+	require.ErrorIs(t, err, ErrOffline)
+	_, err = d.Publish(ctx, "channel", "message", false)
+	require.ErrorIs(t, err, ErrOffline)
+	_, err = d.WatchKeyspace(ctx, "*")
+	require.ErrorIs(t, err, ErrOffline)
+	_, err = d.Topology(ctx)
+	require.ErrorIs(t, err, ErrOffline)
+	_, err = d.EstimateKeys(ctx, "*", 10)
+	require.ErrorIs(t, err, ErrOffline)
+	_, err = d.ExpireCold(ctx, []string{"user:1"}, Coldness{}, time.Hour)
+	require.ErrorIs(t, err, ErrOffline)
+	require.ErrorIs(t, d.ExportKeys(ctx, []string{"user:1"}, nil), ErrOffline)
+	require.ErrorIs(t, d.Backup(ctx, NewScan("*", 10), nil, nil), ErrOffline)
+
+	other, err := OpenRDB(writeRDB(t, ctime), 1, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = other.Close() })
+	assert.Equal(t, int64(1), other.TotalKeys(ctx))
+}
+
+// members returns n distinct strings with the prefix, as arguments of a command.
+func members(prefix string, n int) []any {
+	args := make([]any, n)
+	for i := range args {
+		args[i] = prefix + strconv.Itoa(i)
+	}
+	return args
+}
+
+// TestOpenRDBSavedByRedis checks OpenRDB against a file saved by Redis, with a key of each encoding. It runs its
+// own server, since hash field expiries need Redis 7.4.
+func TestOpenRDBSavedByRedis(t *testing.T) {
+	ctx := context.Background()
+
+	container, err := redisTestContainers.Run(ctx, "docker.io/redis:7.4")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = container.Terminate(context.Background()) })
+	uri, err := container.ConnectionString(ctx)
+	require.NoError(t, err)
+	live, err := NewData(uri, false)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = live.Close() })
+	c := live.rc
+
+	encodings := map[string]string{
+		"string:int":         "int",
+		"string:embstr":      "embstr",
+		"string:raw":         "raw",
+		"list:listpack":      "listpack",
+		"list:quicklist":     "quicklist",
+		"set:intset":         "intset",
+		"set:listpack":       "listpack",
+		"set:hashtable":      "hashtable",
+		"zset:listpack":      "listpack",
+		"zset:skiplist":      "skiplist",
+		"hash:listpack":      "listpack",
+		"hash:hashtable":     "hashtable",
+		"hash:listpackex":    "listpackex",
+		"hash:ttl-hashtable": "hashtable",
+		"stream":             "stream",
+	}
+	require.NoError(t, c.Set(ctx, "string:int", "12345", time.Hour).Err())
+	require.NoError(t, c.Set(ctx, "string:embstr", "hello", 0).Err())
+	require.NoError(t, c.Set(ctx, "string:raw", strings.Repeat("compressible ", 20), 0).Err()) // LZF compressed
+	require.NoError(t, c.RPush(ctx, "list:listpack", "a", "b", 1).Err())
+	require.NoError(t, c.RPush(ctx, "list:quicklist", members(strings.Repeat("x", 20), 1000)...).Err())
+	require.NoError(t, c.SAdd(ctx, "set:intset", 1, 2, -3).Err())
+	require.NoError(t, c.SAdd(ctx, "set:listpack", "a", "b").Err())
+	require.NoError(t, c.SAdd(ctx, "set:hashtable", members("m", 200)...).Err())
+	require.NoError(t, c.ZAdd(ctx, "zset:listpack", redis.Z{Score: 1.5, Member: "a"}, redis.Z{Score: math.Inf(1), Member: "b"}).Err())
+	for i := range 200 {
+		require.NoError(t, c.ZAdd(ctx, "zset:skiplist", redis.Z{Score: float64(i) / 3, Member: i}).Err())
+	}
+	require.NoError(t, c.ZAdd(ctx, "zset:skiplist", redis.Z{Score: math.Inf(-1), Member: "min"}).Err())
+	require.NoError(t, c.HSet(ctx, "hash:listpack", "name", "ada", "age", 36).Err())
+	require.NoError(t, c.HSet(ctx, "hash:hashtable", members("f", 400)...).Err())
+	require.NoError(t, c.HSet(ctx, "hash:listpackex", "f1", "v1", "f2", "v2").Err())
+	require.NoError(t, c.HExpire(ctx, "hash:listpackex", time.Hour, "f1").Err())
+	require.NoError(t, c.HSet(ctx, "hash:ttl-hashtable", members("f", 400)...).Err())
+	require.NoError(t, c.HExpire(ctx, "hash:ttl-hashtable", time.Hour, "f0", "f2").Err())
+	for i := range 3 {
+		require.NoError(t, c.XAdd(ctx, &redis.XAddArgs{Stream: "stream", Values: []string{"n", strconv.Itoa(i)}}).Err())
+	}
+	require.NoError(t, c.XGroupCreate(ctx, "stream", "workers", "0").Err())
+	require.NoError(t, c.XReadGroup(ctx, &redis.XReadGroupArgs{Group: "workers", Consumer: "alice", Streams: []string{"stream", ">"}, Count: 1}).Err())
+
+	require.NoError(t, c.Save(ctx).Err())
+	saved, err := container.CopyFileFromContainer(ctx, "/data/dump.rdb")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = saved.Close() })
+	path := filepath.Join(t.TempDir(), "dump.rdb")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	_, err = io.Copy(f, saved)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	d, err := OpenRDB(path, 0, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = d.Close() })
+	assert.Equal(t, int64(len(encodings)), d.TotalKeys(ctx))
+
+	names := slices.Sorted(maps.Keys(encodings))
+	live.SetDetails(DetailLength | DetailEncoding)
+	liveKeys, err := live.KeysInfo(ctx, names)
+	require.NoError(t, err)
+	offlineKeys, err := d.KeysInfo(ctx, names)
+	require.NoError(t, err)
+	require.Len(t, offlineKeys, len(names))
+	require.Len(t, liveKeys, len(names))
+	for i, k := range offlineKeys {
+		name := names[i]
+		assert.Equal(t, encodings[name], liveKeys[i].Details.Encoding, "the encoding of %s on the server", name)
+		assert.Equal(t, liveKeys[i].Details.Encoding, k.Details.Encoding, name)
+		assert.Equal(t, liveKeys[i].Datatype, k.Datatype, name)
+		assert.Equal(t, liveKeys[i].Details.Length, k.Details.Length, name)
+		if liveKeys[i].TTL > 0 {
+			assert.InDelta(t, liveKeys[i].TTL.Seconds(), k.TTL.Seconds(), 60, name)
+		} else {
+			assert.Equal(t, time.Duration(-1), k.TTL, name)
+		}
+	}
+
+	records, err := live.records(ctx, names)
+	require.NoError(t, err)
+	require.Len(t, records, len(names))
+	for _, r := range records {
+		value, err := d.src.value(r.Key)
+		require.NoError(t, err, r.Key)
+		switch r.Type {
+		case "set":
+			assert.ElementsMatch(t, r.Value, value, r.Key)
+		case "stream":
+			assert.Nil(t, value, "stream values are not read")
+		default:
+			assert.Equal(t, r.Value, value, r.Key)
+		}
+	}
+}
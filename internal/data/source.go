@@ -0,0 +1,24 @@
+package data
+
+import "errors"
+
+// ErrOffline is returned by operations that need a Redis server, when keys are read from a file instead;
+// see OpenRDB.
+var ErrOffline = errors.New("not available when browsing a file")
+
+// source is where a Data reads keys from instead of a Redis server; e.g., an RDB file. Sources only support
+// browsing: scanning keys, and reading their metadata and values.
+type source interface {
+	name() string                   // describes the source, in place of the server address
+	close() error                   // releases the source
+	count() int64                   // the number of keys
+	scan(s *Scan) ([]*Key, error)   // the next page of keys found by the scan
+	keys(names []string) []*Key     // the keys with the names, in order, omitting keys that do not exist
+	value(name string) (any, error) // the value of a key, in the form of a Record value
+}
+
+// Offline returns true if keys are read from a file rather than a Redis server, so that only browsing is
+// supported; operations that need a server return ErrOffline.
+func (d *Data) Offline() bool {
+	return d.src != nil
+}
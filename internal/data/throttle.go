@@ -0,0 +1,151 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrLatencyExceeded is returned when a scan is aborted because a node responded slower than the throttle allows.
+var ErrLatencyExceeded = errors.New("server latency above threshold")
+
+// rateWindow is the period over which the scan rate is measured.
+const rateWindow = 5 * time.Second
+
+// Throttle limits the load that a scan puts on the server. The zero value does not throttle.
+type Throttle struct {
+	KeysPerSecond int           // maximum number of keys scanned per second, across all nodes; 0 for no limit
+	Pause         time.Duration // pause between SCAN calls on each node
+	MaxLatency    time.Duration // the scan is aborted if a PING before a SCAN call takes longer; 0 for no limit
+}
+
+// Enabled returns true if any limit is set.
+func (t Throttle) Enabled() bool {
+	return t.KeysPerSecond > 0 || t.Pause > 0 || t.MaxLatency > 0
+}
+
+// WithThrottle limits the rate of a scan.
+func WithThrottle(t Throttle) ScanOption {
+	return func(s *Scan) {
+		s.throttle = t
+	}
+}
+
+// batchRead is a number of keys scanned at a point in time, for measuring the scan rate.
+type batchRead struct {
+	at   time.Time
+	keys int
+}
+
+// Throttled returns true while the scan is waiting because of its throttle.
+func (s *Scan) Throttled() bool {
+	return s.waiting.Load() > 0
+}
+
+// Rate returns the number of keys scanned per second, over the last few seconds; the COUNT of each SCAN,
+// whether or not the keys matched.
+func (s *Scan) Rate() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneReads(time.Now())
+	if len(s.reads) == 0 {
+		return 0
+	}
+	var keys int
+	for _, r := range s.reads {
+		keys += r.keys
+	}
+	elapsed := min(max(time.Since(s.firstRead), time.Second), rateWindow)
+	return float64(keys) / elapsed.Seconds()
+}
+
+// pruneReads drops the reads outside the rate window. The caller must hold s.mu.
+func (s *Scan) pruneReads(now time.Time) {
+	i := 0
+	for i < len(s.reads) && now.Sub(s.reads[i].at) > rateWindow {
+		i++
+	}
+	s.reads = s.reads[i:]
+}
+
+// beforeBatch is called before each SCAN (or CLUSTER GETKEYSINSLOT) on a node. It waits for the pause since the
+// node's previous call, and aborts the scan if the node's latency is above the threshold.
+func (s *Scan) beforeBatch(ctx context.Context, rc *redis.Client) error {
+	addr := rc.Options().Addr
+	if s.throttle.Pause > 0 {
+		s.mu.Lock()
+		last := s.lastBatch[addr]
+		s.mu.Unlock()
+		if !last.IsZero() {
+			if err := s.sleep(ctx, time.Until(last.Add(s.throttle.Pause))); err != nil {
+				return err
+			}
+		}
+	}
+
+	if s.throttle.MaxLatency > 0 {
+		start := time.Now()
+		if err := rc.Ping(ctx).Err(); err != nil {
+			return err
+		}
+		if latency := time.Since(start); latency > s.throttle.MaxLatency {
+			return fmt.Errorf("%w: %s responded in %s", ErrLatencyExceeded, addr, latency.Round(time.Millisecond))
+		}
+	}
+	return nil
+}
+
+// afterBatch records the number of keys scanned on a node.
+func (s *Scan) afterBatch(addr string, keys int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.lastBatch[addr] = now
+	if keys == 0 {
+		return
+	}
+	s.pruneReads(now)
+	if len(s.reads) == 0 {
+		s.firstRead = now
+	}
+	s.reads = append(s.reads, batchRead{at: now, keys: keys})
+}
+
+// waitForKeys waits until n more keys may be scanned without exceeding the keys per second limit.
+// A batch is allowed immediately, and the following batch waits for its share of the limit.
+func (s *Scan) waitForKeys(ctx context.Context, n int) error {
+	if s.throttle.KeysPerSecond <= 0 || n == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	start := time.Now()
+	if s.nextRead.After(start) {
+		start = s.nextRead
+	}
+	s.nextRead = start.Add(time.Duration(n) * time.Second / time.Duration(s.throttle.KeysPerSecond))
+	s.mu.Unlock()
+	return s.sleep(ctx, time.Until(start))
+}
+
+// sleep waits for the duration, or until the context is canceled.
+func (s *Scan) sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	s.waiting.Add(1)
+	defer s.waiting.Add(-1)
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
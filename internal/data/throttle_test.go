@@ -0,0 +1,64 @@
+package data //nolint:testpackage // white-box testing of internal package
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottleEnabled(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, Throttle{}.Enabled())
+	assert.True(t, Throttle{KeysPerSecond: 1}.Enabled())
+	assert.True(t, Throttle{Pause: time.Millisecond}.Enabled())
+	assert.True(t, Throttle{MaxLatency: time.Millisecond}.Enabled())
+}
+
+func TestWaitForKeys(t *testing.T) {
+	t.Parallel()
+
+	s := NewScan("*", 10, WithThrottle(Throttle{KeysPerSecond: 1000}))
+	start := time.Now()
+	require.NoError(t, s.waitForKeys(t.Context(), 50)) // allowed immediately
+	require.NoError(t, s.waitForKeys(t.Context(), 50)) // waits 50ms for the first batch
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	unlimited := NewScan("*", 10)
+	start = time.Now()
+	for range 10 {
+		require.NoError(t, unlimited.waitForKeys(t.Context(), 1000))
+	}
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestWaitForKeysCanceled(t *testing.T) {
+	t.Parallel()
+
+	s := NewScan("*", 10, WithThrottle(Throttle{KeysPerSecond: 1}))
+	require.NoError(t, s.waitForKeys(t.Context(), 100))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	require.ErrorIs(t, s.waitForKeys(ctx, 1), context.Canceled)
+	assert.False(t, s.Throttled())
+}
+
+func TestScanRate(t *testing.T) {
+	t.Parallel()
+
+	s := NewScan("*", 10)
+	assert.Zero(t, s.Rate())
+
+	s.afterBatch("node", 100)
+	s.afterBatch("node", 50)
+	assert.InDelta(t, 150, s.Rate(), 1, "rates are measured over at least a second")
+
+	s.mu.Lock()
+	s.reads[0].at = time.Now().Add(-2 * rateWindow)
+	s.mu.Unlock()
+	assert.InDelta(t, 50, s.Rate(), 1, "reads outside the window are dropped")
+}
@@ -0,0 +1,27 @@
+package rdb
+
+// crc64Poly is the reflected polynomial of CRC-64-Jones, the checksum at the end of RDB files.
+const crc64Poly = 0x95ac9329ac4bc9b5
+
+var crc64Table = func() (table [256]uint64) {
+	for i := range table {
+		crc := uint64(i)
+		for range 8 {
+			if crc&1 == 1 {
+				crc = crc>>1 ^ crc64Poly
+			} else {
+				crc >>= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+// crc64Update adds b to the checksum crc. Unlike hash/crc64, the checksum of Redis is not inverted.
+func crc64Update(crc uint64, b []byte) uint64 {
+	for _, c := range b {
+		crc = crc64Table[byte(crc)^c] ^ crc>>8
+	}
+	return crc
+}
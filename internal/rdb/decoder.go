@@ -0,0 +1,256 @@
+package rdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Length encodings; the top two bits of the first byte of a length, or else the whole byte for 32 and 64 bits.
+const (
+	len6Bit    = 0
+	len14Bit   = 1
+	lenEncoded = 3
+
+	len32 = 0x80
+	len64 = 0x81
+)
+
+// Special encodings of strings, when a length is lenEncoded.
+const (
+	encInt8  = 0
+	encInt16 = 1
+	encInt32 = 2
+	encLZF   = 3
+)
+
+// maxStringLength is the longest string read, so that a corrupt length cannot allocate unbounded memory.
+// It is the largest string that Redis accepts.
+const maxStringLength = 512 << 20
+
+// errTruncated is returned when the file ends in the middle of a value.
+var errTruncated = errors.New("truncated RDB file")
+
+// decoder reads the primitives of the RDB format, counting the bytes read, and computing their checksum.
+type decoder struct {
+	r   *bufio.Reader
+	n   int64  // the number of bytes read
+	crc uint64 // the CRC64 of the bytes read
+	buf [8]byte
+}
+
+func newDecoder(r io.Reader) *decoder {
+	return &decoder{r: bufio.NewReader(r)}
+}
+
+func (d *decoder) readByte() (byte, error) {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return 0, truncated(err)
+	}
+	d.n++
+	d.crc = crc64Table[byte(d.crc)^b] ^ d.crc>>8
+	return b, nil
+}
+
+func (d *decoder) readFull(b []byte) error {
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return truncated(err)
+	}
+	d.n += int64(len(b))
+	d.crc = crc64Update(d.crc, b)
+	return nil
+}
+
+// truncated reports an end of file in the middle of a value as a truncated file.
+func truncated(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return errTruncated
+	}
+	return err
+}
+
+func (d *decoder) readUint32() (uint32, error) {
+	if err := d.readFull(d.buf[:4]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(d.buf[:4]), nil
+}
+
+func (d *decoder) readUint64() (uint64, error) {
+	if err := d.readFull(d.buf[:8]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(d.buf[:8]), nil
+}
+
+// readLength reads a length. If the length is a special encoding of a string, encoded is true, and the length is
+// the encoding.
+func (d *decoder) readLength() (length uint64, encoded bool, err error) {
+	b, err := d.readByte()
+	if err != nil {
+		return 0, false, err
+	}
+	switch b >> 6 {
+	case len6Bit:
+		return uint64(b & 0x3f), false, nil
+	case len14Bit:
+		next, err := d.readByte()
+		return uint64(b&0x3f)<<8 | uint64(next), false, err
+	case lenEncoded:
+		return uint64(b & 0x3f), true, nil
+	}
+	switch b {
+	case len32:
+		if err := d.readFull(d.buf[:4]); err != nil {
+			return 0, false, err
+		}
+		return uint64(binary.BigEndian.Uint32(d.buf[:4])), false, nil
+	case len64:
+		if err := d.readFull(d.buf[:8]); err != nil {
+			return 0, false, err
+		}
+		return binary.BigEndian.Uint64(d.buf[:8]), false, nil
+	default:
+		return 0, false, fmt.Errorf("invalid length encoding 0x%02x", b)
+	}
+}
+
+// readLen reads a length that is not a string.
+func (d *decoder) readLen() (uint64, error) {
+	length, encoded, err := d.readLength()
+	if err == nil && encoded {
+		err = errors.New("unexpected string encoding of a length")
+	}
+	return length, err
+}
+
+// readString reads a string, which may be an integer or LZF compressed.
+func (d *decoder) readString() ([]byte, error) {
+	length, encoded, err := d.readLength()
+	if err != nil {
+		return nil, err
+	}
+	if !encoded {
+		return d.readBytes(length)
+	}
+	switch length {
+	case encInt8:
+		b, err := d.readByte()
+		return strconv.AppendInt(nil, int64(int8(b)), 10), err
+	case encInt16:
+		if err := d.readFull(d.buf[:2]); err != nil {
+			return nil, err
+		}
+		return strconv.AppendInt(nil, int64(int16(binary.LittleEndian.Uint16(d.buf[:2]))), 10), nil
+	case encInt32:
+		v, err := d.readUint32()
+		return strconv.AppendInt(nil, int64(int32(v)), 10), err
+	case encLZF:
+		return d.readLZF()
+	default:
+		return nil, fmt.Errorf("invalid string encoding %d", length)
+	}
+}
+
+func (d *decoder) readBytes(length uint64) ([]byte, error) {
+	if length > maxStringLength {
+		return nil, fmt.Errorf("corrupt RDB file: a string of %d bytes", length)
+	}
+	b := make([]byte, length)
+	return b, d.readFull(b)
+}
+
+// readLZF reads an LZF compressed string.
+func (d *decoder) readLZF() ([]byte, error) {
+	compressed, err := d.readLen()
+	if err != nil {
+		return nil, err
+	}
+	length, err := d.readLen()
+	if err != nil {
+		return nil, err
+	}
+	if length > maxStringLength {
+		return nil, fmt.Errorf("corrupt RDB file: a string of %d bytes", length)
+	}
+	in, err := d.readBytes(compressed)
+	if err != nil {
+		return nil, err
+	}
+	return decompressLZF(in, int(length))
+}
+
+// decompressLZF decompresses LZF data to a string of the given length. Data that would decompress to more than
+// length bytes is corrupt, and is rejected before it is expanded.
+func decompressLZF(in []byte, length int) ([]byte, error) {
+	out := make([]byte, 0, length)
+	for i := 0; i < len(in); {
+		ctrl := int(in[i])
+		i++
+		if ctrl < 1<<5 { // a literal run of ctrl+1 bytes
+			end := i + ctrl + 1
+			if end > len(in) || len(out)+ctrl+1 > length {
+				return nil, errors.New("corrupt LZF string")
+			}
+			out = append(out, in[i:end]...)
+			i = end
+			continue
+		}
+		// a back reference
+		n := ctrl >> 5
+		if n == 7 {
+			if i >= len(in) {
+				return nil, errors.New("corrupt LZF string")
+			}
+			n += int(in[i])
+			i++
+		}
+		if i >= len(in) || len(out)+n+2 > length {
+			return nil, errors.New("corrupt LZF string")
+		}
+		ref := len(out) - (ctrl&0x1f)<<8 - int(in[i]) - 1
+		i++
+		if ref < 0 {
+			return nil, errors.New("corrupt LZF string")
+		}
+		for j := range n + 2 {
+			out = append(out, out[ref+j]) // the reference may overlap the bytes being copied
+		}
+	}
+	if len(out) != length {
+		return nil, fmt.Errorf("corrupt LZF string: %d bytes instead of %d", len(out), length)
+	}
+	return out, nil
+}
+
+// readDouble reads a double of the old zset encoding, as a string with a one byte length.
+func (d *decoder) readDouble() (float64, error) {
+	n, err := d.readByte()
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case 253:
+		return math.NaN(), nil
+	case 254:
+		return math.Inf(1), nil
+	case 255:
+		return math.Inf(-1), nil
+	}
+	b := make([]byte, n)
+	if err := d.readFull(b); err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(string(b), 64)
+}
+
+// readBinaryDouble reads a little endian IEEE 754 double.
+func (d *decoder) readBinaryDouble() (float64, error) {
+	v, err := d.readUint64()
+	return math.Float64frombits(v), err
+}
@@ -0,0 +1,285 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// errCorrupt is returned when a ziplist, listpack, intset or zipmap is malformed.
+var errCorrupt = errors.New("corrupt RDB file")
+
+// ziplistEnd ends ziplists, listpacks and zipmaps.
+const ziplistEnd = 0xff
+
+// parseZiplist returns the entries of a ziplist, the compact encoding of small lists, hashes and sorted sets
+// before Redis 7. Integers are returned as decimal strings.
+//
+// A ziplist is its size in bytes (4 bytes), the offset of its last entry (4 bytes), its number of entries
+// (2 bytes), the entries, and a 0xff byte. Each entry is the length of the previous entry, its encoding, and its
+// string or integer.
+func parseZiplist(b []byte) ([]string, error) {
+	const headerSize = 10
+	if len(b) < headerSize+1 {
+		return nil, fmt.Errorf("%w: ziplist of %d bytes", errCorrupt, len(b))
+	}
+	entries := make([]string, 0, binary.LittleEndian.Uint16(b[8:10]))
+	for i := headerSize; ; {
+		if i >= len(b) {
+			return nil, fmt.Errorf("%w: ziplist without an end", errCorrupt)
+		}
+		if b[i] == ziplistEnd {
+			return entries, nil
+		}
+		// the length of the previous entry
+		if b[i] < 254 {
+			i++
+		} else {
+			i += 5
+		}
+		entry, n, err := ziplistEntry(b, i)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+		i += n
+	}
+}
+
+// ziplistEntry returns the value of the ziplist entry encoded at b[i:], and its size without the length of the
+// previous entry.
+func ziplistEntry(b []byte, i int) (string, int, error) {
+	if i >= len(b) {
+		return "", 0, fmt.Errorf("%w: truncated ziplist entry", errCorrupt)
+	}
+	enc := b[i]
+	str := func(header, length int) (string, int, error) {
+		start, end := i+header, i+header+length
+		if end > len(b) {
+			return "", 0, fmt.Errorf("%w: truncated ziplist entry", errCorrupt)
+		}
+		return string(b[start:end]), header + length, nil
+	}
+	integer := func(size int) (string, int, error) {
+		if i+1+size > len(b) {
+			return "", 0, fmt.Errorf("%w: truncated ziplist entry", errCorrupt)
+		}
+		return strconv.FormatInt(littleEndianInt(b[i+1:i+1+size]), 10), 1 + size, nil
+	}
+	switch {
+	case enc>>6 == 0:
+		return str(1, int(enc&0x3f))
+	case enc>>6 == 1:
+		if i+1 >= len(b) {
+			return "", 0, fmt.Errorf("%w: truncated ziplist entry", errCorrupt)
+		}
+		return str(2, int(enc&0x3f)<<8|int(b[i+1]))
+	case enc == 0x80:
+		if i+5 > len(b) {
+			return "", 0, fmt.Errorf("%w: truncated ziplist entry", errCorrupt)
+		}
+		return str(5, int(binary.BigEndian.Uint32(b[i+1:i+5])))
+	case enc == 0xc0:
+		return integer(2)
+	case enc == 0xd0:
+		return integer(4)
+	case enc == 0xe0:
+		return integer(8)
+	case enc == 0xf0:
+		return integer(3)
+	case enc == 0xfe:
+		return integer(1)
+	case enc >= 0xf1 && enc <= 0xfd:
+		return strconv.Itoa(int(enc&0x0f) - 1), 1, nil
+	default:
+		return "", 0, fmt.Errorf("%w: ziplist encoding 0x%02x", errCorrupt, enc)
+	}
+}
+
+// littleEndianInt returns the signed little endian integer of 1 to 8 bytes.
+func littleEndianInt(b []byte) int64 {
+	var v uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+	shift := 64 - 8*len(b)
+	return int64(v<<shift) >> shift // sign extend
+}
+
+// parseListpack returns the entries of a listpack, the compact encoding of small lists, sets, hashes and sorted
+// sets since Redis 7. Integers are returned as decimal strings.
+//
+// A listpack is its size in bytes (4 bytes), its number of entries (2 bytes), the entries, and a 0xff byte.
+// Each entry is its encoding, its string or integer, and its size, backwards, so it can be read from the end.
+func parseListpack(b []byte) ([]string, error) {
+	const headerSize = 6
+	if len(b) < headerSize+1 {
+		return nil, fmt.Errorf("%w: listpack of %d bytes", errCorrupt, len(b))
+	}
+	entries := make([]string, 0, binary.LittleEndian.Uint16(b[4:6]))
+	for i := headerSize; ; {
+		if i >= len(b) {
+			return nil, fmt.Errorf("%w: listpack without an end", errCorrupt)
+		}
+		if b[i] == ziplistEnd {
+			return entries, nil
+		}
+		entry, n, err := listpackEntry(b, i)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+		i += n + backlenSize(n)
+	}
+}
+
+// listpackEntry returns the value of the listpack entry encoded at b[i:], and its size without its backwards size.
+func listpackEntry(b []byte, i int) (string, int, error) {
+	enc := b[i]
+	need := func(n int) error {
+		if i+n > len(b) {
+			return fmt.Errorf("%w: truncated listpack entry", errCorrupt)
+		}
+		return nil
+	}
+	str := func(header, length int) (string, int, error) {
+		if err := need(header + length); err != nil {
+			return "", 0, err
+		}
+		return string(b[i+header : i+header+length]), header + length, nil
+	}
+	integer := func(size int) (string, int, error) {
+		if err := need(1 + size); err != nil {
+			return "", 0, err
+		}
+		return strconv.FormatInt(littleEndianInt(b[i+1:i+1+size]), 10), 1 + size, nil
+	}
+	switch {
+	case enc>>7 == 0: // 7 bit unsigned integer
+		return strconv.Itoa(int(enc)), 1, nil
+	case enc>>6 == 2: // string of up to 63 bytes
+		return str(1, int(enc&0x3f))
+	case enc>>5 == 6: // 13 bit signed integer
+		if err := need(2); err != nil {
+			return "", 0, err
+		}
+		v := int(enc&0x1f)<<8 | int(b[i+1])
+		if v >= 1<<12 {
+			v -= 1 << 13
+		}
+		return strconv.Itoa(v), 2, nil
+	case enc>>4 == 0xe: // string of up to 4095 bytes
+		if err := need(2); err != nil {
+			return "", 0, err
+		}
+		return str(2, int(enc&0x0f)<<8|int(b[i+1]))
+	case enc == 0xf0: // string with a 32 bit length
+		if err := need(5); err != nil {
+			return "", 0, err
+		}
+		return str(5, int(binary.LittleEndian.Uint32(b[i+1:i+5])))
+	case enc == 0xf1:
+		return integer(2)
+	case enc == 0xf2:
+		return integer(3)
+	case enc == 0xf3:
+		return integer(4)
+	case enc == 0xf4:
+		return integer(8)
+	default:
+		return "", 0, fmt.Errorf("%w: listpack encoding 0x%02x", errCorrupt, enc)
+	}
+}
+
+// backlenSize returns the size of the backwards size of a listpack entry of n bytes, which has 7 bits per byte.
+// As in lpEncodeBacklen, a size that would fill the bytes exactly takes another byte.
+func backlenSize(n int) int {
+	switch {
+	case n <= 127:
+		return 1
+	case n < 16383:
+		return 2
+	case n < 2097151:
+		return 3
+	case n < 268435455:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// parseIntset returns the members of an intset, the encoding of small sets of integers, as decimal strings.
+//
+// An intset is the size of its integers (4 bytes), its number of integers (4 bytes), and the integers.
+func parseIntset(b []byte) ([]string, error) {
+	const headerSize = 8
+	if len(b) < headerSize {
+		return nil, fmt.Errorf("%w: intset of %d bytes", errCorrupt, len(b))
+	}
+	size := int(binary.LittleEndian.Uint32(b[0:4]))
+	count := int(binary.LittleEndian.Uint32(b[4:8]))
+	if (size != 2 && size != 4 && size != 8) || len(b) < headerSize+size*count {
+		return nil, fmt.Errorf("%w: intset of %d integers of %d bytes", errCorrupt, count, size)
+	}
+	members := make([]string, count)
+	for j := range members {
+		start := headerSize + j*size
+		members[j] = strconv.FormatInt(littleEndianInt(b[start:start+size]), 10)
+	}
+	return members, nil
+}
+
+// parseZipmap returns the fields and values of a zipmap, the compact encoding of small hashes before Redis 2.6.
+//
+// A zipmap is its number of entries (1 byte), the entries, and a 0xff byte. Each entry is the length and string
+// of its field, then the length of its value, the number of free bytes after it, and the string of the value.
+func parseZipmap(b []byte) ([]string, error) {
+	var entries []string
+	i := 1
+	next := func(free bool) (string, error) {
+		if i >= len(b) {
+			return "", fmt.Errorf("%w: truncated zipmap", errCorrupt)
+		}
+		length := int(b[i])
+		i++
+		if length == 254 {
+			if i+4 > len(b) {
+				return "", fmt.Errorf("%w: truncated zipmap", errCorrupt)
+			}
+			length = int(binary.LittleEndian.Uint32(b[i : i+4]))
+			i += 4
+		}
+		skip := 0
+		if free {
+			if i >= len(b) {
+				return "", fmt.Errorf("%w: truncated zipmap", errCorrupt)
+			}
+			skip = int(b[i])
+			i++
+		}
+		if i+length > len(b) {
+			return "", fmt.Errorf("%w: truncated zipmap", errCorrupt)
+		}
+		s := string(b[i : i+length])
+		i += length + skip
+		return s, nil
+	}
+	for {
+		if i >= len(b) {
+			return nil, fmt.Errorf("%w: zipmap without an end", errCorrupt)
+		}
+		if b[i] == ziplistEnd {
+			return entries, nil
+		}
+		field, err := next(false)
+		if err != nil {
+			return nil, err
+		}
+		value, err := next(true)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, field, value)
+	}
+}
@@ -0,0 +1,694 @@
+// Package rdb reads Redis RDB snapshot files, such as dump.rdb, without a Redis server.
+package rdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Opcodes, which are read where a value type is expected.
+const (
+	opSlotInfo      = 0xf4 // the sizes of a cluster slot
+	opFunction2     = 0xf5 // a function library
+	opFunctionPreGA = 0xf6 // a function, in the format of the Redis 7 release candidates
+	opModuleAux     = 0xf7 // module data that is not a key
+	opIdle          = 0xf8 // the LRU idle time of the next key
+	opFreq          = 0xf9 // the LFU frequency of the next key
+	opAux           = 0xfa // an auxiliary field; e.g., redis-ver or ctime
+	opResizeDB      = 0xfb // the size hints of a database
+	opExpireTimeMS  = 0xfc // the expiry of the next key, in milliseconds
+	opExpireTime    = 0xfd // the expiry of the next key, in seconds
+	opSelectDB      = 0xfe // the database of the next keys
+	opEOF           = 0xff
+)
+
+// Value types.
+const (
+	typeString            = 0
+	typeList              = 1
+	typeSet               = 2
+	typeZset              = 3
+	typeHash              = 4
+	typeZset2             = 5 // with binary scores
+	typeModulePreGA       = 6
+	typeModule2           = 7
+	typeHashZipmap        = 9
+	typeListZiplist       = 10
+	typeSetIntset         = 11
+	typeZsetZiplist       = 12
+	typeHashZiplist       = 13
+	typeListQuicklist     = 14
+	typeStreamListpacks   = 15
+	typeHashListpack      = 16
+	typeZsetListpack      = 17
+	typeListQuicklist2    = 18
+	typeStreamListpacks2  = 19
+	typeSetListpack       = 20
+	typeStreamListpacks3  = 21
+	typeHashMetadataPreGA = 22
+	typeHashListpackExPre = 23
+	typeHashMetadata      = 24 // with field expiries
+	typeHashListpackEx    = 25 // with field expiries
+)
+
+// Opcodes of module values.
+const (
+	moduleOpEOF    = 0
+	moduleOpSInt   = 1
+	moduleOpUInt   = 2
+	moduleOpFloat  = 3
+	moduleOpDouble = 4
+	moduleOpString = 5
+)
+
+// Containers of the nodes of quicklists.
+const (
+	quicklistPlain  = 1 // a single large element
+	quicklistPacked = 2 // a listpack
+)
+
+// moduleCharset is the charset of the names of module types, which are encoded in their IDs.
+const moduleCharset = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+
+// ErrUnsupported is returned for values that cannot be read, such as module values of the format before Redis 4.
+var ErrUnsupported = errors.New("unsupported RDB value")
+
+// Entry is a key of a snapshot, with its value.
+type Entry struct {
+	DB       int
+	Key      string
+	Type     string        // as TYPE returns it: string, list, set, zset, hash, stream, or the name of a module type
+	Encoding string        // the encoding in the file, as OBJECT ENCODING names it; e.g., listpack or intset
+	Expiry   time.Time     // or the zero time, if the key does not expire
+	Idle     time.Duration // the LRU idle time when the snapshot was made, or -1 if not recorded
+	Freq     int64         // the LFU frequency when the snapshot was made, or -1 if not recorded
+	Length   int64         // the number of elements; for strings, the length, and for module values, -1
+	Offset   int64         // the offset of the key in the file; see ReadValue
+	Size     int64         // the size of the key and its value in the file
+
+	// Value is a string, a []string for lists and sets, a map[string]string for hashes, and a []ZMember for
+	// sorted sets. It is nil for streams and module values, which are skipped.
+	Value any
+}
+
+// ZMember is a member of a sorted set.
+type ZMember struct {
+	Member string
+	Score  float64
+}
+
+// Reader reads the keys of an RDB file, in the order they were written.
+type Reader struct {
+	d       *decoder
+	version int
+	aux     map[string]string
+	db      int
+	done    bool // true once the end of the file has been read
+}
+
+// NewReader creates a reader of the RDB file in r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{d: newDecoder(r), aux: make(map[string]string)}
+}
+
+// Version returns the RDB version of the file, once the first key has been read.
+func (r *Reader) Version() int {
+	return r.version
+}
+
+// Aux returns the auxiliary fields read so far, which precede the keys; e.g., redis-ver, and ctime, the Unix time
+// in seconds when the snapshot was made.
+func (r *Reader) Aux() map[string]string {
+	return r.aux
+}
+
+// Created returns the time the snapshot was made, from the ctime auxiliary field, or the zero time if it is not
+// known.
+func (r *Reader) Created() time.Time {
+	ctime, err := strconv.ParseInt(r.aux["ctime"], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(ctime, 0)
+}
+
+// readHeader reads the magic string and the version.
+func (r *Reader) readHeader() error {
+	var header [9]byte
+	if err := r.d.readFull(header[:]); err != nil || !bytes.HasPrefix(header[:], []byte("REDIS")) {
+		return errors.New("not an RDB file")
+	}
+	version, err := strconv.Atoi(string(header[5:]))
+	if err != nil {
+		return fmt.Errorf("not an RDB file: version %q", header[5:])
+	}
+	r.version = version
+	return nil
+}
+
+// Next returns the next key, or io.EOF after the last key. At the end of the file, the checksum is verified,
+// unless the file was written without one.
+func (r *Reader) Next() (*Entry, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+	if r.version == 0 {
+		if err := r.readHeader(); err != nil {
+			return nil, err
+		}
+	}
+	d := r.d
+	e := &Entry{Idle: -1, Freq: -1}
+	for {
+		op, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case opEOF:
+			r.done = true
+			return nil, r.verifyChecksum()
+		case opSelectDB:
+			db, err := d.readLen()
+			if err != nil {
+				return nil, err
+			}
+			r.db = int(db) // #nosec G115 -- database numbers are small
+		case opResizeDB:
+			err = d.skipLengths(2)
+		case opSlotInfo:
+			err = d.skipLengths(3)
+		case opAux:
+			var key, value []byte
+			if key, err = d.readString(); err == nil {
+				value, err = d.readString()
+				r.aux[string(key)] = string(value)
+			}
+		case opExpireTime:
+			var secs uint32
+			secs, err = d.readUint32()
+			e.Expiry = time.Unix(int64(secs), 0)
+		case opExpireTimeMS:
+			var ms uint64
+			ms, err = d.readUint64()
+			e.Expiry = time.UnixMilli(int64(ms)) // #nosec G115 -- expiry times fit in an int64
+		case opIdle:
+			var idle uint64
+			idle, err = d.readLen()
+			e.Idle = time.Duration(idle) * time.Second // #nosec G115 -- idle times fit in an int64
+		case opFreq:
+			var freq byte
+			freq, err = d.readByte()
+			e.Freq = int64(freq)
+		case opModuleAux:
+			err = d.skipModuleAux()
+		case opFunction2:
+			_, err = d.readString()
+		case opFunctionPreGA:
+			return nil, fmt.Errorf("%w: functions of a Redis 7 release candidate", ErrUnsupported)
+		default:
+			e.DB = r.db
+			e.Offset = d.n - 1
+			if err := d.readEntry(op, e); err != nil {
+				return nil, fmt.Errorf("reading key at offset %d: %w", e.Offset, err)
+			}
+			return e, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// verifyChecksum reads the checksum at the end of the file, and checks it against the bytes read.
+// Files written before RDB version 5, or with rdbchecksum off, have no checksum.
+func (r *Reader) verifyChecksum() error {
+	const checksumVersion = 5
+	if r.version < checksumVersion {
+		return io.EOF
+	}
+	computed := r.d.crc
+	checksum, err := r.d.readUint64()
+	if err != nil {
+		return err
+	}
+	if checksum != 0 && checksum != computed {
+		return fmt.Errorf("corrupt RDB file: checksum %016x, but the file has %016x", computed, checksum)
+	}
+	return io.EOF
+}
+
+// ReadValue reads the key at offset in the RDB file, with its value; e.g., to show the value of a key found by a
+// Reader, whose value was not kept. The returned entry has no database, expiry, idle time or frequency, which
+// precede the key.
+func ReadValue(ra io.ReaderAt, offset int64) (*Entry, error) {
+	d := newDecoder(io.NewSectionReader(ra, offset, math.MaxInt64-offset))
+	t, err := d.readByte()
+	if err != nil {
+		return nil, err
+	}
+	e := &Entry{Idle: -1, Freq: -1, Offset: offset}
+	if err := d.readEntry(t, e); err != nil {
+		return nil, fmt.Errorf("reading key at offset %d: %w", offset, err)
+	}
+	return e, nil
+}
+
+// readEntry reads the key and value of type t into e.
+func (d *decoder) readEntry(t byte, e *Entry) error {
+	start := d.n - 1
+	key, err := d.readString()
+	if err != nil {
+		return err
+	}
+	e.Key = string(key)
+	if err := d.readValue(t, e); err != nil {
+		return err
+	}
+	e.Size = d.n - start
+	return nil
+}
+
+// readValue reads a value of type t into e.
+func (d *decoder) readValue(t byte, e *Entry) error {
+	var err error
+	switch t {
+	case typeString:
+		var s []byte
+		s, err = d.readString()
+		e.Type, e.Encoding, e.Value, e.Length = "string", stringEncoding(s), string(s), int64(len(s))
+	case typeList, typeSet:
+		var elements []string
+		elements, err = d.readStrings(1)
+		e.Type, e.Encoding = "list", "linkedlist"
+		if t == typeSet {
+			e.Type, e.Encoding = "set", "hashtable"
+		}
+		e.setElements(elements)
+	case typeHash:
+		err = d.readHash(e, false)
+	case typeZset, typeZset2:
+		err = d.readZset(t, e)
+	case typeModule2:
+		err = d.readModule(e)
+	case typeModulePreGA:
+		err = fmt.Errorf("%w: a module value of the format before Redis 4", ErrUnsupported)
+	case typeHashZipmap, typeListZiplist, typeSetIntset, typeZsetZiplist, typeHashZiplist, typeHashListpack,
+		typeZsetListpack, typeSetListpack, typeHashListpackEx:
+		err = d.readCompact(t, e)
+	case typeListQuicklist, typeListQuicklist2:
+		err = d.readQuicklist(t, e)
+	case typeStreamListpacks, typeStreamListpacks2, typeStreamListpacks3:
+		err = d.skipStream(t, e)
+	case typeHashMetadata:
+		if _, err = d.readUint64(); err == nil { // the earliest expiry of the fields
+			err = d.readHash(e, true)
+		}
+	case typeHashMetadataPreGA, typeHashListpackExPre:
+		err = fmt.Errorf("%w: a hash with field expiries of a Redis 7.4 release candidate", ErrUnsupported)
+	default:
+		err = fmt.Errorf("%w: type %d", ErrUnsupported, t)
+	}
+	return err
+}
+
+// stringEncoding returns the encoding Redis uses for the string: int, embstr for short strings, or raw.
+func stringEncoding(s []byte) string {
+	const embstrMax = 44
+	if n, err := strconv.ParseInt(string(s), 10, 64); err == nil && strconv.FormatInt(n, 10) == string(s) {
+		return "int"
+	}
+	if len(s) <= embstrMax {
+		return "embstr"
+	}
+	return "raw"
+}
+
+// setElements sets the value of a list or set.
+func (e *Entry) setElements(elements []string) {
+	e.Value, e.Length = elements, int64(len(elements))
+}
+
+// setHash sets the value of a hash from its fields and values, in turn.
+func (e *Entry) setHash(pairs []string) error {
+	if len(pairs)%2 != 0 {
+		return fmt.Errorf("%w: a hash with %d fields and values", errCorrupt, len(pairs))
+	}
+	hash := make(map[string]string, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		hash[pairs[i]] = pairs[i+1]
+	}
+	e.Type, e.Value, e.Length = "hash", hash, int64(len(hash))
+	return nil
+}
+
+// setZset sets the value of a sorted set from its members and scores, in turn.
+func (e *Entry) setZset(pairs []string) error {
+	if len(pairs)%2 != 0 {
+		return fmt.Errorf("%w: a sorted set with %d members and scores", errCorrupt, len(pairs))
+	}
+	members := make([]ZMember, len(pairs)/2)
+	for i := range members {
+		score, err := strconv.ParseFloat(pairs[2*i+1], 64)
+		if err != nil {
+			return fmt.Errorf("%w: score %q", errCorrupt, pairs[2*i+1])
+		}
+		members[i] = ZMember{Member: pairs[2*i], Score: score}
+	}
+	e.Type, e.Value, e.Length = "zset", members, int64(len(members))
+	return nil
+}
+
+// readStrings reads a count, and then count*per strings.
+func (d *decoder) readStrings(per uint64) ([]string, error) {
+	n, err := d.readLen()
+	if err != nil {
+		return nil, err
+	}
+	strs := make([]string, 0, min(n*per, 1<<16))
+	for range n * per {
+		s, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		strs = append(strs, string(s))
+	}
+	return strs, nil
+}
+
+// readHash reads the fields and values of a hash table. With expiries, each field is preceded by its expiry,
+// which is skipped.
+func (d *decoder) readHash(e *Entry, expiries bool) error {
+	n, err := d.readLen()
+	if err != nil {
+		return err
+	}
+	pairs := make([]string, 0, min(2*n, 1<<16))
+	for range n {
+		if expiries {
+			if err := d.skipLengths(1); err != nil {
+				return err
+			}
+		}
+		for range 2 {
+			s, err := d.readString()
+			if err != nil {
+				return err
+			}
+			pairs = append(pairs, string(s))
+		}
+	}
+	e.Encoding = "hashtable"
+	return e.setHash(pairs)
+}
+
+// readZset reads the members and scores of a skiplist sorted set, with string or binary scores.
+func (d *decoder) readZset(t byte, e *Entry) error {
+	n, err := d.readLen()
+	if err != nil {
+		return err
+	}
+	members := make([]ZMember, 0, min(n, 1<<16))
+	for range n {
+		member, err := d.readString()
+		if err != nil {
+			return err
+		}
+		var score float64
+		if t == typeZset2 {
+			score, err = d.readBinaryDouble()
+		} else {
+			score, err = d.readDouble()
+		}
+		if err != nil {
+			return err
+		}
+		members = append(members, ZMember{Member: string(member), Score: score})
+	}
+	e.Type, e.Encoding, e.Value, e.Length = "zset", "skiplist", members, int64(len(members))
+	return nil
+}
+
+// readCompact reads a value stored as a single string in a compact encoding: a zipmap, ziplist, intset or
+// listpack.
+func (d *decoder) readCompact(t byte, e *Entry) error {
+	if t == typeHashListpackEx {
+		if _, err := d.readUint64(); err != nil { // the earliest expiry of the fields
+			return err
+		}
+	}
+	blob, err := d.readString()
+	if err != nil {
+		return err
+	}
+	var entries []string
+	switch t {
+	case typeHashZipmap:
+		e.Encoding = "zipmap"
+		entries, err = parseZipmap(blob)
+	case typeSetIntset:
+		e.Encoding = "intset"
+		entries, err = parseIntset(blob)
+	case typeListZiplist, typeZsetZiplist, typeHashZiplist:
+		e.Encoding = "ziplist"
+		entries, err = parseZiplist(blob)
+	case typeHashListpackEx:
+		e.Encoding = "listpackex"
+		entries, err = parseListpack(blob)
+	default:
+		e.Encoding = "listpack"
+		entries, err = parseListpack(blob)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch t {
+	case typeListZiplist:
+		e.Type = "list"
+		e.setElements(entries)
+	case typeSetIntset, typeSetListpack:
+		e.Type = "set"
+		e.setElements(entries)
+	case typeZsetZiplist, typeZsetListpack:
+		return e.setZset(entries)
+	case typeHashListpackEx:
+		// each field is followed by its value and its expiry, which is dropped
+		if len(entries)%3 != 0 {
+			return fmt.Errorf("%w: a hash with %d fields, values and expiries", errCorrupt, len(entries))
+		}
+		pairs := make([]string, 0, len(entries)/3*2)
+		for i := 0; i < len(entries); i += 3 {
+			pairs = append(pairs, entries[i], entries[i+1])
+		}
+		return e.setHash(pairs)
+	default:
+		return e.setHash(entries)
+	}
+	return nil
+}
+
+// readQuicklist reads a list of nodes, which are ziplists, or since Redis 7, listpacks or single large elements.
+func (d *decoder) readQuicklist(t byte, e *Entry) error {
+	n, err := d.readLen()
+	if err != nil {
+		return err
+	}
+	var elements []string
+	plain := false // whether a node is a single large element
+	for range n {
+		container := uint64(quicklistPacked)
+		if t == typeListQuicklist2 {
+			if container, err = d.readLen(); err != nil {
+				return err
+			}
+		}
+		blob, err := d.readString()
+		if err != nil {
+			return err
+		}
+		var node []string
+		plain = plain || container == quicklistPlain
+		switch {
+		case container == quicklistPlain:
+			node = []string{string(blob)}
+		case t == typeListQuicklist:
+			node, err = parseZiplist(blob)
+		default:
+			node, err = parseListpack(blob)
+		}
+		if err != nil {
+			return err
+		}
+		elements = append(elements, node...)
+	}
+	// Since Redis 7.2, a list of a single listpack is loaded as a listpack, rather than a quicklist.
+	e.Type, e.Encoding = "list", "quicklist"
+	if t == typeListQuicklist2 && n == 1 && !plain {
+		e.Encoding = "listpack"
+	}
+	e.setElements(elements)
+	return nil
+}
+
+// skipStream reads past a stream, keeping only its length. Its entries are in listpacks, followed by its
+// metadata, and its consumer groups with their pending entries and consumers.
+func (d *decoder) skipStream(t byte, e *Entry) error {
+	const rawIDSize = 16 // a stream ID, as two big endian 64 bit integers
+	raw := make([]byte, rawIDSize)
+
+	nodes, err := d.readLen()
+	if err != nil {
+		return err
+	}
+	for range 2 * nodes { // the master ID of each node, and its listpack
+		if _, err := d.readString(); err != nil {
+			return err
+		}
+	}
+	length, err := d.readLen()
+	if err != nil {
+		return err
+	}
+	ids := 2 // the last ID
+	if t >= typeStreamListpacks2 {
+		ids += 5 // the first ID, the maximal deleted ID, and the number of entries added
+	}
+	if err := d.skipLengths(ids); err != nil {
+		return err
+	}
+
+	groups, err := d.readLen()
+	if err != nil {
+		return err
+	}
+	for range groups {
+		if _, err := d.readString(); err != nil { // the name
+			return err
+		}
+		ids := 2 // the last delivered ID
+		if t >= typeStreamListpacks2 {
+			ids++ // the number of entries read
+		}
+		if err := d.skipLengths(ids); err != nil {
+			return err
+		}
+		pending, err := d.readLen()
+		if err != nil {
+			return err
+		}
+		for range pending { // the ID, delivery time and delivery count of each pending entry
+			if err := d.readFull(raw); err != nil {
+				return err
+			}
+			if _, err := d.readUint64(); err != nil {
+				return err
+			}
+			if err := d.skipLengths(1); err != nil {
+				return err
+			}
+		}
+		consumers, err := d.readLen()
+		if err != nil {
+			return err
+		}
+		for range consumers {
+			if _, err := d.readString(); err != nil { // the name
+				return err
+			}
+			times := 1 // the seen time
+			if t >= typeStreamListpacks3 {
+				times++ // the active time
+			}
+			for range times {
+				if _, err := d.readUint64(); err != nil {
+					return err
+				}
+			}
+			pending, err := d.readLen()
+			if err != nil {
+				return err
+			}
+			for range pending {
+				if err := d.readFull(raw); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	e.Type, e.Encoding, e.Length = "stream", "stream", int64(length) // #nosec G115 -- lengths fit in an int64
+	return nil
+}
+
+// readModule reads past a module value, keeping the name of its type.
+func (d *decoder) readModule(e *Entry) error {
+	id, err := d.readLen()
+	if err != nil {
+		return err
+	}
+	e.Type, e.Length = moduleTypeName(id), -1
+	return d.skipModuleValue()
+}
+
+// moduleTypeName returns the name of the module type with the ID, which is its nine characters, six bits each,
+// followed by a ten bit version.
+func moduleTypeName(id uint64) string {
+	const nameLength = 9
+	name := make([]byte, nameLength)
+	id >>= 10
+	for i := nameLength - 1; i >= 0; i-- {
+		name[i] = moduleCharset[id&63]
+		id >>= 6
+	}
+	return string(name)
+}
+
+// skipModuleAux reads past module data that is not a key: the module ID, when it is loaded, and its values.
+func (d *decoder) skipModuleAux() error {
+	if err := d.skipLengths(3); err != nil {
+		return err
+	}
+	return d.skipModuleValue()
+}
+
+// skipModuleValue reads past the values saved by a module, each preceded by its opcode, up to moduleOpEOF.
+func (d *decoder) skipModuleValue() error {
+	for {
+		op, err := d.readLen()
+		if err != nil {
+			return err
+		}
+		switch op {
+		case moduleOpEOF:
+			return nil
+		case moduleOpSInt, moduleOpUInt:
+			_, err = d.readLen()
+		case moduleOpFloat:
+			_, err = d.readUint32()
+		case moduleOpDouble:
+			_, err = d.readUint64()
+		case moduleOpString:
+			_, err = d.readString()
+		default:
+			return fmt.Errorf("%w: module opcode %d", errCorrupt, op)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// skipLengths reads past n lengths.
+func (d *decoder) skipLengths(n int) error {
+	for range n {
+		if _, err := d.readLen(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
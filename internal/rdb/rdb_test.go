@@ -0,0 +1,315 @@
+package rdb //nolint:testpackage // white-box testing of internal package
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rdbWriter writes RDB files for tests.
+type rdbWriter struct {
+	bytes.Buffer
+}
+
+func newRDBWriter(version string) *rdbWriter {
+	w := &rdbWriter{}
+	w.WriteString("REDIS" + version)
+	return w
+}
+
+func (w *rdbWriter) length(n int) {
+	switch {
+	case n < 1<<6:
+		w.WriteByte(byte(n))
+	case n < 1<<14:
+		w.WriteByte(byte(n>>8) | 0x40)
+		w.WriteByte(byte(n))
+	default:
+		w.WriteByte(len32)
+		w.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
+
+func (w *rdbWriter) str(s string) {
+	w.length(len(s))
+	w.WriteString(s)
+}
+
+func (w *rdbWriter) key(t byte, name string) {
+	w.WriteByte(t)
+	w.str(name)
+}
+
+// finish ends the file, with its checksum.
+func (w *rdbWriter) finish() []byte {
+	w.WriteByte(opEOF)
+	w.Write(binary.LittleEndian.AppendUint64(nil, crc64Update(0, w.Bytes())))
+	return w.Bytes()
+}
+
+// listpack encodes strings and small integers as a listpack.
+func listpack(entries ...any) string {
+	var body []byte
+	for _, e := range entries {
+		var entry []byte
+		switch v := e.(type) {
+		case string:
+			entry = append([]byte{0x80 | byte(len(v))}, v...)
+		case int:
+			if v >= 0 && v < 128 {
+				entry = []byte{byte(v)}
+			} else {
+				entry = binary.LittleEndian.AppendUint16([]byte{0xf1}, uint16(int16(v)))
+			}
+		}
+		body = append(body, entry...)
+		body = append(body, make([]byte, backlenSize(len(entry)))...)
+	}
+	b := binary.LittleEndian.AppendUint32(nil, uint32(6+len(body)+1))
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(entries)))
+	return string(append(append(b, body...), ziplistEnd))
+}
+
+// ziplist encodes strings and small integers as a ziplist.
+func ziplist(entries ...any) string {
+	var body []byte
+	for _, e := range entries {
+		body = append(body, 0) // the length of the previous entry, which is not read
+		switch v := e.(type) {
+		case string:
+			body = append(append(body, byte(len(v))), v...)
+		case int:
+			if v >= 0 && v <= 12 {
+				body = append(body, 0xf1+byte(v))
+			} else {
+				body = binary.LittleEndian.AppendUint16(append(body, 0xc0), uint16(int16(v)))
+			}
+		}
+	}
+	b := binary.LittleEndian.AppendUint32(nil, uint32(10+len(body)+1))
+	b = binary.LittleEndian.AppendUint32(b, 0)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(entries)))
+	return string(append(append(b, body...), ziplistEnd))
+}
+
+func readAll(t *testing.T, r *Reader) []*Entry {
+	t.Helper()
+	var entries []*Entry
+	for {
+		e, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return entries
+		}
+		require.NoError(t, err)
+		entries = append(entries, e)
+	}
+}
+
+func TestCRC64(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, uint64(0xe9c6d914c4b8d9ca), crc64Update(0, []byte("123456789")))
+}
+
+func TestDecompressLZF(t *testing.T) {
+	t.Parallel()
+
+	// a literal "ab", then a back reference to it of 9 bytes
+	out, err := decompressLZF([]byte{0x01, 'a', 'b', 0xe0, 0x00, 0x01}, 11)
+	require.NoError(t, err)
+	assert.Equal(t, "ababababab"+"a", string(out))
+
+	_, err = decompressLZF([]byte{0xe0, 0x00, 0x05}, 9)
+	require.Error(t, err)
+
+	// data expanding past the declared length is rejected at the first run that would pass it
+	_, err = decompressLZF([]byte{0x01, 'a', 'b', 0xe0, 0xff, 0x01}, 4)
+	require.ErrorContains(t, err, "corrupt LZF string")
+	_, err = decompressLZF([]byte{0x02, 'a', 'b', 'c'}, 2)
+	require.ErrorContains(t, err, "corrupt LZF string")
+}
+
+func TestReader(t *testing.T) {
+	t.Parallel()
+
+	expiry := time.UnixMilli(1_700_000_060_000)
+	w := newRDBWriter("0011")
+	w.WriteByte(opAux)
+	w.str("ctime")
+	w.str("1700000000")
+	w.WriteByte(opSelectDB)
+	w.length(0)
+	w.WriteByte(opResizeDB)
+	w.length(10)
+	w.length(1)
+
+	w.WriteByte(opExpireTimeMS)
+	w.Write(binary.LittleEndian.AppendUint64(nil, uint64(expiry.UnixMilli())))
+	w.WriteByte(opIdle)
+	w.length(90)
+	w.key(typeString, "greeting")
+	w.str("hello")
+
+	w.WriteByte(opFreq)
+	w.WriteByte(5)
+	w.key(typeString, "counter")
+	w.Write([]byte{0xc0 | encInt16, 0x39, 0x30}) // 12345
+
+	w.key(typeListQuicklist2, "queue")
+	w.length(2)
+	w.length(quicklistPacked)
+	w.str(listpack("a", 5, -300))
+	w.length(quicklistPlain)
+	w.str("large")
+
+	w.key(typeSetIntset, "ids")
+	intset := binary.LittleEndian.AppendUint32(nil, 2)
+	intset = binary.LittleEndian.AppendUint32(intset, 3)
+	for _, v := range []int16{-3, 1, 2} {
+		intset = binary.LittleEndian.AppendUint16(intset, uint16(v))
+	}
+	w.str(string(intset))
+
+	w.key(typeZsetListpack, "scores")
+	w.str(listpack("ada", "1.5", "bob", 2))
+
+	w.key(typeHashZiplist, "user:1")
+	w.str(ziplist("name", "ada", "age", 36))
+
+	w.key(typeHash, "user:2")
+	w.length(1)
+	w.str("name")
+	w.str("bob")
+
+	w.key(typeZset2, "ranks")
+	w.length(1)
+	w.str("x")
+	w.Write(binary.LittleEndian.AppendUint64(nil, 0x4004000000000000)) // 2.5
+
+	w.key(typeStreamListpacks3, "events")
+	w.length(1) // a node
+	w.str(strings.Repeat("\x00", 16))
+	w.str(listpack("fields"))
+	w.length(3) // the length
+	w.length(1) // the last ID
+	w.length(0)
+	w.Write(make([]byte, 5)) // the first ID, maximal deleted ID and entries added
+	w.length(1)              // a group
+	w.str("workers")
+	w.Write([]byte{1, 0, 3})    // the last ID and entries read
+	w.length(1)                 // a pending entry
+	w.Write(make([]byte, 16+8)) // its ID and delivery time
+	w.length(1)                 // its delivery count
+	w.length(1)                 // a consumer
+	w.str("alice")
+	w.Write(make([]byte, 8+8)) // the seen and active times
+	w.length(1)                // a pending entry
+	w.Write(make([]byte, 16))
+
+	w.key(typeListQuicklist2, "small")
+	w.length(1)
+	w.length(quicklistPacked)
+	w.str(listpack("x"))
+
+	w.WriteByte(opSelectDB)
+	w.length(1)
+	w.key(typeString, "other")
+	w.str("db1")
+
+	file := w.finish()
+	r := NewReader(bytes.NewReader(file))
+	entries := readAll(t, r)
+	assert.Equal(t, 11, r.Version())
+	assert.Equal(t, time.Unix(1_700_000_000, 0), r.Created())
+	require.Len(t, entries, 11)
+
+	greeting := entries[0]
+	assert.Equal(t, "string", greeting.Type)
+	assert.Equal(t, "embstr", greeting.Encoding)
+	assert.Equal(t, "hello", greeting.Value)
+	assert.True(t, expiry.Equal(greeting.Expiry))
+	assert.Equal(t, 90*time.Second, greeting.Idle)
+	assert.Equal(t, int64(-1), greeting.Freq)
+
+	assert.Equal(t, "12345", entries[1].Value)
+	assert.Equal(t, "int", entries[1].Encoding)
+	assert.Equal(t, int64(5), entries[1].Freq)
+	assert.True(t, entries[1].Expiry.IsZero())
+
+	assert.Equal(t, []string{"a", "5", "-300", "large"}, entries[2].Value)
+	assert.Equal(t, "quicklist", entries[2].Encoding)
+	assert.Equal(t, int64(4), entries[2].Length)
+	assert.Equal(t, []string{"-3", "1", "2"}, entries[3].Value)
+	assert.Equal(t, "intset", entries[3].Encoding)
+	assert.Equal(t, []ZMember{{Member: "ada", Score: 1.5}, {Member: "bob", Score: 2}}, entries[4].Value)
+	assert.Equal(t, map[string]string{"name": "ada", "age": "36"}, entries[5].Value)
+	assert.Equal(t, "ziplist", entries[5].Encoding)
+	assert.Equal(t, map[string]string{"name": "bob"}, entries[6].Value)
+	assert.Equal(t, []ZMember{{Member: "x", Score: 2.5}}, entries[7].Value)
+
+	events := entries[8]
+	assert.Equal(t, "stream", events.Type)
+	assert.Equal(t, int64(3), events.Length)
+	assert.Nil(t, events.Value)
+
+	assert.Equal(t, []string{"x"}, entries[9].Value)
+	assert.Equal(t, "listpack", entries[9].Encoding, "a list of one listpack is loaded as a listpack")
+
+	assert.Equal(t, 1, entries[10].DB)
+	assert.Equal(t, "db1", entries[10].Value)
+
+	for _, e := range entries {
+		read, err := ReadValue(bytes.NewReader(file), e.Offset)
+		require.NoError(t, err)
+		assert.Equal(t, e.Key, read.Key)
+		assert.Equal(t, e.Value, read.Value)
+		assert.Equal(t, e.Size, read.Size)
+	}
+
+	_, err := r.Next()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestReaderChecksum(t *testing.T) {
+	t.Parallel()
+
+	w := newRDBWriter("0009")
+	w.key(typeString, "a")
+	w.str("value")
+	file := w.finish()
+
+	corrupt := bytes.Clone(file)
+	corrupt[len(corrupt)-10] = 'X' // the last byte of the value, before the EOF opcode and checksum
+	r := NewReader(bytes.NewReader(corrupt))
+	_, err := r.Next()
+	require.NoError(t, err, "the key is read before the checksum")
+	_, err = r.Next()
+	require.ErrorContains(t, err, "checksum")
+
+	unchecked := bytes.Clone(corrupt)
+	copy(unchecked[len(unchecked)-8:], make([]byte, 8)) // written with rdbchecksum off
+	assert.Len(t, readAll(t, NewReader(bytes.NewReader(unchecked))), 1)
+
+	_, err = NewReader(bytes.NewReader(file[:len(file)-12])).Next()
+	require.ErrorIs(t, err, errTruncated)
+
+	_, err = NewReader(strings.NewReader("not an rdb file")).Next()
+	require.ErrorContains(t, err, "not an RDB file")
+}
+
+func TestModuleTypeName(t *testing.T) {
+	t.Parallel()
+
+	var id uint64
+	for _, c := range "ReJSON-RL" {
+		id = id<<6 | uint64(strings.IndexRune(moduleCharset, c))
+	}
+	assert.Equal(t, "ReJSON-RL", moduleTypeName(id<<10|3))
+}
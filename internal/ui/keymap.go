@@ -42,3 +42,91 @@ func NewListKeyMap() *ListKeyMap {
 		),
 	}
 }
+
+// AppKeyMap defines application-wide key bindings for switching between screens.
+type AppKeyMap struct {
+	PubSub  key.Binding
+	Cluster key.Binding
+	Live    key.Binding
+	Exact   key.Binding
+	Type    key.Binding
+	Memory  key.Binding
+	Columns key.Binding
+	Filter  key.Binding
+	Sort    key.Binding
+	Tree    key.Binding
+	Reverse key.Binding
+	Reports key.Binding
+	Mark    key.Binding
+	Export  key.Binding
+	Back    key.Binding
+}
+
+// NewAppKeyMap creates a new AppKeyMap. Screen bindings use ctrl or alt combinations,
+// since letter keys are reserved for the text inputs; none of them may clash with the
+// editing keys of textinput.DefaultKeyMap. The suggestion keys (tab, ctrl+n and ctrl+p)
+// are exempt, since no text input shows suggestions.
+func NewAppKeyMap() *AppKeyMap {
+	return &AppKeyMap{
+		PubSub: key.NewBinding(
+			key.WithKeys("ctrl+p"),
+			key.WithHelp("ctrl+p", "pub/sub"),
+		),
+		Cluster: key.NewBinding(
+			key.WithKeys("ctrl+g"),
+			key.WithHelp("ctrl+g", "cluster"),
+		),
+		Live: key.NewBinding(
+			key.WithKeys("ctrl+l"),
+			key.WithHelp("ctrl+l", "live updates"),
+		),
+		Exact: key.NewBinding(
+			key.WithKeys("ctrl+x"),
+			key.WithHelp("ctrl+x", "exact match"),
+		),
+		Type: key.NewBinding(
+			key.WithKeys("tab", "shift+tab"),
+			key.WithHelp("tab", "filter type"),
+		),
+		Memory: key.NewBinding(
+			key.WithKeys("ctrl+o"),
+			key.WithHelp("ctrl+o", "size column"),
+		),
+		Columns: key.NewBinding(
+			key.WithKeys("alt+c"),
+			key.WithHelp("alt+c", "columns"),
+		),
+		Filter: key.NewBinding(
+			key.WithKeys("alt+l"),
+			key.WithHelp("alt+l", "filter loaded keys"),
+		),
+		Sort: key.NewBinding(
+			key.WithKeys("ctrl+s"),
+			key.WithHelp("ctrl+s", "sort"),
+		),
+		Reverse: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("ctrl+r", "reverse sort"),
+		),
+		Tree: key.NewBinding(
+			key.WithKeys("ctrl+n"),
+			key.WithHelp("ctrl+n", "namespaces"),
+		),
+		Reports: key.NewBinding(
+			key.WithKeys("alt+r"),
+			key.WithHelp("alt+r", "reports"),
+		),
+		Mark: key.NewBinding(
+			key.WithKeys("alt+m"),
+			key.WithHelp("alt+m", "mark"),
+		),
+		Export: key.NewBinding(
+			key.WithKeys("alt+e"),
+			key.WithHelp("alt+e", "export"),
+		),
+		Back: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "back"),
+		),
+	}
+}